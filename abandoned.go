@@ -0,0 +1,133 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+type pullRequestLabel struct {
+	Name string
+}
+
+// When the PR was closed without merging, as of the end date.
+func (pr pullRequest) closedUnmergedAt(endDate time.Time) (time.Time, bool) {
+	if pr.stateAt(endDate) != prClosed {
+		return time.Time{}, false
+	}
+
+	closedAt := pr.ClosedAt
+	for _, e := range pr.stateEvents() {
+		if e.state == prClosed && !e.at.After(endDate) {
+			closedAt = e.at
+		}
+	}
+
+	return closedAt, !closedAt.IsZero()
+}
+
+// The three labels most used by the PRs, most used first.
+func commonLabels(prs []pullRequest) string {
+	counts := make(map[string]int)
+	for _, pr := range prs {
+		for _, label := range pr.Labels.Nodes {
+			counts[label.Name]++
+		}
+	}
+
+	var labels []string
+	for label := range counts {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if counts[labels[i]] != counts[labels[j]] {
+			return counts[labels[i]] > counts[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+
+	if len(labels) > 3 {
+		labels = labels[:3]
+	}
+
+	return strings.Join(labels, ", ")
+}
+
+// PRs closed without merging, per author and per repository: work that was
+// thrown away. Nil when there is none.
+func abandonedSections(prs []pullRequest, endDate time.Time) []*reportSection {
+	type group struct {
+		prs       int
+		abandoned []pullRequest
+		timesOpen []float64
+	}
+
+	byAuthor := make(map[string]*group)
+	byRepo := make(map[string]*group)
+	total := 0
+
+	for _, pr := range prs {
+		for _, groups := range []struct {
+			m   map[string]*group
+			key string
+		}{{byAuthor, pr.Author.Login}, {byRepo, pr.Repository.NameWithOwner}} {
+			if groups.m[groups.key] == nil {
+				groups.m[groups.key] = &group{}
+			}
+			g := groups.m[groups.key]
+			g.prs++
+
+			if closedAt, ok := pr.closedUnmergedAt(endDate); ok {
+				g.abandoned = append(g.abandoned, pr)
+				g.timesOpen = append(g.timesOpen, float64(closedAt.Sub(pr.CreatedAt)))
+			}
+		}
+
+		if _, ok := pr.closedUnmergedAt(endDate); ok {
+			total++
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	section := func(name, key string, groups map[string]*group) *reportSection {
+		s := &reportSection{
+			Name:     name,
+			Title:    name,
+			Parent:   "Abandoned PRs",
+			Header:   table.Row{key, "Total PRs", "Closed unmerged", "Closed unmerged (%)", "Median time open", "Common labels"},
+			Centered: []int{2, 3, 4, 5},
+		}
+
+		var keys []string
+		for k, g := range groups {
+			if len(g.abandoned) > 0 {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			g := groups[k]
+			s.Rows = append(s.Rows, table.Row{
+				k,
+				g.prs,
+				len(g.abandoned),
+				percentCell(len(g.abandoned), g.prs),
+				durationCell(g.timesOpen, 50),
+				commonLabels(g.abandoned),
+			})
+		}
+
+		return s
+	}
+
+	return []*reportSection{
+		section("Abandoned PRs by author", "ID", byAuthor),
+		section("Abandoned PRs by repository", "Repository", byRepo),
+	}
+}
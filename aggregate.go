@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// The PRs created, or merged, closed or updated with --select, after start,
+// counted as they were at end.
+type window struct {
+	start time.Time
+	end   time.Time
+}
+
+func (w window) contains(t time.Time) bool {
+	return t.After(w.start) && !t.After(w.end)
+}
+
+// The numbers of an author behind the rows of the GitHub section, before
+// any formatting, thresholds or overlays.
+type userStats struct {
+	login          string
+	prs            []pullRequest
+	counts         prCounts
+	comments       int
+	reviewsGiven   int
+	reviewRequests int
+}
+
+// Aggregates the PRs whose selected event, like created, falls in the window
+// per author, sorted by login. It only depends on its arguments, so every
+// metric built on it can be checked against a handful of PRs.
+func aggregate(prs []pullRequest, w window, selection string) []userStats {
+	var inWindow []pullRequest
+	for _, pr := range prs {
+		if w.contains(pr.selectedBy(selection)) {
+			inWindow = append(inWindow, pr)
+		}
+	}
+
+	byAuthor := make(map[string][]pullRequest)
+	for _, pr := range inWindow {
+		byAuthor[pr.Author.Login] = append(byAuthor[pr.Author.Login], pr)
+	}
+
+	given, requested := reviewActivity(inWindow)
+
+	var stats []userStats
+	for login, authorPRs := range byAuthor {
+		user := userStats{
+			login:          login,
+			prs:            authorPRs,
+			counts:         countPRs(authorPRs, w.end),
+			reviewsGiven:   given[login],
+			reviewRequests: requested[login],
+		}
+		for _, pr := range authorPRs {
+			user.comments += pr.TotalCommentsCount
+		}
+
+		stats = append(stats, user)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].login < stats[j].login
+	})
+
+	return stats
+}
+
+// The sum of the stats, as the team's.
+func totalStats(stats []userStats) userStats {
+	var total userStats
+	for _, user := range stats {
+		total.prs = append(total.prs, user.prs...)
+		total.counts.prs += user.counts.prs
+		total.counts.merged += user.counts.merged
+		total.counts.open += user.counts.open
+		total.counts.closedUnmerged += user.counts.closedUnmerged
+		total.counts.addedLines += user.counts.addedLines
+		total.counts.removedLines += user.counts.removedLines
+		total.counts.changedFiles += user.counts.changedFiles
+		total.comments += user.comments
+		total.reviewsGiven += user.reviewsGiven
+		total.reviewRequests += user.reviewRequests
+	}
+
+	return total
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func reviewedPR(pr pullRequest, state string, reviewers ...string) pullRequest {
+	for _, reviewer := range reviewers {
+		var review pullRequestReview
+		review.Author.Login = reviewer
+		review.State = state
+		review.SubmittedAt = pr.CreatedAt.Add(1)
+		pr.Reviews.Nodes = append(pr.Reviews.Nodes, review)
+	}
+
+	return pr
+}
+
+func requestedPR(pr pullRequest, reviewers ...string) pullRequest {
+	for _, reviewer := range reviewers {
+		var item timelineItem
+		item.Typename = "ReviewRequestedEvent"
+		item.ReviewRequestedEvent.CreatedAt = pr.CreatedAt
+		item.ReviewRequestedEvent.RequestedReviewer.User.Login = reviewer
+		pr.TimelineItems.Nodes = append(pr.TimelineItems.Nodes, item)
+	}
+
+	return pr
+}
+
+func TestAggregate(t *testing.T) {
+	created := testStart.AddDate(0, 0, 2)
+	merged := created.AddDate(0, 0, 1)
+
+	commented := testPR("ana", created)
+	commented.TotalCommentsCount = 3
+
+	mergedBeforeWindow := mergedPR("ana", testStart.AddDate(0, 0, -5), testStart.AddDate(0, 0, 5))
+	createdAfterEnd := testPR("ana", testEnd.AddDate(0, 0, 1))
+
+	tests := []struct {
+		name      string
+		prs       []pullRequest
+		selection string
+		metric    func(userStats) int
+		want      map[string]int
+	}{
+		{
+			name:      "no PRs",
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.counts.prs },
+			want:      map[string]int{},
+		},
+		{
+			name:      "PRs per author",
+			prs:       []pullRequest{testPR("ana", created), testPR("bob", created), testPR("ana", created)},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.counts.prs },
+			want:      map[string]int{"ana": 2, "bob": 1},
+		},
+		{
+			name:      "PRs outside the window",
+			prs:       []pullRequest{testPR("ana", testStart), createdAfterEnd, testPR("bob", created)},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.counts.prs },
+			want:      map[string]int{"bob": 1},
+		},
+		{
+			name:      "merged",
+			prs:       []pullRequest{mergedPR("ana", created, merged), mergedPR("ana", created, testEnd.AddDate(0, 0, 1)), testPR("bob", created)},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.counts.merged },
+			want:      map[string]int{"ana": 1, "bob": 0},
+		},
+		{
+			name:      "open",
+			prs:       []pullRequest{mergedPR("ana", created, testEnd.AddDate(0, 0, 1)), testPR("ana", created), closedPR("bob", created, merged)},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.counts.open },
+			want:      map[string]int{"ana": 2, "bob": 0},
+		},
+		{
+			name:      "closed unmerged",
+			prs:       []pullRequest{closedPR("ana", created, merged), mergedPR("ana", created, merged)},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.counts.closedUnmerged },
+			want:      map[string]int{"ana": 1},
+		},
+		{
+			name:      "added lines",
+			prs:       []pullRequest{testPR("ana", created), testPR("ana", created)},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.counts.addedLines },
+			want:      map[string]int{"ana": 20},
+		},
+		{
+			name:      "comments",
+			prs:       []pullRequest{commented, testPR("bob", created)},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.comments },
+			want:      map[string]int{"ana": 3, "bob": 0},
+		},
+		{
+			name: "reviews given, without the author's own and the pending ones",
+			prs: []pullRequest{
+				reviewedPR(testPR("ana", created), "APPROVED", "bob", "ana"),
+				reviewedPR(testPR("ana", created), "PENDING", "bob"),
+				reviewedPR(testPR("bob", created), "COMMENTED", "ana"),
+			},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.reviewsGiven },
+			want:      map[string]int{"ana": 1, "bob": 1},
+		},
+		{
+			name: "review requests, once per PR",
+			prs: []pullRequest{
+				requestedPR(testPR("ana", created), "bob", "bob"),
+				requestedPR(testPR("bob", created), "ana"),
+				requestedPR(testPR("bob", created), "ana"),
+			},
+			selection: selectCreated,
+			metric:    func(u userStats) int { return u.reviewRequests },
+			want:      map[string]int{"ana": 2, "bob": 1},
+		},
+		{
+			name:      "selected by merge date",
+			prs:       []pullRequest{mergedBeforeWindow, testPR("bob", created), mergedPR("bob", created, testEnd.AddDate(0, 0, 1))},
+			selection: selectMerged,
+			metric:    func(u userStats) int { return u.counts.prs },
+			want:      map[string]int{"ana": 1},
+		},
+		{
+			name:      "selected by close date",
+			prs:       []pullRequest{closedPR("ana", testStart.AddDate(0, 0, -5), merged), mergedPR("bob", created, merged), testPR("bob", created)},
+			selection: selectClosed,
+			metric:    func(u userStats) int { return u.counts.prs },
+			want:      map[string]int{"ana": 1, "bob": 1},
+		},
+	}
+
+	for _, test := range tests {
+		got := make(map[string]int)
+		for _, user := range aggregate(test.prs, window{testStart, testEnd}, test.selection) {
+			got[user.login] = test.metric(user)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAggregateSortsByLogin(t *testing.T) {
+	created := testStart.AddDate(0, 0, 2)
+	prs := []pullRequest{testPR("cy", created), testPR("ana", created), testPR("bob", created)}
+
+	var logins []string
+	for _, user := range aggregate(prs, window{testStart, testEnd}, selectCreated) {
+		logins = append(logins, user.login)
+	}
+
+	if want := []string{"ana", "bob", "cy"}; !reflect.DeepEqual(logins, want) {
+		t.Errorf("got %v, want %v", logins, want)
+	}
+}
+
+func TestTotalStats(t *testing.T) {
+	created := testStart.AddDate(0, 0, 2)
+
+	tests := []struct {
+		name string
+		prs  []pullRequest
+		want prCounts
+	}{
+		{"no users", nil, prCounts{}},
+		{
+			name: "sum of the authors",
+			prs:  []pullRequest{mergedPR("ana", created, created.AddDate(0, 0, 1)), testPR("bob", created), closedPR("bob", created, created.AddDate(0, 0, 1))},
+			want: prCounts{prs: 3, merged: 1, open: 1, closedUnmerged: 1, addedLines: 30, removedLines: 12, changedFiles: 6},
+		},
+	}
+
+	for _, test := range tests {
+		total := totalStats(aggregate(test.prs, window{testStart, testEnd}, selectCreated))
+		if total.counts != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, total.counts, test.want)
+		}
+		if len(total.prs) != test.want.prs {
+			t.Errorf("%s: %d PRs, want %d", test.name, len(total.prs), test.want.prs)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The buckets of time open, the last one without limit.
+var agingBuckets = []struct {
+	label string
+	upTo  time.Duration
+}{
+	{"0-1d", 24 * time.Hour},
+	{"1-3d", 3 * 24 * time.Hour},
+	{"3-7d", 7 * 24 * time.Hour},
+	{"> 7d", 0},
+}
+
+func agingBucket(open time.Duration) int {
+	for i, bucket := range agingBuckets {
+		if bucket.upTo == 0 || open < bucket.upTo {
+			return i
+		}
+	}
+
+	return len(agingBuckets) - 1
+}
+
+// How long the PR was open by the end date, and whether it was open at any
+// time of the window.
+func (pr pullRequest) openDuring(initialDate, endDate time.Time) (time.Duration, bool) {
+	if pr.CreatedAt.After(endDate) {
+		return 0, false
+	}
+
+	until := endDate
+	if at, ok := pr.decidedAt(endDate); ok {
+		if !at.After(initialDate) {
+			return 0, false
+		}
+		until = at
+	}
+
+	return until.Sub(pr.CreatedAt), true
+}
+
+// The distribution of the time open of the PRs active in the window, per
+// repository, and the longest open ones. PRs created before the window are
+// only known when they were fetched, e.g. with --select updated or fetch.
+func agingSections(prs []pullRequest, initialDate, endDate time.Time) []*reportSection {
+	type active struct {
+		pr   pullRequest
+		open time.Duration
+	}
+
+	byRepo := make(map[string][]int)
+	var all []active
+	for _, pr := range prs {
+		open, ok := pr.openDuring(initialDate, endDate)
+		if !ok {
+			continue
+		}
+
+		repo := pr.Repository.NameWithOwner
+		if byRepo[repo] == nil {
+			byRepo[repo] = make([]int, len(agingBuckets))
+		}
+		byRepo[repo][agingBucket(open)]++
+		all = append(all, active{pr, open})
+	}
+
+	aging := &reportSection{
+		Name:   "PR aging",
+		Title:  "PR aging",
+		Header: table.Row{"Repository", "Active PRs"},
+	}
+	for _, bucket := range agingBuckets {
+		aging.Header = append(aging.Header, bucket.label)
+	}
+	for column := 2; column <= len(aging.Header); column++ {
+		aging.Centered = append(aging.Centered, column)
+	}
+
+	var repos []string
+	for repo := range byRepo {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	totals := make([]int, len(agingBuckets))
+	for _, repo := range repos {
+		row := table.Row{repo, 0}
+		for i, count := range byRepo[repo] {
+			row = append(row, count)
+			row[1] = row[1].(int) + count
+			totals[i] += count
+		}
+		aging.Rows = append(aging.Rows, row)
+	}
+
+	if len(repos) > 1 {
+		aging.Footer = table.Row{"Total", len(all)}
+		for _, count := range totals {
+			aging.Footer = append(aging.Footer, count)
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].open > all[j].open
+	})
+	if len(all) > 10 {
+		all = all[:10]
+	}
+
+	longest := &reportSection{
+		Name:     "Longest open PRs",
+		Title:    "Longest open PRs",
+		Header:   table.Row{"URL", "Author", "Repository", "Time open", "State"},
+		Centered: []int{4, 5},
+	}
+	for _, a := range all {
+		longest.Rows = append(longest.Rows, table.Row{a.pr.Url, a.pr.Author.Login, a.pr.Repository.NameWithOwner, duration(a.open), a.pr.stateAt(endDate)})
+	}
+
+	return []*reportSection{aging, longest}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// A note on a day or a range of days, like an incident week or an offsite, so
+// the dips of the trends carry their context.
+type annotation struct {
+	Start time.Time
+	End   time.Time
+	Text  string
+}
+
+// The annotations of the store, read in main.
+var annotations []annotation
+
+func (a annotation) label() string {
+	if a.End.Equal(a.Start) {
+		return a.Start.Format("2006-01-02") + ": " + a.Text
+	}
+
+	return a.Start.Format("2006-01-02") + ".." + a.End.Format("2006-01-02") + ": " + a.Text
+}
+
+// Whether any of its days falls in the window.
+func (a annotation) overlaps(initialDate, endDate time.Time) bool {
+	return !a.Start.After(endDate) && !a.End.Add(time.Hour*24-time.Second).Before(initialDate)
+}
+
+func annotationsIn(initialDate, endDate time.Time) []annotation {
+	var in []annotation
+	for _, a := range annotations {
+		if a.overlaps(initialDate, endDate) {
+			in = append(in, a)
+		}
+	}
+
+	return in
+}
+
+func (s *store) annotationsPath() string {
+	return filepath.Join(s.dir, "annotations.json")
+}
+
+func (s *store) annotations() ([]annotation, error) {
+	content, err := os.ReadFile(s.annotationsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var list []annotation
+	if err := json.Unmarshal(content, &list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (s *store) annotate(a annotation) error {
+	list, err := s.annotations()
+	if err != nil {
+		return err
+	}
+
+	list = append(list, a)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Start.Before(list[j].Start)
+	})
+
+	content, _ := json.MarshalIndent(list, "", "  ")
+	return os.WriteFile(s.annotationsPath(), content, 0o644)
+}
+
+// pull-metrics annotate <date>[..<date>] <text> adds a note, and without
+// arguments lists them.
+func annotateCommand(s *store, args []string) {
+	s = requireStore(s)
+
+	if len(args) == 0 {
+		list, err := s.annotations()
+		if err != nil {
+			log.Fatalf("Error reading the annotations: %v", err)
+		}
+
+		t := table.NewWriter()
+		t.AppendHeader(table.Row{"Start", "End", "Text"})
+		for _, a := range list {
+			t.AppendRow(table.Row{a.Start.Format("2006-01-02"), a.End.Format("2006-01-02"), a.Text})
+		}
+		fmt.Println(t.Render())
+		return
+	}
+
+	text := strings.TrimSpace(strings.Join(args[1:], " "))
+	if text == "" {
+		fatalf(exitConfig, "pull-metrics annotate <date>[..<date>] <text>. E.g.: pull-metrics annotate 2024-03-10 prod incident week")
+	}
+
+	start, end, isRange := strings.Cut(args[0], "..")
+	a := annotation{Text: text}
+	var err error
+	if a.Start, err = time.Parse("2006-1-2", start); err != nil {
+		fatalf(exitConfig, "Invalid annotation date %q: %v", args[0], err)
+	}
+	a.End = a.Start
+	if isRange {
+		if a.End, err = time.Parse("2006-1-2", end); err != nil {
+			fatalf(exitConfig, "Invalid annotation date %q: %v", args[0], err)
+		}
+		if a.End.Before(a.Start) {
+			fatalf(exitConfig, "Invalid annotation date %q: it ends before it starts", args[0])
+		}
+	}
+
+	if err := s.annotate(a); err != nil {
+		log.Fatalf("Error saving the annotation: %v", err)
+	}
+
+	fmt.Printf("Annotated %s\n", a.label())
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type asanaTask struct {
+	Gid         string
+	Name        string
+	Assignee    *struct{ Name string }
+	Completed   bool
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// A story of a task, the section_changed ones moving it between sections.
+type asanaStory struct {
+	ResourceSubtype string                 `json:"resource_subtype"`
+	CreatedAt       time.Time              `json:"created_at"`
+	NewSection      *struct{ Name string } `json:"new_section"`
+}
+
+type asanaClient struct {
+	baseUrl string
+	token   string
+	client  *http.Client
+}
+
+func (c *asanaClient) get(path string, out interface{}) {
+	req, err := http.NewRequest("GET", c.baseUrl+"/api/1.0"+path, nil)
+	if err != nil {
+		fatalf(exitConfig, "Error parsing ASANA_URL: %v", err)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+c.token)
+	req.Header.Add("Accept", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		fatalf(exitError, "Error requesting %s to Asana: %v", path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		fatalf(exitAuth, "Asana rejected the credentials: %s", res.Status)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		fatalf(exitError, "Error requesting %s to Asana: %s", path, res.Status)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		fatalf(exitError, "Error decoding %s from Asana: %v", path, err)
+	}
+}
+
+// The sections of ASANA_STARTED_SECTIONS, In Progress by default, and of
+// ASANA_DONE_SECTIONS, Done by default, lower-cased.
+func asanaSections(variable, fallback string) map[string]bool {
+	names := statusList(getenv(variable))
+	if len(names) == 0 {
+		names = []string{fallback}
+	}
+
+	sections := make(map[string]bool)
+	for _, name := range names {
+		sections[strings.ToLower(name)] = true
+	}
+
+	return sections
+}
+
+// The tasks of ASANA_PROJECTS not completed before the window, started when
+// first moved to a started section and completed when marked so or moved to a
+// done section. They count for their assignee.
+func fetchAsanaTasks(initialDate, endDate time.Time) ([]jiraIssue, bool) {
+	token := getenv("ASANA_TOKEN")
+	if token == "" {
+		return nil, false
+	}
+
+	projects := statusList(getenv("ASANA_PROJECTS"))
+	if len(projects) == 0 {
+		fmt.Fprintln(progress, "ASANA_PROJECTS not provided. Skipping Asana.")
+		return nil, false
+	}
+
+	baseUrl := strings.TrimSuffix(getenv("ASANA_URL"), "/")
+	if baseUrl == "" {
+		baseUrl = "https://app.asana.com"
+	}
+
+	started := asanaSections("ASANA_STARTED_SECTIONS", "In Progress")
+	done := asanaSections("ASANA_DONE_SECTIONS", "Done")
+
+	c := &asanaClient{baseUrl: baseUrl, token: token, client: newHTTPClient("ASANA")}
+
+	var issues []jiraIssue
+	for _, project := range projects {
+		var details struct {
+			Data struct{ Name string }
+		}
+		c.get("/projects/"+url.PathEscape(project)+"?opt_fields=name", &details)
+		name := details.Data.Name
+		if name == "" {
+			name = project
+		}
+
+		query := url.Values{
+			"opt_fields":      {"name,assignee.name,completed,completed_at"},
+			"completed_since": {initialDate.Format(time.RFC3339)},
+			"limit":           {"100"},
+		}
+
+		for page := 1; ; page++ {
+			fmt.Fprintf(progress, "Requesting page %d of the tasks of %s to Asana\n", page, name)
+
+			var result struct {
+				Data     []asanaTask
+				NextPage *struct{ Offset string } `json:"next_page"`
+			}
+			c.get("/projects/"+url.PathEscape(project)+"/tasks?"+query.Encode(), &result)
+
+			for _, task := range result.Data {
+				if task.Assignee == nil {
+					continue
+				}
+
+				var stories struct {
+					Data []asanaStory
+				}
+				c.get("/tasks/"+task.Gid+"/stories?opt_fields=resource_subtype,created_at,new_section.name", &stories)
+
+				var startedAt, completedAt time.Time
+				for _, story := range stories.Data {
+					if story.ResourceSubtype != "section_changed" || story.NewSection == nil || story.CreatedAt.After(endDate) {
+						continue
+					}
+
+					section := strings.ToLower(story.NewSection.Name)
+					if started[section] && (startedAt.IsZero() || story.CreatedAt.Before(startedAt)) {
+						startedAt = story.CreatedAt
+					}
+					if done[section] && story.CreatedAt.After(completedAt) {
+						completedAt = story.CreatedAt
+					}
+				}
+				if task.Completed && task.CompletedAt != nil {
+					completedAt = *task.CompletedAt
+				}
+				if startedAt.IsZero() && completedAt.IsZero() {
+					continue
+				}
+
+				var issue jiraIssue
+				issue.Key = task.Gid
+				issue.Fields.Summary = task.Name
+				issue.Fields.Project.Key = name
+				issue.Fields.Assignee.DisplayName = task.Assignee.Name
+				issue.Fields.IssueType.Name = "Task"
+				issue.setWorked(startedAt, completedAt)
+
+				issue.Source = "asana"
+				issues = append(issues, issue)
+			}
+
+			if result.NextPage == nil || result.NextPage.Offset == "" {
+				break
+			}
+			query.Set("offset", result.NextPage.Offset)
+		}
+	}
+
+	return issues, true
+}
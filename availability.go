@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type availabilityEntry struct {
+	person       string
+	start        time.Time
+	end          time.Time
+	availability float64
+}
+
+type availabilityCalendar struct {
+	entries  []availabilityEntry
+	holidays *holidayCalendar
+}
+
+// The file has one line per absence or part-time period:
+// person,start,end[,availability]. Availability is the fraction of a working
+// day the person is around (0 for vacation, 0.5 for a half-time contract) and
+// defaults to 0. The public holidays are days off too.
+func loadAvailability(holidays *holidayCalendar) *availabilityCalendar {
+	path := getenv("AVAILABILITY_FILE")
+	if path == "" {
+		if holidays == nil {
+			return nil
+		}
+		return &availabilityCalendar{holidays: holidays}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fatalf(exitConfig, "Error opening AVAILABILITY_FILE: %v", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		fatalf(exitConfig, "Error reading AVAILABILITY_FILE: %v", err)
+	}
+
+	calendar := &availabilityCalendar{holidays: holidays}
+	for i, record := range records {
+		if len(record) < 3 {
+			fatalf(exitConfig, "AVAILABILITY_FILE line %d: expected person,start,end[,availability]", i+1)
+		}
+
+		start, err := parseDateOrTime(strings.TrimSpace(record[1]), false)
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			fatalf(exitConfig, "AVAILABILITY_FILE line %d: %v", i+1, err)
+		}
+
+		end, err := parseDateOrTime(strings.TrimSpace(record[2]), true)
+		if err != nil {
+			fatalf(exitConfig, "AVAILABILITY_FILE line %d: %v", i+1, err)
+		}
+
+		entry := availabilityEntry{
+			person: strings.ToLower(strings.TrimSpace(record[0])),
+			start:  start,
+			end:    end,
+		}
+
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			if entry.availability, err = strconv.ParseFloat(strings.TrimSpace(record[3]), 64); err != nil {
+				fatalf(exitConfig, "AVAILABILITY_FILE line %d: %v", i+1, err)
+			}
+		}
+
+		calendar.entries = append(calendar.entries, entry)
+	}
+
+	return calendar
+}
+
+func isWorkingDay(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// Returns the number of working days between the dates the person was
+// available, matching any of the given identities.
+func (calendar *availabilityCalendar) workingDays(initialDate, endDate time.Time, identities ...string) float64 {
+	total := 0.0
+
+	for day := initialDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		if !isWorkingDay(day) || (calendar.holidays != nil && calendar.holidays.isHoliday(day, identities...)) {
+			continue
+		}
+
+		available := 1.0
+		for _, entry := range calendar.entries {
+			if day.Before(entry.start) || day.After(entry.end) || !entry.matches(identities) {
+				continue
+			}
+
+			if entry.availability < available {
+				available = entry.availability
+			}
+		}
+
+		total += available
+	}
+
+	return total
+}
+
+func (entry availabilityEntry) matches(identities []string) bool {
+	for _, identity := range identities {
+		if identity != "" && strings.ToLower(identity) == entry.person {
+			return true
+		}
+	}
+
+	return false
+}
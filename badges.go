@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The JSON of a shields.io endpoint badge:
+// https://img.shields.io/endpoint?url=https://metrics.example.com/badges/repo/owner/repo/review-time.json
+type badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// The first review by somebody other than the author, up to the end date.
+func (pr pullRequest) firstReviewAt(endDate time.Time) (time.Time, bool) {
+	var first time.Time
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login == pr.Author.Login || review.State == "PENDING" || review.SubmittedAt.After(endDate) {
+			continue
+		}
+
+		if first.IsZero() || review.SubmittedAt.Before(first) {
+			first = review.SubmittedAt
+		}
+	}
+
+	return first, !first.IsZero()
+}
+
+func badgeColor(ok bool) string {
+	if ok {
+		return "brightgreen"
+	}
+
+	return "orange"
+}
+
+func prBadges(prs []pullRequest, endDate time.Time) map[string]badge {
+	counts := countPRs(prs, endDate)
+
+	var reviewTimes, mergeTimes []float64
+	for _, pr := range prs {
+		if pr.isDraftAt(endDate) {
+			continue
+		}
+
+		if at, ok := pr.firstReviewAt(endDate); ok {
+			reviewTimes = append(reviewTimes, float64(at.Sub(pr.readyForReviewAt())))
+		}
+
+		if pr.stateAt(endDate) == prMerged {
+			mergeTimes = append(mergeTimes, float64(pr.MergedAt.Sub(pr.CreatedAt)))
+		}
+	}
+
+	badges := map[string]badge{
+		"prs": {1, "PRs", fmt.Sprint(counts.prs), "blue"},
+	}
+
+	if rate, ok := counts.mergeRate(); ok {
+		badges["merge-rate"] = badge{1, "merge rate", rate.String(), badgeColor(float64(rate) >= thresholds.minMergeRate)}
+	}
+
+	if len(reviewTimes) > 0 {
+		review := time.Duration(median(reviewTimes))
+		badges["review-time"] = badge{1, "median review time", duration(review).String(), badgeColor(review <= reviewSLA())}
+	}
+
+	if len(mergeTimes) > 0 {
+		badges["merge-time"] = badge{1, "median time to merge", duration(time.Duration(median(mergeTimes))).String(), "blue"}
+	}
+
+	return badges
+}
+
+// The badges of every repository and author, keyed by their path:
+// repo/<owner>/<repo>/<metric> and author/<login>/<metric>. The authors who
+// opted out get none.
+func reportBadges(prs []pullRequest, endDate time.Time) map[string]badge {
+	byRepo := make(map[string][]pullRequest)
+	byAuthor := make(map[string][]pullRequest)
+	for _, pr := range prs {
+		byRepo[pr.Repository.NameWithOwner] = append(byRepo[pr.Repository.NameWithOwner], pr)
+		if !isOptedOut(pr.Author.Login) {
+			byAuthor[pr.Author.Login] = append(byAuthor[pr.Author.Login], pr)
+		}
+	}
+
+	badges := make(map[string]badge)
+	for repo, repoPRs := range byRepo {
+		for metric, b := range prBadges(repoPRs, endDate) {
+			badges["repo/"+repo+"/"+metric] = b
+		}
+	}
+	for login, authorPRs := range byAuthor {
+		for metric, b := range prBadges(authorPRs, endDate) {
+			badges["author/"+login+"/"+metric] = b
+		}
+	}
+
+	return badges
+}
+
+// Writes the badges as <BADGES_DIR>/<path>.json, for a static site or bucket.
+func writeBadges(badges map[string]badge) {
+	dir := getenv("BADGES_DIR")
+	if dir == "" {
+		return
+	}
+
+	var paths []string
+	for path := range badges {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		file := filepath.Join(dir, filepath.FromSlash(path)+".json")
+		if !strings.HasPrefix(file, filepath.Clean(dir)+string(filepath.Separator)) {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			fatalf(exitConfig, "Error creating BADGES_DIR: %v", err)
+		}
+
+		content, _ := json.Marshal(badges[path])
+		if err := os.WriteFile(file, content, 0o644); err != nil {
+			fatalf(exitConfig, "Error writing %s: %v", file, err)
+		}
+	}
+
+	fmt.Fprintf(progress, "%d badges written to %s\n", len(paths), dir)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// BUG_SLAS is a comma-separated list of priority=duration, e.g.
+// "P1=24h,P2=72h,Highest=24h", the time to resolve the bugs of that priority.
+func loadBugSLAs() map[string]time.Duration {
+	slas := make(map[string]time.Duration)
+	for _, entry := range strings.Split(getenv("BUG_SLAS"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		priority, value, ok := strings.Cut(entry, "=")
+		sla, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || sla <= 0 {
+			fatalf(exitConfig, "Invalid BUG_SLAS entry %q: expected priority=duration, like P1=24h", entry)
+		}
+		slas[strings.ToLower(strings.TrimSpace(priority))] = sla
+	}
+
+	return slas
+}
+
+// The issue types of BUG_ISSUE_TYPES, Bug by default.
+func bugIssueTypes() []string {
+	types := statusList(getenv("BUG_ISSUE_TYPES"))
+	if len(types) == 0 {
+		return []string{"Bug"}
+	}
+
+	return types
+}
+
+func (issue jiraIssue) isBug() bool {
+	for _, bugType := range bugIssueTypes() {
+		if strings.EqualFold(issue.Fields.IssueType.Name, bugType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// With BUG_SLAS, the bugs resolved in the window and the ones still open at
+// its end.
+func bugJQL(jiraProject string, initialDate, endDate time.Time) string {
+	var quoted []string
+	for _, bugType := range bugIssueTypes() {
+		quoted = append(quoted, `"`+bugType+`"`)
+	}
+
+	end := endDate.AddDate(0, 0, 1).Format("2006-01-02")
+	return fmt.Sprintf(`project in (%s) and issuetype in (%s) and (resolved >= "%s" and resolved < "%s" or resolution is EMPTY and created < "%s") ORDER BY key ASC`,
+		jiraProject, strings.Join(quoted, ", "), initialDate.Format("2006-01-02"), end, end)
+}
+
+// The bugs with an SLA for their priority resolved in the window, or open past
+// it at the end date, per project and assignee.
+func bugSLASection(issues []jiraIssue, initialDate, endDate time.Time) *reportSection {
+	slas := loadBugSLAs()
+	if len(slas) == 0 {
+		return nil
+	}
+
+	type slaStats struct {
+		resolved int
+		breached int
+		open     int
+	}
+
+	stats := make(map[string]*slaStats)
+	total := &slaStats{}
+	for _, issue := range issues {
+		sla, ok := slas[strings.ToLower(issue.Fields.Priority.Name)]
+		if !issue.isBug() || !ok {
+			continue
+		}
+
+		created, err := time.Parse(jiraTimeLayout, issue.Fields.Created)
+		if err != nil || created.After(endDate) {
+			continue
+		}
+
+		assignee := issue.Fields.Assignee.DisplayName
+		if assignee == "" {
+			assignee = "Unassigned"
+		}
+		key := issue.Fields.Project.Key + "\n" + assignee
+
+		resolved, err := time.Parse(jiraTimeLayout, issue.Fields.ResolutionDate)
+		isResolved := err == nil && !resolved.After(endDate)
+
+		var s *slaStats
+		switch {
+		case isResolved && !resolved.Before(initialDate):
+			if stats[key] == nil {
+				stats[key] = &slaStats{}
+			}
+			s = stats[key]
+			s.resolved++
+			total.resolved++
+			if resolved.Sub(created) > sla {
+				s.breached++
+				total.breached++
+			}
+		case !isResolved && endDate.Sub(created) > sla:
+			if stats[key] == nil {
+				stats[key] = &slaStats{}
+			}
+			s = stats[key]
+			s.open++
+			total.open++
+		}
+	}
+
+	section := &reportSection{
+		Name:     "Bug SLA",
+		Title:    "Bug resolution SLA",
+		Summary:  "Bugs resolved in the window against the SLA of their priority in BUG_SLAS, and the ones still open past it",
+		Header:   table.Row{"Project", "Assignee", "Resolved bugs", "Breached", "Within SLA (%)", "Open past SLA"},
+		Centered: []int{3, 4, 5, 6},
+	}
+
+	breachCell := func(n int) interface{} {
+		if n > 0 {
+			return flagged{n, warning}
+		}
+		return n
+	}
+
+	for _, key := range sortedKeys(stats) {
+		s := stats[key]
+		project, assignee, _ := strings.Cut(key, "\n")
+		section.Rows = append(section.Rows, table.Row{project, assignee, s.resolved, breachCell(s.breached), percentCell(s.resolved-s.breached, s.resolved), breachCell(s.open)})
+	}
+
+	if len(stats) > 0 {
+		section.Footer = table.Row{"Total", "", total.resolved, total.breached, percentCell(total.resolved-total.breached, total.resolved), total.open}
+	}
+
+	section.Outputs = map[string]string{
+		"jira_bug_sla_breaches": fmt.Sprint(total.breached + total.open),
+	}
+
+	return section
+}
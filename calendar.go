@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The periods of REPORTING_CALENDAR, for --range:
+//   - month (default) and week, starting on Mondays.
+//   - sprint: SPRINT_DAYS long, from CALENDAR_START.
+//   - 4-4-5, 4-5-4 or 5-4-4: fiscal months of that many weeks per quarter, in
+//     years of 52 weeks from CALENDAR_START. Move CALENDAR_START when the
+//     fiscal year has a 53rd week.
+type reportingCalendar struct {
+	kind  string
+	start time.Time
+	days  int
+	weeks []int
+}
+
+func loadCalendar() reportingCalendar {
+	c := reportingCalendar{kind: strings.ToLower(getenv("REPORTING_CALENDAR"))}
+	if c.kind == "" {
+		c.kind = "month"
+	}
+
+	switch c.kind {
+	case "month", "week":
+		return c
+	case "sprint":
+		c.days = envInt("SPRINT_DAYS", 14)
+	case "4-4-5", "4-5-4", "5-4-4":
+		for _, weeks := range strings.Split(c.kind, "-") {
+			n, _ := strconv.Atoi(weeks)
+			c.weeks = append(c.weeks, n)
+		}
+	default:
+		fatalf(exitConfig, "Unknown REPORTING_CALENDAR %q, expected month, week, sprint, 4-4-5, 4-5-4 or 5-4-4", c.kind)
+	}
+
+	start, err := time.Parse("2006-1-2", getenv("CALENDAR_START"))
+	if err != nil {
+		fatalf(exitConfig, "REPORTING_CALENDAR %s needs CALENDAR_START, the first day of a sprint or fiscal year, like 2024-02-04", c.kind)
+	}
+	c.start = start
+
+	return c
+}
+
+// Rounds down, so the days before CALENDAR_START belong to earlier periods.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+
+	return a / b
+}
+
+// The first day of the period containing day, and of the next one.
+func (c reportingCalendar) period(day time.Time) (time.Time, time.Time) {
+	switch c.kind {
+	case "week":
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	case "sprint":
+		days := int(day.Sub(c.start).Hours() / 24)
+		start := c.start.AddDate(0, 0, floorDiv(days, c.days)*c.days)
+		return start, start.AddDate(0, 0, c.days)
+	case "4-4-5", "4-5-4", "5-4-4":
+		weeks := floorDiv(int(day.Sub(c.start).Hours()/24), 7)
+		start := c.start.AddDate(0, 0, floorDiv(weeks, 52)*52*7)
+		for remaining := weeks - floorDiv(weeks, 52)*52; ; {
+			for _, length := range c.weeks {
+				if remaining < length {
+					return start, start.AddDate(0, 0, length*7)
+				}
+				remaining -= length
+				start = start.AddDate(0, 0, length*7)
+			}
+		}
+	}
+
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// The window of --range: current-period, up to now, or last-period, the
+// last complete one.
+func rangeWindow(name string) (time.Time, time.Time) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	calendar := loadCalendar()
+
+	start, _ := calendar.period(today)
+	switch name {
+	case "current-period":
+		return start, now
+	case "last-period":
+		previous, _ := calendar.period(start.AddDate(0, 0, -1))
+		return previous, start.Add(-time.Second)
+	}
+
+	fatalf(exitConfig, "Unknown range %q, expected current-period or last-period", name)
+	return time.Time{}, time.Time{}
+}
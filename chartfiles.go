@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// Writes the weekly merge rate, the box plot of the cycle time per repository
+// and the PRs per team, or per repository without teams in the roster, for
+// slide decks. CHART_FORMATS is a comma-separated list of png and svg.
+func (data *fetchedData) writeChartFiles(dir string, initialDate, endDate time.Time) error {
+	people := data.people
+	people.initialDate, people.endDate = initialDate, endDate
+	prs := windowPRs(data.prs, initialDate, endDate, people)
+
+	plots := map[string]func() (*plot.Plot, error){
+		"merge-rate-trend": func() (*plot.Plot, error) { return mergeRatePlot(prs, initialDate, endDate) },
+		"cycle-time":       func() (*plot.Plot, error) { return cycleTimePlot(prs, endDate) },
+		"team-comparison":  func() (*plot.Plot, error) { return teamComparisonPlot(prs, endDate, people) },
+	}
+
+	formats := getenv("CHART_FORMATS")
+	if formats == "" {
+		formats = "png,svg"
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for name, newPlot := range plots {
+		p, err := newPlot()
+		if err != nil {
+			return fmt.Errorf("drawing %s: %v", name, err)
+		}
+
+		for _, format := range strings.Split(formats, ",") {
+			format = strings.ToLower(strings.TrimSpace(format))
+			if format != "png" && format != "svg" {
+				fatalf(exitConfig, "Invalid CHART_FORMATS %q: expected png and svg", formats)
+			}
+
+			if err := p.Save(8*vg.Inch, 4*vg.Inch, filepath.Join(dir, name+"."+format)); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintf(progress, "Charts written to %s\n", dir)
+	return nil
+}
+
+// The share of the PRs of every week of the window that were merged by its
+// end.
+func mergeRatePlot(prs []pullRequest, initialDate, endDate time.Time) (*plot.Plot, error) {
+	p := plot.New()
+	p.Title.Text = tr("Merge rate trend")
+	p.Y.Label.Text = tr("Merged PRs (%)")
+	p.Y.Min, p.Y.Max = 0, 100
+	p.X.Tick.Marker = plot.TimeTicks{Format: currentLocale.dateLayout}
+
+	week := 7 * 24 * time.Hour
+	var points plotter.XYs
+	for start := initialDate; start.Before(endDate); start = start.Add(week) {
+		total, merged := 0, 0
+		for _, pr := range prs {
+			if at := pr.selectedAt(); at.Before(start) || !at.Before(start.Add(week)) {
+				continue
+			}
+			total++
+			if pr.stateAt(endDate) == prMerged {
+				merged++
+			}
+		}
+
+		if total > 0 {
+			points = append(points, plotter.XY{X: float64(start.Unix()), Y: float64(merged) * 100 / float64(total)})
+		}
+	}
+
+	if len(points) == 0 {
+		return p, annotatePlot(p, initialDate, endDate)
+	}
+
+	line, scatter, err := plotter.NewLinePoints(points)
+	if err != nil {
+		return nil, err
+	}
+	p.Add(line, scatter, plotter.NewGrid())
+
+	return p, annotatePlot(p, initialDate, endDate)
+}
+
+// Marks the annotations of the window with a dashed line on their first day
+// and their text at the top.
+func annotatePlot(p *plot.Plot, initialDate, endDate time.Time) error {
+	var marks plotter.XYLabels
+	for _, a := range annotationsIn(initialDate, endDate) {
+		at := a.Start
+		if at.Before(initialDate) {
+			at = initialDate
+		}
+		x := float64(at.Unix())
+
+		mark, err := plotter.NewLine(plotter.XYs{{X: x, Y: p.Y.Min}, {X: x, Y: p.Y.Max}})
+		if err != nil {
+			return err
+		}
+		mark.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+		p.Add(mark)
+
+		marks.XYs = append(marks.XYs, plotter.XY{X: x, Y: p.Y.Max})
+		marks.Labels = append(marks.Labels, a.Text)
+	}
+
+	if len(marks.XYs) == 0 {
+		return nil
+	}
+
+	labels, err := plotter.NewLabels(marks)
+	if err != nil {
+		return err
+	}
+	p.Add(labels)
+
+	return nil
+}
+
+// The days from creation to merge of the merged PRs, per repository.
+func cycleTimePlot(prs []pullRequest, endDate time.Time) (*plot.Plot, error) {
+	p := plot.New()
+	p.Title.Text = tr("Cycle time")
+	p.Y.Label.Text = tr("Days")
+
+	byRepo := make(map[string]plotter.Values)
+	for _, pr := range prs {
+		if pr.stateAt(endDate) == prMerged {
+			repo := pr.Repository.NameWithOwner
+			byRepo[repo] = append(byRepo[repo], pr.MergedAt.Sub(pr.CreatedAt).Hours()/24)
+		}
+	}
+
+	repos := sortedKeys(byRepo)
+	for i, repo := range repos {
+		box, err := plotter.NewBoxPlot(vg.Points(30), float64(i), byRepo[repo])
+		if err != nil {
+			return nil, err
+		}
+		p.Add(box)
+	}
+	if len(repos) > 0 {
+		p.NominalX(repos...)
+		p.X.Min, p.X.Max = -0.5, float64(len(repos))-0.5
+	}
+
+	return p, nil
+}
+
+// The PRs and the merged PRs per team side by side.
+func teamComparisonPlot(prs []pullRequest, endDate time.Time, people overlays) (*plot.Plot, error) {
+	p := plot.New()
+	p.Y.Label.Text = tr("PRs")
+
+	byTeam := people.roster != nil && people.roster.hasTeams()
+	p.Title.Text = tr("PRs per repository")
+	if byTeam {
+		p.Title.Text = tr("PRs per team")
+	}
+
+	total := make(map[string]float64)
+	merged := make(map[string]float64)
+	for _, pr := range prs {
+		group := pr.Repository.NameWithOwner
+		if byTeam {
+			group = people.roster.team(pr.Author.Login, names[pr.Author.Login])
+		}
+
+		total[group]++
+		if pr.stateAt(endDate) == prMerged {
+			merged[group]++
+		}
+	}
+
+	groups := sortedKeys(total)
+	if len(groups) == 0 {
+		return p, nil
+	}
+
+	var totals, mergedTotals plotter.Values
+	peak := 0.0
+	for _, group := range groups {
+		totals = append(totals, total[group])
+		mergedTotals = append(mergedTotals, merged[group])
+		peak = max(peak, total[group])
+	}
+
+	width := vg.Points(20)
+	for i, series := range []struct {
+		name   string
+		values plotter.Values
+	}{{"Total PRs", totals}, {"Merged PRs", mergedTotals}} {
+		bars, err := plotter.NewBarChart(series.values, width)
+		if err != nil {
+			return nil, err
+		}
+		bars.Color = plotutil.Color(i)
+		bars.LineStyle.Width = 0
+		bars.Offset = width * vg.Length(2*i-1) / 2
+
+		p.Add(bars)
+		p.Legend.Add(tr(series.name), bars)
+	}
+	// Room for the legend above the bars.
+	p.Legend.Top = true
+	p.NominalX(groups...)
+	p.X.Min, p.X.Max = -0.5, float64(len(groups))-0.5
+	p.Y.Min, p.Y.Max = 0, peak*1.25
+
+	return p, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A bar chart drawn below the table of its section with --charts.
+type barChart struct {
+	Title  string
+	Labels []string
+	Values []float64
+}
+
+// The eighths of a block, for the end of the bars.
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// The bars fill the terminal width, up to 60 columns.
+func (c barChart) render(width int) string {
+	labelWidth, peak := 0, 0.0
+	for i, label := range c.Labels {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(label))
+		peak = math.Max(peak, c.Values[i])
+	}
+
+	barWidth := 60
+	if width > 0 {
+		barWidth = min(barWidth, width-labelWidth-12)
+	}
+	barWidth = max(barWidth, 10)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, tr(c.Title))
+	for i, label := range c.Labels {
+		eighths := 0
+		if peak > 0 {
+			eighths = int(math.Round(c.Values[i] / peak * float64(barWidth*8)))
+		}
+
+		bar := strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+		fmt.Fprintf(&b, "%s%s │%s %s\n", label, strings.Repeat(" ", labelWidth-utf8.RuneCountInString(label)), bar, formatDecimal("%.0f", c.Values[i]))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// The PRs of every author, most first, and of every week of the window, by
+// the event of --select.
+func prCharts(stats []userStats, prs []pullRequest, initialDate, endDate time.Time) []barChart {
+	authors := barChart{Title: "PRs per author"}
+	sorted := append([]userStats(nil), stats...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].counts.prs > sorted[j].counts.prs
+	})
+	for _, user := range sorted {
+		authors.Labels = append(authors.Labels, user.login)
+		authors.Values = append(authors.Values, float64(user.counts.prs))
+	}
+
+	weeks := barChart{Title: "PRs per week"}
+	for start := initialDate; start.Before(endDate); start = start.AddDate(0, 0, 7) {
+		weeks.Labels = append(weeks.Labels, formatDate(start))
+		weeks.Values = append(weeks.Values, 0)
+	}
+	for _, pr := range prs {
+		at := pr.selectedAt()
+		if week := int(at.Sub(initialDate) / (7 * 24 * time.Hour)); week >= 0 && week < len(weeks.Values) {
+			weeks.Values[week]++
+		}
+	}
+
+	return []barChart{authors, weeks}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The lines the commits of a PR added in the window, and how many of them
+// commits of the window, of any PR, modified or deleted again.
+type prChurn struct {
+	Added   int
+	Churned int
+}
+
+type githubCommit struct {
+	Sha    string
+	Commit struct {
+		Committer struct {
+			Date time.Time
+		}
+	}
+	Parents []struct {
+		Sha string
+	}
+}
+
+type githubCommitFiles struct {
+	Files []struct {
+		Filename string
+		Patch    string
+	}
+}
+
+// Lines too short to tell apart, like blank lines and braces, are not
+// tracked.
+func trackedLine(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	return line, len(line) >= 3
+}
+
+// With CHURN_ANALYSIS, reads the diff of every commit of the PRs committed in
+// the window, oldest first, and counts the added lines that a later commit
+// removed again, matched by file and content. It takes a request per commit.
+func fetchChurn(owner, repo string, prs []pullRequest, initialDate, endDate time.Time) {
+	if getenv("CHURN_ANALYSIS") != "true" || len(prs) == 0 {
+		return
+	}
+
+	type commit struct {
+		sha string
+		at  time.Time
+		pr  int
+	}
+
+	var commits []commit
+	seen := make(map[string]bool)
+	for i, pr := range prs {
+		var prCommits []githubCommit
+		url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/commits?per_page=100", githubApiUrl(), owner, repo, pr.Number)
+		if err := githubGetJSON(url, &prCommits); err != nil {
+			warnPartial("Error reading the commits of %s, skipping its churn: %v\n", pr.Url, err)
+			continue
+		}
+
+		prs[i].Churn = &prChurn{}
+		for _, c := range prCommits {
+			at := c.Commit.Committer.Date
+			if seen[c.Sha] || len(c.Parents) > 1 || !at.After(initialDate) || at.After(endDate) {
+				continue
+			}
+			seen[c.Sha] = true
+			commits = append(commits, commit{c.Sha, at, i})
+		}
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].at.Before(commits[j].at)
+	})
+
+	fmt.Fprintf(progress, "Reading the diffs of %d commits of %s for the churn\n", len(commits), repo)
+
+	// The PRs that added each line still in place, by file and content.
+	added := make(map[string][]int)
+	for _, c := range commits {
+		var diff githubCommitFiles
+		url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", githubApiUrl(), owner, repo, c.sha)
+		if err := githubGetJSON(url, &diff); err != nil {
+			warnPartial("Error reading the diff of %s, skipping it: %v\n", c.sha, err)
+			continue
+		}
+
+		for _, file := range diff.Files {
+			for _, line := range strings.Split(file.Patch, "\n") {
+				if line == "" || (line[0] != '+' && line[0] != '-') {
+					continue
+				}
+
+				content, ok := trackedLine(line[1:])
+				if !ok {
+					continue
+				}
+				key := file.Filename + "\n" + content
+
+				if line[0] == '-' {
+					if adders := added[key]; len(adders) > 0 {
+						prs[adders[0]].Churn.Churned++
+						added[key] = adders[1:]
+					}
+					continue
+				}
+
+				prs[c.pr].Churn.Added++
+				added[key] = append(added[key], c.pr)
+			}
+		}
+	}
+}
+
+// The churn of the PRs per author and per repository. Nil without
+// CHURN_ANALYSIS.
+func churnSections(prs []pullRequest) []*reportSection {
+	type churn struct{ added, churned int }
+
+	byAuthor := make(map[string]*churn)
+	byRepo := make(map[string]*churn)
+	for _, pr := range prs {
+		if pr.Churn == nil {
+			continue
+		}
+
+		for _, group := range []struct {
+			m   map[string]*churn
+			key string
+		}{{byAuthor, pr.Author.Login}, {byRepo, pr.Repository.NameWithOwner}} {
+			if group.m[group.key] == nil {
+				group.m[group.key] = &churn{}
+			}
+			group.m[group.key].added += pr.Churn.Added
+			group.m[group.key].churned += pr.Churn.Churned
+		}
+	}
+
+	if len(byAuthor) == 0 {
+		return nil
+	}
+
+	section := func(name, key string, groups map[string]*churn) *reportSection {
+		s := &reportSection{
+			Name:     name,
+			Title:    name,
+			Parent:   "Churn",
+			Header:   table.Row{key, "Added lines", "Churned lines", "Churn (%)"},
+			Centered: []int{2, 3, 4},
+		}
+
+		var keys []string
+		for k := range groups {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			g := groups[k]
+			s.Rows = append(s.Rows, table.Row{k, g.added, g.churned, percentCell(g.churned, g.added)})
+		}
+
+		return s
+	}
+
+	return []*reportSection{
+		section("Churn by author", "ID", byAuthor),
+		section("Churn by repository", "Repository", byRepo),
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The associations of the people with write access or in the organization.
+var internalAssociations = map[string]bool{
+	"OWNER":        true,
+	"MEMBER":       true,
+	"COLLABORATOR": true,
+}
+
+// Whether the PR comes from outside the team: the author is neither in the
+// roster nor associated to the repository, or, for the providers without
+// associations, it comes from a fork owned by someone else.
+func (pr pullRequest) external(people overlays) bool {
+	if people.roster != nil {
+		if _, ok := people.roster.lookup(pr.Author.Login, names[pr.Author.Login]); ok {
+			return false
+		}
+	}
+
+	if pr.AuthorAssociation != "" {
+		return !internalAssociations[pr.AuthorAssociation]
+	}
+
+	owner, _, _ := strings.Cut(pr.Repository.NameWithOwner, "/")
+	return pr.IsCrossRepository && !strings.EqualFold(pr.HeadRepositoryOwner.Login, owner)
+}
+
+func contributionCell(prs []pullRequest, people overlays) string {
+	external := 0
+	for _, pr := range prs {
+		if pr.external(people) {
+			external++
+		}
+	}
+
+	switch external {
+	case 0:
+		return "Internal"
+	case len(prs):
+		return "External"
+	}
+
+	return "Mixed"
+}
+
+// Maintainers are the people in the roster or associated to the repository.
+func isMaintainer(login, association string, people overlays) bool {
+	if people.roster != nil {
+		if _, ok := people.roster.lookup(login, names[login]); ok {
+			return true
+		}
+	}
+
+	return internalAssociations[association]
+}
+
+// The first review or comment of a maintainer other than the author, up to the
+// end of the report.
+func (pr pullRequest) firstMaintainerResponseAt(endDate time.Time, people overlays) (time.Time, bool) {
+	var first time.Time
+
+	respond := func(login, association string, at time.Time) {
+		if login == "" || login == pr.Author.Login || at.After(endDate) || !isMaintainer(login, association, people) {
+			return
+		}
+
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+
+	for _, review := range pr.Reviews.Nodes {
+		if review.State != "PENDING" {
+			respond(review.Author.Login, review.AuthorAssociation, review.SubmittedAt)
+		}
+	}
+
+	for _, comment := range pr.Comments.Nodes {
+		respond(comment.Author.Login, comment.AuthorAssociation, comment.CreatedAt)
+	}
+
+	return first, !first.IsZero()
+}
+
+// When the PR was merged or closed, if it was by the end of the report.
+func (pr pullRequest) decidedAt(endDate time.Time) (time.Time, bool) {
+	switch pr.stateAt(endDate) {
+	case prMerged:
+		return pr.MergedAt, true
+	case prClosed:
+		return pr.ClosedAt, true
+	}
+
+	return time.Time{}, false
+}
+
+// How long external contributors wait for a maintainer to answer and for a
+// decision on their PRs, per repository.
+func maintainerResponseSection(prs []pullRequest, endDate time.Time, people overlays) *reportSection {
+	type repoStats struct {
+		prs       int
+		responses []float64
+		decisions []float64
+	}
+
+	byRepo := make(map[string]*repoStats)
+	total := &repoStats{}
+
+	for _, pr := range prs {
+		if !pr.external(people) {
+			continue
+		}
+
+		repo := pr.Repository.NameWithOwner
+		if byRepo[repo] == nil {
+			byRepo[repo] = &repoStats{}
+		}
+
+		for _, stats := range []*repoStats{byRepo[repo], total} {
+			stats.prs++
+
+			if at, ok := pr.firstMaintainerResponseAt(endDate, people); ok {
+				stats.responses = append(stats.responses, float64(at.Sub(pr.CreatedAt)))
+			}
+
+			if at, ok := pr.decidedAt(endDate); ok {
+				stats.decisions = append(stats.decisions, float64(at.Sub(pr.CreatedAt)))
+			}
+		}
+	}
+
+	if total.prs == 0 {
+		return nil
+	}
+
+	var repos []string
+	for repo := range byRepo {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	section := &reportSection{
+		Name:     "Maintainer response",
+		Title:    "Maintainer response",
+		Summary:  fmt.Sprintf("Time from an external PR being opened to the first review or comment of a maintainer, and to its merge or close. %d of %d external PRs had no answer", total.prs-len(total.responses), total.prs),
+		Header:   table.Row{"Repository", "External PRs", "Answered", "Median first response", "Decided", "Median time to decision"},
+		Centered: []int{2, 3, 4, 5, 6},
+	}
+
+	row := func(name string, stats *repoStats) table.Row {
+		return table.Row{
+			name,
+			stats.prs,
+			len(stats.responses),
+			durationCell(stats.responses, 50),
+			len(stats.decisions),
+			durationCell(stats.decisions, 50),
+		}
+	}
+
+	for _, repo := range repos {
+		section.Rows = append(section.Rows, row(repo, byRepo[repo]))
+	}
+	section.Footer = row("Total", total)
+
+	return section
+}
+
+type githubIssue struct {
+	Author struct {
+		Login string
+	}
+	Url       string
+	CreatedAt time.Time
+	Comments  struct {
+		Nodes []pullRequestComment
+	} `graphql:"comments(first: 20)"`
+}
+
+func fetchRepoIssues(owner, repo string, initialDate, endDate time.Time) []githubIssue {
+	var query struct {
+		Repository struct {
+			Issues struct {
+				Nodes    []githubIssue
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				}
+			} `graphql:"issues(first: 100, orderBy: {direction: DESC, field: CREATED_AT}, after: $cursor)"`
+		} `graphql:"repository(owner: $owner, name: $repo)"`
+	}
+
+	variables := map[string]interface{}{
+		"owner":  owner,
+		"repo":   repo,
+		"cursor": (*string)(nil),
+	}
+
+	var issues []githubIssue
+	for {
+		fmt.Fprintf(progress, "Requesting a page of the issues of %s\n", repo)
+
+		query.Repository.Issues.Nodes = nil
+		if err := client.Query(context.Background(), &query, variables); err != nil {
+			failGithubQuery(err, repo)
+		}
+
+		for _, issue := range query.Repository.Issues.Nodes {
+			if issue.CreatedAt.After(endDate) {
+				continue
+			}
+			if !issue.CreatedAt.After(initialDate) {
+				return issues
+			}
+			issues = append(issues, issue)
+		}
+
+		if !query.Repository.Issues.PageInfo.HasNextPage {
+			return issues
+		}
+		variables["cursor"] = &query.Repository.Issues.PageInfo.EndCursor
+	}
+}
+
+// The first comment or review of somebody other than the author, as CHAOSS
+// defines the time to first response. Bots do not count.
+func firstResponseAt(author string, endDate time.Time, comments []pullRequestComment, reviews []pullRequestReview) (time.Time, bool) {
+	var first time.Time
+
+	respond := func(login string, at time.Time) {
+		if login == "" || login == author || strings.HasSuffix(login, "[bot]") || at.After(endDate) {
+			return
+		}
+
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+
+	for _, comment := range comments {
+		respond(comment.Author.Login, comment.CreatedAt)
+	}
+
+	for _, review := range reviews {
+		if review.State != "PENDING" {
+			respond(review.Author.Login, review.SubmittedAt)
+		}
+	}
+
+	return first, !first.IsZero()
+}
+
+// A selection of the CHAOSS community health metrics: new and retained
+// contributors, and how fast PRs and issues get a first response. New
+// contributors had no PR in the COMMUNITY_LOOKBACK before the window, and
+// retained ones also had PRs in the window of the same length before.
+func communitySections(prs []pullRequest, issues []githubIssue, initialDate, endDate time.Time) []*reportSection {
+	previousDate := initialDate.Add(-endDate.Sub(initialDate))
+
+	contributors := make(map[string]pullRequest)
+	earlier := make(map[string]bool)
+	previous := make(map[string]bool)
+
+	var responses []float64
+	answered := 0
+	window := windowPRs(prs, initialDate, endDate, overlays{})
+
+	for _, pr := range prs {
+		login := pr.Author.Login
+		if strings.HasSuffix(login, "[bot]") {
+			continue
+		}
+
+		switch {
+		case pr.CreatedAt.After(initialDate):
+			if first, ok := contributors[login]; !ok || pr.CreatedAt.Before(first.CreatedAt) {
+				contributors[login] = pr
+			}
+		case pr.CreatedAt.After(previousDate):
+			previous[login] = true
+			earlier[login] = true
+		default:
+			earlier[login] = true
+		}
+	}
+
+	for _, pr := range window {
+		if at, ok := firstResponseAt(pr.Author.Login, endDate, pr.Comments.Nodes, pr.Reviews.Nodes); ok {
+			answered++
+			responses = append(responses, float64(at.Sub(pr.CreatedAt)))
+		}
+	}
+
+	var issueResponses []float64
+	for _, issue := range issues {
+		if at, ok := firstResponseAt(issue.Author.Login, endDate, issue.Comments.Nodes, nil); ok {
+			issueResponses = append(issueResponses, float64(at.Sub(issue.CreatedAt)))
+		}
+	}
+
+	newContributors := &reportSection{
+		Name:     "New contributors",
+		Title:    "New contributors",
+		Header:   table.Row{"ID", "Name", "First PR", "First response"},
+		Centered: []int{4},
+	}
+
+	retained := 0
+	var logins []string
+	for login := range contributors {
+		logins = append(logins, login)
+		if previous[login] {
+			retained++
+		}
+	}
+	sort.Strings(logins)
+
+	for _, login := range logins {
+		if earlier[login] {
+			continue
+		}
+
+		pr := contributors[login]
+		response := interface{}(notApplicable)
+		if at, ok := firstResponseAt(login, endDate, pr.Comments.Nodes, pr.Reviews.Nodes); ok {
+			response = duration(at.Sub(pr.CreatedAt))
+		}
+
+		newContributors.Rows = append(newContributors.Rows, table.Row{login, getNameById(login), pr.Url, response})
+	}
+
+	health := &reportSection{
+		Name:     "Community",
+		Title:    "Community health",
+		Summary:  fmt.Sprintf("CHAOSS metrics between %s and %s", initialDate.Format("2006-01-02"), endDate.Format("2006-01-02")),
+		Header:   table.Row{"Metric", "Value"},
+		Centered: []int{2},
+		Rows: []table.Row{
+			{"Contributors", len(contributors)},
+			{"New contributors", len(newContributors.Rows)},
+			{"Contributors of the previous window", len(previous)},
+			{"Retained contributors", retained},
+			{"Retention (%)", percentCell(retained, len(previous))},
+			{"PRs", len(window)},
+			{"PRs with a response", answered},
+			{"Median time to first PR response", durationCell(responses, 50)},
+			{"Issues", len(issues)},
+			{"Issues with a response", len(issueResponses)},
+			{"Median time to first issue response", durationCell(issueResponses, 50)},
+		},
+	}
+
+	return []*reportSection{health, newContributors}
+}
+
+func communityReport(args []string, format string, options terminalOptions, forgotten map[string]bool) {
+	if len(args) < 1 && reportingRange == "" {
+		fatalf(exitConfig, "pull-metrics community <start date> [<end date>]")
+	}
+
+	initialDate, endDate := parseWindow(args)
+	lookback := envDuration("COMMUNITY_LOOKBACK", 365*24*time.Hour)
+
+	owner, repos, ok := connectGithub(initialDate.Add(-lookback))
+	if !ok {
+		fatalf(exitConfig, "The community report needs GITHUB_TOKEN, GITHUB_OWNER and GITHUB_REPO")
+	}
+
+	var prs []pullRequest
+	var issues []githubIssue
+	for _, repo := range repos {
+		prs = append(prs, fetchRepoPRs(owner, repo, initialDate.Add(-lookback), endDate)...)
+		issues = append(issues, fetchRepoIssues(owner, repo, initialDate, endDate)...)
+	}
+
+	data := &fetchedData{prs: prs}
+	data.mergeRenames()
+	data.forget(forgotten)
+	data.exclude()
+
+	rep := &report{InitialDate: initialDate, EndDate: endDate, Sections: hideOptedOut(communitySections(data.prs, issues, initialDate, endDate))}
+	saveCachedNames()
+	rep.Entities = data.reportEntities(initialDate, endDate)
+	if err := rep.write(os.Stdout, format, options); err != nil {
+		log.Fatalf("Error writing the report: %v", err)
+	}
+}
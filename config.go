@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// The current directory followed by the XDG config directories, in order of
+// precedence.
+func configFiles(name string) []string {
+	files := []string{name}
+
+	if configHome := userConfigHome(); configHome != "" {
+		files = append(files, filepath.Join(configHome, "pull-metrics", name))
+	}
+
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+	for _, dir := range strings.Split(configDirs, ":") {
+		if dir != "" {
+			files = append(files, filepath.Join(dir, "pull-metrics", name))
+		}
+	}
+
+	return files
+}
+
+func userConfigHome() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+
+	return configHome
+}
+
+// An explicit file must exist. Otherwise every candidate is optional, so the
+// configuration can come from the real environment alone. Variables already
+// set in the environment always win, and godotenv never overrides them, so
+// the first file that sets a variable wins over the next ones.
+func loadEnv(envFile string) {
+	if envFile != "" {
+		if err := godotenv.Load(envFile); err != nil {
+			fatalf(exitConfig, "Error loading %s: %v", envFile, err)
+		}
+		return
+	}
+
+	for _, file := range configFiles(".env") {
+		if err := godotenv.Load(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fatalf(exitConfig, "Error loading %s: %v", file, err)
+		}
+	}
+}
+
+// The profiles file maps each profile name to the variables it sets, e.g.
+//
+//	{
+//	  "platform-team": {"GITHUB_REPO": "infra", "JIRA_PROJECTS": "PLAT", "ROSTER_FILE": "platform.csv"},
+//	  "mobile-team": {"GITHUB_REPO": "ios,android", "JIRA_PROJECTS": "MOB"}
+//	}
+//
+// The variables of the selected profile override the environment and .env.
+func applyProfile(name string) {
+	if name == "" {
+		return
+	}
+
+	path := os.Getenv("PROFILES_FILE")
+	if path == "" {
+		for _, file := range configFiles("profiles.json") {
+			if _, err := os.Stat(file); err == nil {
+				path = file
+				break
+			}
+		}
+	}
+
+	if path == "" {
+		fatalf(exitConfig, "No profiles file found. Set PROFILES_FILE or create profiles.json")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf(exitConfig, "Error opening PROFILES_FILE: %v", err)
+	}
+
+	var profiles map[string]map[string]string
+	if err := json.Unmarshal(content, &profiles); err != nil {
+		fatalf(exitConfig, "Error reading %s: %v", path, err)
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		var known []string
+		for profileName := range profiles {
+			known = append(known, profileName)
+		}
+		sort.Strings(known)
+
+		fatalf(exitConfig, "Unknown profile %q. Profiles in %s: %s", name, path, strings.Join(known, ", "))
+	}
+
+	for key, value := range profile {
+		os.Setenv(key, value)
+	}
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		fatalf(exitConfig, "Invalid %s %q: expected a duration like 30m or 24h", name, value)
+	}
+
+	return d
+}
+
+func envInt(name string, fallback int) int {
+	value := getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		fatalf(exitConfig, "Invalid %s %q: expected a positive number", name, value)
+	}
+
+	return n
+}
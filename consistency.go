@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The Jira keys of the projects in a PR title or branch, upper-cased.
+func jiraReferences(pr pullRequest, keys *regexp.Regexp) []string {
+	var references []string
+	for _, text := range []string{pr.Title, pr.HeadRefName} {
+		for _, key := range keys.FindAllString(text, -1) {
+			references = append(references, strings.ToUpper(key))
+		}
+	}
+
+	return references
+}
+
+// pull-metrics consistency lists, per team, the Jira issues moved to Done in
+// the window that no merged PR references, and the PRs merged in the window
+// that reference no issue of JIRA_PROJECTS. PRs reference issues by their key
+// in the title or the branch, and the ones merged up to CONSISTENCY_LOOKBACK
+// before the window still count for the issues.
+func consistencyReport(args []string, format string, options terminalOptions, settings reportOptions) {
+	if len(args) < 1 && reportingRange == "" {
+		fatalf(exitConfig, "pull-metrics consistency <start date> [<end date>]")
+	}
+
+	initialDate, endDate := parseWindow(args)
+	lookback := envDuration("CONSISTENCY_LOOKBACK", 30*24*time.Hour)
+
+	jiraBaseUrl, auth, jiraProject, ok := connectJira()
+	if !ok {
+		fatalf(exitConfig, "The consistency report needs JIRA_BASE_URL, JIRA_USER, JIRA_TOKEN and JIRA_PROJECTS")
+	}
+
+	jql := fmt.Sprintf(`project in (%s) and status changed DURING (%s, %s) TO "Done" and issuetype not in (Epic, sub-task) ORDER BY key ASC`,
+		jiraProject, initialDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	var done []jiraIssue
+	for _, issue := range searchJira(jiraBaseUrl, auth, jql) {
+		if issue.Fields.Status.Name == "Done" {
+			done = append(done, issue)
+		}
+	}
+
+	prSelection = selectMerged
+
+	var prs []pullRequest
+	githubPRs, githubOk := fetchGithubPRs(initialDate.Add(-lookback), endDate)
+	gerritPRs, _ := fetchGerritChanges(initialDate.Add(-lookback), endDate)
+	giteaPRs, _ := fetchGiteaPRs(initialDate.Add(-lookback), endDate)
+	prs = append(append(githubPRs, gerritPRs...), giteaPRs...)
+	if !githubOk && len(prs) == 0 {
+		fatalf(exitConfig, "The consistency report needs the PRs of GitHub, Gerrit or Gitea")
+	}
+
+	data := &fetchedData{prs: prs}
+	data.mergeRenames()
+	data.forget(settings.forgotten)
+	data.exclude()
+
+	people := loadOverlays(initialDate, endDate, settings)
+	rep := &report{InitialDate: initialDate, EndDate: endDate, Sections: hideOptedOut(consistencySections(done, data.prs, initialDate, endDate, people))}
+	rep.Entities = data.reportEntities(initialDate, endDate)
+	if err := rep.write(os.Stdout, format, options); err != nil {
+		log.Fatalf("Error writing the report: %v", err)
+	}
+}
+
+func consistencySections(done []jiraIssue, prs []pullRequest, initialDate, endDate time.Time, people overlays) []*reportSection {
+	var projects []string
+	for _, project := range jiraProjects() {
+		projects = append(projects, regexp.QuoteMeta(project))
+	}
+	keys := regexp.MustCompile(`(?i)\b(` + strings.Join(projects, "|") + `)-\d+\b`)
+
+	team := func(identities ...string) string {
+		if people.roster == nil {
+			return "No team"
+		}
+		return people.roster.team(identities...)
+	}
+
+	type teamCounts struct {
+		done, doneWithoutPR, merged, mergedWithoutIssue int
+	}
+	teams := make(map[string]*teamCounts)
+	count := func(name string) *teamCounts {
+		if teams[name] == nil {
+			teams[name] = &teamCounts{}
+		}
+		return teams[name]
+	}
+
+	referenced := make(map[string]bool)
+	unreferenced := &reportSection{
+		Name:   "Merged PRs without an issue",
+		Title:  "Merged PRs without an issue",
+		Header: table.Row{"Team", "URL", "Author", "Title"},
+	}
+	for _, pr := range prs {
+		if !pr.Merged || pr.MergedAt.After(endDate) {
+			continue
+		}
+
+		// An issue done in the window may have had its PR merged before.
+		references := jiraReferences(pr, keys)
+		for _, key := range references {
+			referenced[key] = true
+		}
+
+		if !pr.MergedAt.After(initialDate) {
+			continue
+		}
+
+		t := team(pr.Author.Login, names[pr.Author.Login])
+		count(t).merged++
+		if len(references) == 0 {
+			count(t).mergedWithoutIssue++
+			unreferenced.Rows = append(unreferenced.Rows, table.Row{t, pr.Url, pr.Author.Login, pr.Title})
+		}
+	}
+
+	withoutPR := &reportSection{
+		Name:   "Done without a merged PR",
+		Title:  "Done without a merged PR",
+		Header: table.Row{"Team", "Issue", "Summary", "Assignee"},
+	}
+	for _, issue := range done {
+		assignee := issue.Fields.Assignee.DisplayName
+		t := team(assignee)
+		count(t).done++
+		if !referenced[issue.Key] {
+			count(t).doneWithoutPR++
+			withoutPR.Rows = append(withoutPR.Rows, table.Row{t, issue.Key, issue.Fields.Summary, assignee})
+		}
+	}
+
+	for _, s := range []*reportSection{unreferenced, withoutPR} {
+		sort.SliceStable(s.Rows, func(i, j int) bool {
+			return fmt.Sprint(s.Rows[i][0]) < fmt.Sprint(s.Rows[j][0])
+		})
+	}
+
+	var teamNames []string
+	for name := range teams {
+		teamNames = append(teamNames, name)
+	}
+	sort.Strings(teamNames)
+
+	summary := &reportSection{
+		Name:     "Consistency",
+		Title:    "Jira and PR consistency per team",
+		Header:   table.Row{"Team", "Done issues", "Done without PR", "Merged PRs", "Merged without issue"},
+		Centered: []int{2, 3, 4, 5},
+	}
+	flagMissing := func(n int) interface{} {
+		if n > 0 {
+			return flagged{n, warning}
+		}
+		return n
+	}
+	for _, name := range teamNames {
+		c := teams[name]
+		summary.Rows = append(summary.Rows, table.Row{name, c.done, flagMissing(c.doneWithoutPR), c.merged, flagMissing(c.mergedWithoutIssue)})
+	}
+
+	return []*reportSection{summary, withoutPR, unreferenced}
+}
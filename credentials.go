@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// The names of the variables saved by pull-metrics login. The values are in
+// the keychain or in the age file, the names are not secret.
+var (
+	storedNames     map[string]bool
+	storedNamesOnce sync.Once
+)
+
+func userConfigFile(name string) string {
+	configHome := userConfigHome()
+	if configHome == "" {
+		fatalf(exitConfig, "No home directory for %s", name)
+	}
+
+	return filepath.Join(configHome, "pull-metrics", name)
+}
+
+func credentialsStore() string {
+	store := getenv("CREDENTIALS_STORE")
+	switch store {
+	case "", "keychain":
+		return "keychain"
+	case "age":
+		return store
+	}
+
+	fatalf(exitConfig, "Unknown CREDENTIALS_STORE %q, expected keychain or age", store)
+	return ""
+}
+
+func isStored(name string) bool {
+	storedNamesOnce.Do(func() {
+		storedNames = make(map[string]bool)
+
+		configHome := userConfigHome()
+		if configHome == "" {
+			return
+		}
+
+		content, err := os.ReadFile(filepath.Join(configHome, "pull-metrics", "credentials"))
+		if err != nil {
+			return
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				storedNames[line] = true
+			}
+		}
+	})
+
+	return storedNames[name]
+}
+
+// Reads a variable saved by pull-metrics login, when it is not in the
+// environment. getenv remembers it, so the keychain is asked once per run.
+func readStoredCredential(name string) (string, error) {
+	if credentialsStore() == "age" {
+		credentials, err := readAgeCredentials()
+		return credentials[name], err
+	}
+
+	var output []byte
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		output, err = exec.Command("security", "find-generic-password", "-s", "pull-metrics", "-a", name, "-w").Output()
+	case "linux", "freebsd", "openbsd":
+		output, err = exec.Command("secret-tool", "lookup", "service", "pull-metrics", "account", name).Output()
+	default:
+		return "", fmt.Errorf("no keychain support on %s, use CREDENTIALS_STORE=age", runtime.GOOS)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s from the keychain: %v", name, err)
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// The secret goes on the standard input, never in the arguments any local user
+// can read with ps.
+func writeKeychain(name, value string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// -w last and without a value prompts for the password and its
+		// confirmation.
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", "pull-metrics", "-a", name, "-w")
+		cmd.Stdin = strings.NewReader(value + "\n" + value + "\n")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "store", "--label", "pull-metrics "+name, "service", "pull-metrics", "account", name)
+		cmd.Stdin = strings.NewReader(value)
+	default:
+		return fmt.Errorf("no keychain support on %s, use CREDENTIALS_STORE=age", runtime.GOOS)
+	}
+
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// The age file holds every credential as a JSON object, encrypted to the
+// identity in CREDENTIALS_AGE_IDENTITY (made with age-keygen).
+func ageIdentity() string {
+	identity := getenv("CREDENTIALS_AGE_IDENTITY")
+	if identity == "" {
+		fatalf(exitConfig, "CREDENTIALS_STORE=age needs CREDENTIALS_AGE_IDENTITY, a key file made with age-keygen")
+	}
+
+	return identity
+}
+
+func readAgeCredentials() (map[string]string, error) {
+	credentials := make(map[string]string)
+
+	file := userConfigFile("credentials.age")
+	if _, err := os.Stat(file); err != nil {
+		return credentials, nil
+	}
+
+	output, err := exec.Command("age", "--decrypt", "--identity", ageIdentity(), file).Output()
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %v", file, err)
+	}
+
+	return credentials, json.Unmarshal(output, &credentials)
+}
+
+func writeAgeCredential(name, value string) error {
+	credentials, err := readAgeCredentials()
+	if err != nil {
+		return err
+	}
+	credentials[name] = value
+
+	content, _ := json.Marshal(credentials)
+
+	file := userConfigFile("credentials.age")
+	cmd := exec.Command("age", "--encrypt", "--identity", ageIdentity(), "--output", file)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// Saves the value of a variable, like GITHUB_TOKEN, in the store and adds its
+// name to the list of stored ones.
+func storeCredential(name, value string) {
+	if err := os.MkdirAll(filepath.Dir(userConfigFile("credentials")), 0o700); err != nil {
+		fatalf(exitConfig, "Error creating the config directory: %v", err)
+	}
+
+	var err error
+	if credentialsStore() == "age" {
+		err = writeAgeCredential(name, value)
+	} else {
+		err = writeKeychain(name, value)
+	}
+	if err != nil {
+		fatalf(exitConfig, "Error saving %s: %v", name, err)
+	}
+
+	isStored(name)
+	storedNames[name] = true
+
+	var names []string
+	for stored := range storedNames {
+		names = append(names, stored)
+	}
+	sort.Strings(names)
+
+	if err := os.WriteFile(userConfigFile("credentials"), []byte(strings.Join(names, "\n")+"\n"), 0o600); err != nil {
+		fatalf(exitConfig, "Error saving the list of credentials: %v", err)
+	}
+
+	fmt.Printf("%s saved in the %s store\n", name, credentialsStore())
+}
+
+// pull-metrics login <variable> reads the value from the terminal, without
+// echoing it, or from stdin. pull-metrics login github gets GITHUB_TOKEN from
+// the browser instead.
+func loginCommand(args []string) {
+	if len(args) != 1 {
+		fatalf(exitConfig, "pull-metrics login github | <variable>, e.g. pull-metrics login GITHUB_TOKEN")
+	}
+	name := args[0]
+
+	if name == "github" {
+		githubDeviceLogin()
+		return
+	}
+
+	var value string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "%s: ", name)
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fatalf(exitConfig, "Error reading %s: %v", name, err)
+		}
+		value = string(secret)
+	} else {
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		value = line
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		fatalf(exitConfig, "Empty %s, nothing saved", name)
+	}
+
+	storeCredential(name, value)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var debugLog io.Writer
+
+var debugMutex sync.Mutex
+
+// Headers that carry credentials.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":        true,
+	"Proxy-Authorization":  true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+	"X-Vault-Token":        true,
+	"X-Amz-Security-Token": true,
+}
+
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func withDebug(next http.RoundTripper) http.RoundTripper {
+	if debugLog == nil {
+		return next
+	}
+
+	return &debugTransport{next: next}
+}
+
+func openDebugLog(path string) {
+	if path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		fatalf(exitConfig, "Error opening the HTTP debug log: %v", err)
+	}
+
+	debugLog = f
+}
+
+// The values of the variables that look like credentials, to scrub them from
+// URLs and bodies too.
+func secretValues() []string {
+	var values []string
+
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		upper := strings.ToUpper(name)
+
+		if len(value) < 8 || strings.HasSuffix(upper, "_FILE") {
+			continue
+		}
+
+		for _, marker := range []string{"TOKEN", "SECRET", "PASSWORD", "KEY"} {
+			if strings.Contains(upper, marker) {
+				values = append(values, value)
+				break
+			}
+		}
+	}
+
+	secretsMutex.Lock()
+	for _, secret := range resolvedSecrets {
+		values = append(values, secret)
+	}
+	secretsMutex.Unlock()
+
+	return values
+}
+
+func redact(text string) string {
+	for _, secret := range secretValues() {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, "[REDACTED]")
+		}
+	}
+
+	return text
+}
+
+func redactUrl(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User("[REDACTED]")
+	}
+
+	query := redacted.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "token") || strings.Contains(lower, "key") || strings.Contains(lower, "sig") {
+			query.Set(key, "[REDACTED]")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+
+	return redact(redacted.String())
+}
+
+func writeHeaders(b *strings.Builder, headers http.Header) {
+	var keys []string
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := strings.Join(headers[key], ", ")
+		if sensitiveHeaders[key] {
+			value = "[REDACTED]"
+		}
+		fmt.Fprintf(b, "%s: %s\n", key, redact(value))
+	}
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var b strings.Builder
+	start := time.Now()
+
+	fmt.Fprintf(&b, "=== %s %s %s\n", start.Format(time.RFC3339), req.Method, redactUrl(req.URL))
+	writeHeaders(&b, req.Header)
+
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			content, _ := io.ReadAll(body)
+			fmt.Fprintf(&b, "\n%s\n", redact(string(content)))
+		}
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(&b, "--- error after %v: %v\n\n", time.Since(start).Round(time.Millisecond), redact(err.Error()))
+		t.write(b.String())
+		return res, err
+	}
+
+	fmt.Fprintf(&b, "--- %s after %v\n", res.Status, time.Since(start).Round(time.Millisecond))
+	writeHeaders(&b, res.Header)
+
+	content, readErr := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(content))
+	if readErr != nil {
+		fmt.Fprintf(&b, "\nError reading the body: %v\n\n", readErr)
+	} else {
+		fmt.Fprintf(&b, "\n%s\n\n", redact(string(content)))
+	}
+
+	t.write(b.String())
+
+	return res, nil
+}
+
+func (t *debugTransport) write(entry string) {
+	debugMutex.Lock()
+	defer debugMutex.Unlock()
+
+	io.WriteString(debugLog, entry)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// DEPENDENCY_MANIFESTS is a comma-separated list of the file names of the
+// dependency manifests. The lock files are left out, so a PR only updating
+// one does not count.
+func dependencyManifests() map[string]bool {
+	list := getenv("DEPENDENCY_MANIFESTS")
+	if list == "" {
+		list = "go.mod,package.json,requirements.txt,pyproject.toml,Pipfile,Gemfile,Cargo.toml,pom.xml,build.gradle,build.gradle.kts,composer.json"
+	}
+
+	manifests := make(map[string]bool)
+	for _, name := range statusList(list) {
+		manifests[strings.ToLower(name)] = true
+	}
+
+	return manifests
+}
+
+func (pr pullRequest) changesManifest(manifests map[string]bool) bool {
+	for _, file := range pr.Files.Nodes {
+		if manifests[strings.ToLower(path.Base(file.Path))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	// Bump golang.org/x/net from 0.17.0 to 1.0.0, the Dependabot titles.
+	bumpFromTo = regexp.MustCompile(`(?i)\bfrom v?(\d+)\S* to v?(\d+)`)
+	// Update dependency react to v19, the Renovate titles of major updates.
+	bumpToMajor = regexp.MustCompile(`(?i)\bto v\d+(\s|$)`)
+)
+
+// Whether the PR bumps a dependency to a new major version, as told by its
+// title or a label with major in its name, like semver-major.
+func (pr pullRequest) majorBump() bool {
+	for _, label := range pr.Labels.Nodes {
+		if strings.Contains(strings.ToLower(label.Name), "major") {
+			return true
+		}
+	}
+
+	if match := bumpFromTo.FindStringSubmatch(pr.Title); match != nil {
+		return match[1] != match[2]
+	}
+
+	return bumpToMajor.MatchString(pr.Title)
+}
+
+// The first approval by somebody other than the author, up to the end date.
+func (pr pullRequest) firstApprovalAt(endDate time.Time) (time.Time, bool) {
+	var first time.Time
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login == pr.Author.Login || review.State != "APPROVED" || review.SubmittedAt.After(endDate) {
+			continue
+		}
+
+		if first.IsZero() || review.SubmittedAt.Before(first) {
+			first = review.SubmittedAt
+		}
+	}
+
+	return first, !first.IsZero()
+}
+
+// The PRs changing a dependency manifest per repository, how long they waited
+// for an approval and how many moved a dependency to a new major version.
+func dependencySection(prs []pullRequest, endDate time.Time) *reportSection {
+	type repoStats struct {
+		prs        int
+		merged     int
+		approved   int
+		unapproved int
+		latencies  []float64
+		major      int
+	}
+
+	manifests := dependencyManifests()
+	byRepo := make(map[string]*repoStats)
+	total := &repoStats{}
+	for _, pr := range prs {
+		if !pr.changesManifest(manifests) {
+			continue
+		}
+
+		repo := pr.Repository.NameWithOwner
+		if byRepo[repo] == nil {
+			byRepo[repo] = &repoStats{}
+		}
+
+		approvedAt, approved := pr.firstApprovalAt(endDate)
+		merged := pr.stateAt(endDate) == prMerged
+		for _, stats := range []*repoStats{byRepo[repo], total} {
+			stats.prs++
+			if merged {
+				stats.merged++
+			}
+			if approved {
+				stats.approved++
+				stats.latencies = append(stats.latencies, float64(approvedAt.Sub(pr.readyForReviewAt())))
+			} else if merged {
+				stats.unapproved++
+			}
+			if pr.majorBump() {
+				stats.major++
+			}
+		}
+	}
+
+	row := func(label string, stats *repoStats) table.Row {
+		unapproved := interface{}(stats.unapproved)
+		if stats.unapproved > 0 {
+			unapproved = flagged{stats.unapproved, warning}
+		}
+
+		return table.Row{label, stats.prs, stats.merged, percentCell(stats.approved, stats.prs), durationCell(stats.latencies, 50), unapproved, stats.major}
+	}
+
+	section := &reportSection{
+		Name:     "Dependency PRs",
+		Title:    "Dependency PRs",
+		Summary:  fmt.Sprintf("PRs changing %s", strings.Join(sortedKeys(manifests), ", ")),
+		Header:   table.Row{"Repository", "Dependency PRs", "Merged PRs", "Approved (%)", "Median approval latency", "Merged unapproved", "Major bumps"},
+		Centered: []int{2, 3, 4, 5, 6, 7},
+	}
+
+	for _, repo := range sortedKeys(byRepo) {
+		section.Rows = append(section.Rows, row(repo, byRepo[repo]))
+	}
+
+	if total.prs > 0 {
+		section.Footer = row("Total", total)
+		section.Outputs = map[string]string{
+			"github_dependency_prs":         fmt.Sprint(total.prs),
+			"github_dependency_major_bumps": fmt.Sprint(total.major),
+		}
+	}
+
+	return section
+}
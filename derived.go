@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A metric computed per person from the other ones, defined in
+// DERIVED_METRICS as name = expression, separated by semicolons or new lines:
+//
+//	DERIVED_METRICS="churn = additions + deletions; review_ratio = reviews_given / prs"
+//
+// Expressions support numbers, the variables below, + - * / and parentheses.
+type derivedMetric struct {
+	name   string
+	source string
+	expr   expression
+}
+
+var derivedMetrics []derivedMetric
+
+// The variables available to the expressions, per person.
+var derivedVariables = []string{
+	"prs",
+	"merged",
+	"open",
+	"closed_unmerged",
+	"additions",
+	"deletions",
+	"changed_files",
+	"comments",
+	"reviews_given",
+	"review_requests",
+}
+
+type expression interface {
+	eval(vars map[string]float64) float64
+}
+
+type number float64
+
+func (n number) eval(vars map[string]float64) float64 {
+	return float64(n)
+}
+
+type variable string
+
+func (v variable) eval(vars map[string]float64) float64 {
+	return vars[string(v)]
+}
+
+type negation struct {
+	operand expression
+}
+
+func (n negation) eval(vars map[string]float64) float64 {
+	return -n.operand.eval(vars)
+}
+
+type binary struct {
+	operator    byte
+	left, right expression
+}
+
+// Dividing by zero gives NaN, so the cell shows as not applicable.
+func (b binary) eval(vars map[string]float64) float64 {
+	left, right := b.left.eval(vars), b.right.eval(vars)
+
+	switch b.operator {
+	case '+':
+		return left + right
+	case '-':
+		return left - right
+	case '*':
+		return left * right
+	}
+
+	if right == 0 {
+		return math.NaN()
+	}
+	return left / right
+}
+
+type expressionParser struct {
+	input string
+	pos   int
+}
+
+func parseExpression(input string) (expression, error) {
+	p := &expressionParser{input: input}
+
+	expr, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos+1)
+	}
+
+	return expr, nil
+}
+
+func (p *expressionParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *expressionParser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+
+	return 0
+}
+
+func (p *expressionParser) sum() (expression, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+
+	for operator := p.peek(); operator == '+' || operator == '-'; operator = p.peek() {
+		p.pos++
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) product() (expression, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for operator := p.peek(); operator == '*' || operator == '/'; operator = p.peek() {
+		p.pos++
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) operand() (expression, error) {
+	switch c := p.peek(); {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of the expression")
+	case c == '-':
+		p.pos++
+		operand, err := p.operand()
+		return negation{operand}, err
+	case c == '(':
+		p.pos++
+		expr, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) at position %d", p.pos+1)
+		}
+		p.pos++
+		return expr, nil
+	case c == '.' || unicode.IsDigit(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || unicode.IsDigit(rune(p.input[p.pos]))) {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		return number(value), err
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '_' || unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos]))) {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		for _, known := range derivedVariables {
+			if name == known {
+				return variable(name), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown variable %q, expected one of %s", name, strings.Join(derivedVariables, ", "))
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", string(c), p.pos+1)
+	}
+}
+
+func loadDerivedMetrics() []derivedMetric {
+	var metrics []derivedMetric
+
+	definitions := strings.FieldsFunc(getenv("DERIVED_METRICS"), func(r rune) bool {
+		return r == ';' || r == '\n'
+	})
+
+	for _, definition := range definitions {
+		if strings.TrimSpace(definition) == "" {
+			continue
+		}
+
+		name, source, found := strings.Cut(definition, "=")
+		name, source = strings.TrimSpace(name), strings.TrimSpace(source)
+		if !found || name == "" {
+			fatalf(exitConfig, "DERIVED_METRICS: expected name = expression in %q", definition)
+		}
+
+		expr, err := parseExpression(source)
+		if err != nil {
+			fatalf(exitConfig, "DERIVED_METRICS %s: %v", name, err)
+		}
+
+		metrics = append(metrics, derivedMetric{name: name, source: source, expr: expr})
+	}
+
+	return metrics
+}
+
+func (metric derivedMetric) cell(vars map[string]float64) interface{} {
+	value := metric.expr.eval(vars)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return notApplicable
+	}
+
+	return average(value)
+}
+
+// Counts the reviews given and requested of every person on the PRs.
+func reviewActivity(prs []pullRequest) (given, requested map[string]int) {
+	given, requested = make(map[string]int), make(map[string]int)
+
+	for _, pr := range prs {
+		for _, review := range pr.Reviews.Nodes {
+			if review.Author.Login != "" && review.Author.Login != pr.Author.Login && review.State != "PENDING" {
+				given[review.Author.Login]++
+			}
+		}
+
+		for _, request := range pr.reviewRequests() {
+			requested[request.reviewer]++
+		}
+	}
+
+	return given, requested
+}
+
+func derivedVars(counts prCounts, comments, reviewsGiven, reviewRequests int) map[string]float64 {
+	return map[string]float64{
+		"prs":             float64(counts.prs),
+		"merged":          float64(counts.merged),
+		"open":            float64(counts.open),
+		"closed_unmerged": float64(counts.closedUnmerged),
+		"additions":       float64(counts.addedLines),
+		"deletions":       float64(counts.removedLines),
+		"changed_files":   float64(counts.changedFiles),
+		"comments":        float64(comments),
+		"reviews_given":   float64(reviewsGiven),
+		"review_requests": float64(reviewRequests),
+	}
+}
+
+func derivedDefinitions() []metricDefinition {
+	var definitions []metricDefinition
+	for _, metric := range derivedMetrics {
+		definitions = append(definitions, metricDefinition{"GitHub", metric.name, metric.name + " = " + metric.source, "DERIVED_METRICS"})
+	}
+
+	return definitions
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	vars := map[string]float64{"prs": 4, "merged": 3, "additions": 10, "deletions": 6, "reviews_given": 0}
+
+	tests := []struct {
+		name  string
+		input string
+		want  float64
+	}{
+		{"number", "2.5", 2.5},
+		{"variable", "prs", 4},
+		{"product before sum", "1 + 2 * 3", 7},
+		{"division before difference", "additions - deletions / 2", 7},
+		{"left to right", "prs - merged - 1", 0},
+		{"left to right division", "12 / prs / 3", 1},
+		{"parentheses", "(additions + deletions) / prs", 4},
+		{"nested parentheses", "((1 + 2) * (3 - 1))", 6},
+		{"negation", "-merged + prs", 1},
+		{"negated parentheses", "-(prs - merged) * 2", -2},
+		{"tabs and new lines", "additions\t+\n deletions", 16},
+		{"no spaces", "merged/prs*100", 75},
+	}
+
+	for _, test := range tests {
+		expr, err := parseExpression(test.input)
+		if err != nil {
+			t.Errorf("%s: parseExpression(%q): %v", test.name, test.input, err)
+			continue
+		}
+
+		if got := expr.eval(vars); got != test.want {
+			t.Errorf("%s: %q = %v, want %v", test.name, test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"empty", "", "unexpected end"},
+		{"unknown variable", "prs + lines", `unknown variable "lines"`},
+		{"dangling operator", "prs +", "unexpected end"},
+		{"missing parenthesis", "(prs + 1", "missing )"},
+		{"extra parenthesis", "prs + 1)", `unexpected ")"`},
+		{"two operands", "prs merged", `unexpected "merged"`},
+		{"unknown operator", "prs % 2", `unexpected "% 2"`},
+		{"malformed number", "1.2.3", "invalid syntax"},
+	}
+
+	for _, test := range tests {
+		_, err := parseExpression(test.input)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: parseExpression(%q) = %v, want an error with %q", test.name, test.input, err, test.err)
+		}
+	}
+}
+
+func TestDerivedMetricCell(t *testing.T) {
+	vars := map[string]float64{"reviews_given": 3, "prs": 0, "merged": 2}
+
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{"ratio", "reviews_given / merged", average(1.5)},
+		{"division by zero", "reviews_given / prs", notApplicable},
+		{"division by a zero difference", "merged / (merged - 2)", notApplicable},
+		{"zero over zero", "prs / prs", notApplicable},
+	}
+
+	for _, test := range tests {
+		expr, err := parseExpression(test.input)
+		if err != nil {
+			t.Fatalf("%s: parseExpression(%q): %v", test.name, test.input, err)
+		}
+
+		if got := (derivedMetric{expr: expr}).cell(vars); got != test.want {
+			t.Errorf("%s: %q = %v, want %v", test.name, test.input, got, test.want)
+		}
+	}
+}
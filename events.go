@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// One line of --events, written once the data is fetched, the forgotten,
+// excluded and opted-out people left out. Its fields follow schemaVersion.
+type event struct {
+	SchemaVersion int      `json:"schema_version"`
+	Entity        string   `json:"entity"`
+	Type          string   `json:"type"`
+	Source        string   `json:"source,omitempty"`
+	Repository    string   `json:"repository,omitempty"`
+	Number        int      `json:"number,omitempty"`
+	Key           string   `json:"key,omitempty"`
+	Url           string   `json:"url,omitempty"`
+	Author        string   `json:"author,omitempty"`
+	Title         string   `json:"title,omitempty"`
+	State         string   `json:"state,omitempty"`
+	IssueType     string   `json:"issue_type,omitempty"`
+	Draft         bool     `json:"draft,omitempty"`
+	Additions     int      `json:"additions,omitempty"`
+	Deletions     int      `json:"deletions,omitempty"`
+	ChangedFiles  int      `json:"changed_files,omitempty"`
+	At            string   `json:"at,omitempty"`
+	MergedAt      string   `json:"merged_at,omitempty"`
+	ClosedAt      string   `json:"closed_at,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Team          string   `json:"team,omitempty"`
+	Members       []string `json:"members,omitempty"`
+}
+
+var (
+	events      *json.Encoder
+	eventsMutex sync.Mutex
+	collecting  bool
+)
+
+// Streams the events to path, or to stdout with "-". The file is not
+// buffered, so every event is written as soon as it is emitted.
+func openEvents(path string) {
+	switch path {
+	case "":
+		return
+	case "-":
+		events = json.NewEncoder(os.Stdout)
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		fatalf(exitConfig, "Error creating the events file: %v", err)
+	}
+
+	events = json.NewEncoder(file)
+}
+
+func eventTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func wantEvents() bool {
+	return events != nil || collecting
+}
+
+func emitEvents(batch ...event) {
+	eventsMutex.Lock()
+	defer eventsMutex.Unlock()
+
+	for i := range batch {
+		batch[i].SchemaVersion = schemaVersion
+	}
+
+	if collecting {
+		collectedEvents = append(collectedEvents, batch...)
+	}
+
+	if events != nil {
+		for _, e := range batch {
+			events.Encode(e)
+		}
+	}
+}
+
+// Every PR and issue left after the filters, then the people and teams.
+func (data *fetchedData) emit() {
+	if !wantEvents() {
+		return
+	}
+
+	emitEvents(data.withoutOptedOut().entities()...)
+}
+
+// The entities of the JSON report: the ones of the events, for the PRs of the
+// window and the issues open in it.
+func (data *fetchedData) reportEntities(initialDate, endDate time.Time) []event {
+	visible := data.withoutOptedOut()
+	visible.prs = windowPRs(visible.prs, initialDate, endDate, data.people)
+
+	var issues []jiraIssue
+	for _, issue := range visible.issues {
+		if issue.openIn(initialDate, endDate) {
+			issues = append(issues, issue)
+		}
+	}
+	visible.issues = issues
+
+	batch := visible.entities()
+	for i := range batch {
+		batch[i].SchemaVersion = schemaVersion
+	}
+
+	return batch
+}
+
+// Whether the issue was created by the end date and not resolved before the
+// start. The dates that cannot be read keep it.
+func (issue jiraIssue) openIn(initialDate, endDate time.Time) bool {
+	if created, err := time.Parse(jiraTimeLayout, issue.Fields.Created); err == nil && created.After(endDate) {
+		return false
+	}
+	if resolved, err := time.Parse(jiraTimeLayout, issue.Fields.ResolutionDate); err == nil && resolved.Before(initialDate) {
+		return false
+	}
+
+	return true
+}
+
+func (data *fetchedData) entities() []event {
+	var batch []event
+	for _, pr := range data.prs {
+		source := pr.Source
+		if source == "" {
+			source = "github"
+		}
+		batch = append(batch, prEvents(source, pr)...)
+	}
+	for _, issue := range data.issues {
+		source := issue.Source
+		if source == "" {
+			source = "jira"
+		}
+		batch = append(batch, issueEvents(source, issue)...)
+	}
+
+	return append(batch, data.peopleEvents()...)
+}
+
+// The pull request, then each of its reviews.
+func prEvents(source string, pr pullRequest) []event {
+	batch := []event{{
+		Entity:       entityChangeRequest,
+		Type:         "pull_request",
+		Source:       source,
+		Repository:   pr.Repository.NameWithOwner,
+		Number:       pr.Number,
+		Url:          pr.Url,
+		Author:       pr.Author.Login,
+		Title:        pr.Title,
+		State:        pr.stateAt(time.Now()),
+		Draft:        pr.IsDraft,
+		Additions:    pr.Additions,
+		Deletions:    pr.Deletions,
+		ChangedFiles: pr.ChangedFiles,
+		At:           eventTime(pr.CreatedAt),
+		MergedAt:     eventTime(pr.MergedAt),
+		ClosedAt:     eventTime(pr.ClosedAt),
+	}}
+
+	for _, review := range pr.Reviews.Nodes {
+		batch = append(batch, event{
+			Entity:     entityReview,
+			Type:       "review",
+			Source:     source,
+			Repository: pr.Repository.NameWithOwner,
+			Number:     pr.Number,
+			Url:        pr.Url,
+			Author:     review.Author.Login,
+			State:      review.State,
+			At:         eventTime(review.SubmittedAt),
+		})
+	}
+
+	return batch
+}
+
+// The issue, then each of its status changes, from Jira or from the trackers
+// feeding the Jira report.
+func issueEvents(source string, issue jiraIssue) []event {
+	batch := []event{{
+		Entity:     entityWorkItem,
+		Type:       "issue",
+		Source:     source,
+		Repository: issue.Fields.Project.Key,
+		Key:        issue.Key,
+		Author:     issue.Fields.Assignee.DisplayName,
+		Title:      issue.Fields.Summary,
+		State:      issue.Fields.Status.Name,
+		IssueType:  issue.Fields.IssueType.Name,
+	}}
+
+	for _, history := range issue.Changelog.Histories {
+		for _, item := range history.Items {
+			if item.Field != "status" {
+				continue
+			}
+
+			at := history.Created
+			if created, err := time.Parse(jiraTimeLayout, history.Created); err == nil {
+				at = eventTime(created)
+			}
+
+			batch = append(batch, event{
+				Entity:     entityWorkItem,
+				Type:       "status_change",
+				Source:     source,
+				Repository: issue.Fields.Project.Key,
+				Key:        issue.Key,
+				Author:     history.Author.DisplayName,
+				State:      item.ToString,
+				At:         at,
+			})
+		}
+	}
+
+	return batch
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// The PRs left out of every metric: EXCLUDE_BRANCHES is a comma-separated list
+// of patterns like release/* matched against the base and head branches, and
+// EXCLUDE_TITLES a list of title regexes separated by semicolons, e.g.
+// ^chore\(sync\).
+type prExclusions struct {
+	branches []string
+	titles   []*regexp.Regexp
+}
+
+func loadExclusions() prExclusions {
+	var exclusions prExclusions
+
+	for _, pattern := range strings.Split(getenv("EXCLUDE_BRANCHES"), ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			fatalf(exitConfig, "Invalid pattern %q in EXCLUDE_BRANCHES: %v", pattern, err)
+		}
+		exclusions.branches = append(exclusions.branches, pattern)
+	}
+
+	for _, expression := range strings.Split(getenv("EXCLUDE_TITLES"), ";") {
+		if strings.TrimSpace(expression) == "" {
+			continue
+		}
+		title, err := regexp.Compile(strings.TrimSpace(expression))
+		if err != nil {
+			fatalf(exitConfig, "Invalid regex %q in EXCLUDE_TITLES: %v", expression, err)
+		}
+		exclusions.titles = append(exclusions.titles, title)
+	}
+
+	return exclusions
+}
+
+func (exclusions prExclusions) excludes(pr pullRequest) bool {
+	for _, pattern := range exclusions.branches {
+		for _, branch := range []string{pr.BaseRefName, pr.HeadRefName} {
+			if matched, _ := path.Match(pattern, branch); matched && branch != "" {
+				return true
+			}
+		}
+	}
+
+	for _, title := range exclusions.titles {
+		if title.MatchString(pr.Title) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Leaves the excluded PRs out of the fetched data, like the forgotten people.
+func (data *fetchedData) exclude() {
+	exclusions := loadExclusions()
+	if len(exclusions.branches) == 0 && len(exclusions.titles) == 0 {
+		return
+	}
+
+	var prs []pullRequest
+	for _, pr := range data.prs {
+		if !exclusions.excludes(pr) {
+			prs = append(prs, pr)
+		}
+	}
+
+	if excluded := len(data.prs) - len(prs); excluded > 0 {
+		fmt.Fprintf(progress, "Excluding %d PRs by their branch or title\n", excluded)
+	}
+	data.prs = prs
+}
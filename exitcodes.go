@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Exit codes, so the scripts and CI pipelines wrapping the tool can tell the
+// failures apart. exitError is the 1 of log.Fatal, for any other error.
+const (
+	exitError      = 1
+	exitConfig     = 2
+	exitAuth       = 3
+	exitPartial    = 4
+	exitThresholds = 5
+	exitIntegrity  = 6
+)
+
+// Set by serve, whose refreshes must not exit: fatalf panics with a
+// refreshFailure instead, which the refresh recovers to keep serving the last
+// report.
+var serving bool
+
+type refreshFailure string
+
+func fatalf(code int, format string, v ...interface{}) {
+	if serving {
+		panic(refreshFailure(fmt.Sprintf(format, v...)))
+	}
+
+	log.Printf(format, v...)
+	os.Exit(code)
+}
+
+// Set when the fetch went on after an error, like a PR whose churn could not
+// be read, so --fail-on partial tells the data is incomplete. The providers
+// that are not configured are left out on purpose, and do not count.
+var partialFetch atomic.Bool
+
+func warnPartial(format string, v ...interface{}) {
+	partialFetch.Store(true)
+	fmt.Fprintf(progress, format, v...)
+}
+
+func isAuthError(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "401 Unauthorized") ||
+		strings.Contains(message, "Bad credentials") ||
+		strings.Contains(message, "Resource not accessible")
+}
+
+func (r *report) violatesThresholds() bool {
+	for _, s := range r.Sections {
+		for _, row := range s.Rows {
+			for _, cell := range row {
+				if _, ok := cell.(flagged); ok {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
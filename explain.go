@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type metricDefinition struct {
+	section    string
+	column     string
+	definition string
+	source     string
+}
+
+// How the window of the report is applied to every metric.
+const reportSemantics = `The report covers the PRs created after the start date and up to the end of
+the end date, or the ones merged, closed or updated then with --select. Every state is taken as of the end date, a point in time: a PR
+merged or closed after the end date counts as open, and a PR closed before the
+end date and reopened after it counts as closed. The state is replayed from the
+closed, reopened and merged events of the PR timeline.`
+
+// An empty section applies to the per-person columns added to every section.
+var metricDefinitions = []metricDefinition{
+	{"GitHub", "Total PRs", "PRs created by the person in the window.", "GitHub pullRequests.createdAt"},
+	{"GitHub", "Merged PRs", "PRs of the window merged at or before the end date.", "GitHub timeline MergedEvent, or mergedAt"},
+	{"GitHub", "Merged PRs (%)", "Merged PRs over Total PRs. Flagged when under MERGE_RATE_THRESHOLD.", "Derived"},
+	{"GitHub", "Open PRs", "PRs of the window that were open at the end date: not merged and not closed then, or reopened before it.", "GitHub timeline ClosedEvent, ReopenedEvent and MergedEvent, or closedAt and mergedAt"},
+	{"GitHub", "Added lines", "Lines added by the PRs of the window, whatever their state. Flagged when the average PR size is over PR_SIZE_THRESHOLD.", "GitHub pullRequests.additions"},
+	{"GitHub", "Removed lines", "Lines removed by the PRs of the window, whatever their state. Flagged like Added lines.", "GitHub pullRequests.deletions"},
+	{"GitHub", "Changed files", "Files changed by the PRs of the window, counted once per PR.", "GitHub pullRequests.changedFiles"},
+	{"GitHub", "Requested reviewers / PR", "Distinct people asked to review each PR, averaged over the PRs of the author. Team requests are left out.", "GitHub timeline ReviewRequestedEvent"},
+	{"GitHub", "Reviewers / PR", "Distinct people other than the author who submitted a review by the end date, averaged over the PRs. Flagged under one. The Reviewers per PR table has the same per repository.", "GitHub reviews"},
+	{"GitHub", "Role", "The highest role of the author in the repositories of their PRs, as outside collaborator when not in the organization. Only with AUTHOR_ROLES.", "GitHub collaborators/{login}/permission"},
+	{"GitHub", "Contribution", "Internal when every PR of the person is by someone in ROSTER_FILE or with an OWNER, MEMBER or COLLABORATOR association, External when none is, Mixed otherwise. Without associations, PRs from forks of other owners are external. Only shown when there are external PRs.", "GitHub pullRequests.authorAssociation, isCrossRepository and headRepositoryOwner"},
+	{"GitHub", "Averages", "Column totals divided by the number of people with PRs.", "Derived"},
+	{"Maintainer response", "External PRs", "PRs of the window from external contributors, as in the Contribution column.", "GitHub pullRequests.authorAssociation"},
+	{"Maintainer response", "Answered", "External PRs with a review or comment by a maintainer other than the author by the end date. Maintainers are in ROSTER_FILE or have an OWNER, MEMBER or COLLABORATOR association.", "GitHub reviews and comments"},
+	{"Maintainer response", "Median first response", "Median time from the PR creation to the first answer of a maintainer.", "GitHub reviews.submittedAt and comments.createdAt"},
+	{"Maintainer response", "Decided", "External PRs merged or closed by the end date.", "GitHub timeline, or mergedAt and closedAt"},
+	{"Maintainer response", "Median time to decision", "Median time from the PR creation to its merge or close.", "GitHub mergedAt and closedAt"},
+	{"Abandoned PRs", "Closed unmerged", "PRs of the window closed without merging by the end date.", "GitHub timeline ClosedEvent, or closedAt"},
+	{"Abandoned PRs", "Closed unmerged (%)", "Closed unmerged over Total PRs.", "Derived"},
+	{"Abandoned PRs", "Median time open", "Median time from the creation to the close of the closed unmerged PRs.", "GitHub createdAt and ClosedEvent"},
+	{"Abandoned PRs", "Common labels", "The three labels most used by the closed unmerged PRs.", "GitHub pullRequests.labels"},
+	{"PR aging", "Active PRs", "PRs open at any time of the window: created by the end date and not merged nor closed before the start. The ones created before the window are only known when fetched, e.g. with --select updated.", "GitHub createdAt, mergedAt and closedAt"},
+	{"PR aging", "0-1d", "Active PRs open for less than a day by their merge, close or the end date. The other buckets follow.", "Derived"},
+	{"Longest open PRs", "Time open", "Time from the creation to the merge, close or end date of the ten active PRs open the longest.", "Derived"},
+	{"Review tone", "Scored", "Reviews and comments written by the end date and scored by SENTIMENT_COMMAND or SENTIMENT_URL, from -1 (negative) to 1 (positive).", "GitHub reviews.body and comments.body"},
+	{"Review tone", "Negative (%)", "Scored reviews and comments under SENTIMENT_NEGATIVE. Flagged when twice the share of all the repositories.", "Derived"},
+	{"Negative threads", "Negative", "Negative reviews and comments of the ten PRs with the most of them.", "Derived"},
+	{"Consistency", "Done without PR", "Jira issues moved to Done in the window, and still Done, whose key no merged PR has in its title or branch. PRs merged up to CONSISTENCY_LOOKBACK before the window count. Only in pull-metrics consistency.", "Jira changelog and GitHub title and headRefName"},
+	{"Consistency", "Merged without issue", "PRs merged in the window without the key of a JIRA_PROJECTS issue in their title or branch.", "GitHub mergedAt, title and headRefName"},
+	{"PR WIP", "Average WIP", "PRs of the author open at the same time, averaged over the window: the time each PR was open inside the window, summed, over the length of the window.", "GitHub createdAt, mergedAt and closedAt"},
+	{"PR WIP", "Peak WIP", "Most PRs of the author open at the same time in the window. Flagged when over WIP_LIMIT.", "Derived"},
+	{"Jira WIP", "Average WIP", "Issues the person moved into an active status and not yet to another status, averaged over the window like PR WIP.", "Jira changelog, status field"},
+	{"Jira WIP", "Peak WIP", "Most issues in an active status at the same time in the window. Flagged when over WIP_LIMIT.", "Derived"},
+	{"Forecast", "Median per week", "Median of the items completed in each of the last FORECAST_HISTORY weeks of pull-metrics fetch: PRs merged, or Jira issues moved to Done and still Done. Only in pull-metrics forecast.", "Stored GitHub mergedAt and Jira changelog"},
+	{"Forecast", "85% likely", "Items completed in at least 85% of FORECAST_TRIALS simulations of the next weeks, each week drawing one of the past weeks at random.", "Derived"},
+	{"Churn", "Added lines", "Lines of three characters or more added by the commits of the PRs committed in the window. Merge commits are left out.", "GitHub REST commits, with CHURN_ANALYSIS"},
+	{"Churn", "Churned lines", "Added lines that a later commit of the window, of any PR, removed or modified again in the same file.", "GitHub REST commit diffs"},
+	{"Churn", "Churn (%)", "Churned lines over Added lines.", "Derived"},
+	{"File hotspots", "Merged PRs", "PRs merged by the end date that changed the file, ten files changed by the most PRs. Directory hotspots count the PRs once per directory.", "GitHub pullRequests.files"},
+	{"File hotspots", "Authors", "Distinct authors of those PRs.", "GitHub pullRequests.files"},
+	{"File hotspots", "Fix PRs", "Those PRs with one of DEFECT_LABELS, or a title starting with fix, hotfix or bugfix. Flagged when at least half of them.", "GitHub labels and title"},
+	{"Directory hotspots", "Fix PRs", "Merged PRs changing the directory with one of DEFECT_LABELS, or a title starting with fix, hotfix or bugfix. Flagged when at least half of them.", "GitHub labels and title"},
+	{"Skipped statuses", "Skipped issues", "Issues moved to Done in the window that were never in one of JIRA_WORK_STATUSES, counted for whoever moved them to Done. The Jira table misses them.", "Jira changelog"},
+	{"Jira cycle time", "Median cycle time", "Median time from the first move into an active status to the last move to Done, of the issues started in the window and Done by its end, per issue type. The median in days is the jira_median_cycle_days_<type> output.", "Jira changelog"},
+	{"Jira cycle time", "85th percentile", "85% of those issues were done within this time.", "Jira changelog"},
+	{"Bug SLA", "Resolved bugs", "Issues of BUG_ISSUE_TYPES, Bug by default, with a priority in BUG_SLAS resolved in the window, per project and current assignee.", "Jira priority, created and resolutiondate"},
+	{"Bug SLA", "Breached", "Resolved bugs that took longer than the SLA of their priority from creation to resolution.", "Derived"},
+	{"Bug SLA", "Open past SLA", "Bugs still unresolved at the end date and created longer than their SLA before it.", "Derived"},
+	{"Review coverage", "Reviewed", "PRs merged by the end date with a review of someone other than their author submitted before the merge, per repository. The percentage is the github_review_coverage output.", "GitHub reviews and mergedAt"},
+	{"Review coverage by team", "Review coverage (%)", "The same per roster team of the author, with a github_review_coverage_<team> output each.", "GitHub reviews and ROSTER_FILE"},
+	{"Integration friction", "Force pushes / PR", "Force pushes to the head branch per PR, rebases included.", "GitHub timeline HeadRefForcePushedEvent"},
+	{"Integration friction", "Update merges / PR", "Merge commits among the last 30 commits of the PR per PR, like the ones of Update branch.", "GitHub commits parents"},
+	{"Integration friction", "PRs with friction (%)", "PRs with at least a force push or an update merge.", "Derived"},
+	{"Leaderboards", "Top reviewers", "People with the most PRs of the window they reviewed, their own left out. Only with --leaderboards, LEADERBOARD_SIZE places.", "GitHub reviews"},
+	{"Leaderboards", "Fastest review turnaround", "Lowest median time from a review request to the first review of the reviewer, among the ones with 3 reviews or more.", "GitHub timeline ReviewRequestedEvent and reviews"},
+	{"Leaderboards", "Most PRs merged", "Authors with the most PRs of the window merged by the end date.", "GitHub mergedAt"},
+	{"GitHub", "Team median", "With --view ic, the median of the people of a table with a row per person, VIEW_PERSON included, shown below their row in place of everybody else. The comparisons between people, like Review pairs and Leaderboards, are left out.", "Derived"},
+	{"Velocity", "Working days", "Weekdays of the period the people of the roster team had started by, minus their public holidays, added up. Only with --velocity.", "ROSTER_FILE and HOLIDAYS"},
+	{"Velocity", "Available days", "Working days minus the absences and part-time periods of the people.", "AVAILABILITY_FILE"},
+	{"Velocity", "Capacity (%)", "Available days over Working days.", "Derived"},
+	{"Velocity", "Merged PRs / person-week", "PRs of the window authored by the team and merged in the period, per 5 Available days. The github_velocity_<team> output is the one of the whole window.", "GitHub mergedAt"},
+	{"Velocity", "Merged PRs at full capacity", "Merged PRs over Capacity (%): the merged PRs the team would have had with everybody around.", "Derived"},
+	{"Services", "Total PRs", "PRs that changed a file of the service, the one of the longest SERVICES_FILE prefix of the path. A PR counts for every service it changed.", "GitHub pullRequests.files"},
+	{"Services", "Median cycle time", "Median time from creation to merge of the merged PRs of the service.", "GitHub createdAt and mergedAt"},
+	{"Services", "Changed lines", "Added plus removed lines of the PRs in the files of the service.", "GitHub pullRequests.files"},
+	{"Dependency PRs", "Dependency PRs", "PRs changing a dependency manifest of DEPENDENCY_MANIFESTS, go.mod or package.json among others, per repository. The lock files do not count.", "GitHub pullRequests.files"},
+	{"Dependency PRs", "Median approval latency", "Median time from the PR being ready for review to the first approval by somebody else, up to the end date.", "GitHub timeline and reviews"},
+	{"Dependency PRs", "Merged unapproved", "Dependency PRs merged without an approval by somebody else.", "GitHub reviews and mergedAt"},
+	{"Dependency PRs", "Major bumps", "Dependency PRs moving a dependency to a new major version: titled from x to y with another major, like Dependabot, to vN, like Renovate, or with a label naming major.", "GitHub title and labels"},
+	{"Cohorts", "People", "People in the roster group with PRs in the window.", "ROSTER_FILE"},
+	{"Cohorts", "PRs / person", "Total PRs of the group divided by its people.", "Derived"},
+	{"Cohorts", "Merged PRs (%)", "Merged PRs of the group over its Total PRs.", "Derived"},
+	{"Review assignment", "Reviewer requested", "PRs with a reviewer requested or an assignee by the end date.", "GitHub timeline ReviewRequestedEvent and AssignedEvent"},
+	{"Review assignment", "Nobody asked", "PRs ready for review without any reviewer requested nor assignee by the end date. Drafts are left out.", "GitHub timeline ReviewRequestedEvent and AssignedEvent"},
+	{"Review assignment", "Median latency", "Median time from the PR being ready for review (its creation, or the first ReadyForReviewEvent of drafts) to the first request or assignment.", "GitHub timeline"},
+	{"Review response", "Requests", "First review request of each reviewer on the PRs of the window. Team requests are left out.", "GitHub timeline ReviewRequestedEvent"},
+	{"Review response", "Reviewed", "Requests answered with a submitted review by the end date.", "GitHub reviews.submittedAt"},
+	{"Review response", "Pending", "Unanswered requests still inside REVIEW_SLA at the end date. They do not count against the reviewer.", "Derived"},
+	{"Review response", "Median response", "Median time from the request to the first submitted review of the reviewer.", "GitHub timeline and reviews"},
+	{"Review response", "Within SLA (%)", "Requests answered within REVIEW_SLA over the requests that were due.", "Derived"},
+	{"Review matrix", "Total", "Reviews submitted by the reviewer team on the PRs of the author team, once per reviewer and PR. Self reviews are left out.", "GitHub reviews and the team column of ROSTER_FILE"},
+	{"Review pairs", "Reviewers", "People who reviewed the PRs of the author, once per reviewer and PR. Flagged when only one did, and the pair is flagged when both only review each other.", "GitHub reviews"},
+	{"Ownership", "Changed lines", "Added plus removed lines of the area in the PRs merged by the end date.", "GitHub pullRequests.files"},
+	{"Ownership", "Top contributor (%)", "Share of the changed lines authored by the top contributor. Flagged when over OWNERSHIP_THRESHOLD.", "Derived"},
+	{"Ownership", "Bus factor", "Fewest people that authored more than half of the changed lines of the area.", "Derived"},
+	{"Repository groups", "Merged PRs (%)", "Merged PRs of the group over its Total PRs.", "Derived"},
+	{"Jira", "Total started", "Issues whose last move from another status into one of JIRA_ACTIVE_STATUSES, In Progress by default, inside the window was done by the person. With several active statuses, a column per status counts the moves into it.", "Jira changelog, status field"},
+	{"Jira", "Spikes started", "Started issues of type Spike.", "Jira issuetype"},
+	{"Jira", "Closed", "Started issues whose current status is Done or Rejected. This is the status now, not at the end date.", "Jira status"},
+	{"", "Available days", "Working days of the window, minus the public holidays of the country of the person and the absences and part-time periods.", "HOLIDAYS and AVAILABILITY_FILE"},
+	{"", "PRs / day", "Total PRs over Available days.", "Derived"},
+	{"", "Started / day", "Total started over Available days.", "Derived"},
+	{"", "On call", "Time on call inside the window, with overlapping shifts merged.", "ONCALL_FILE and PagerDuty"},
+}
+
+// Sections per project share the definitions of their parent section.
+func (metric metricDefinition) appliesTo(s *reportSection) bool {
+	return metric.section == "" || metric.section == s.Name || strings.HasPrefix(s.Name, metric.section+" ")
+}
+
+// The definitions of the columns of the section, in the order of the columns.
+func definitionsFor(s *reportSection) []metricDefinition {
+	var definitions []metricDefinition
+
+	columns := s.columns()
+	if len(s.Footer) > 0 {
+		columns = append(columns, fmt.Sprint(s.Footer[0]))
+	}
+
+	for _, column := range columns {
+		for _, metric := range append(metricDefinitions, derivedDefinitions()...) {
+			if metric.column == column && metric.appliesTo(s) {
+				definitions = append(definitions, metric)
+				break
+			}
+		}
+	}
+
+	return definitions
+}
+
+func printExplanation(w io.Writer) {
+	fmt.Fprintln(w, reportSemantics)
+
+	section := "-"
+	for _, metric := range metricDefinitions {
+		if metric.section != section {
+			section = metric.section
+
+			title := section
+			if title == "" {
+				title = "Per-person columns"
+			}
+			fmt.Fprintf(w, "\n%s\n", title)
+		}
+
+		fmt.Fprintf(w, "  %s: %s\n    Source: %s\n", metric.column, metric.definition, metric.source)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// The raw data collected by pull-metrics fetch, so pull-metrics report renders
+// any window inside it without waiting for the APIs.
+type fetchedStore struct {
+	FetchedAt   time.Time
+	InitialDate time.Time
+	EndDate     time.Time
+	GithubOk    bool
+	OtherPRsOk  bool
+	JiraOk      bool
+	PRs         []pullRequest
+	Issues      []jiraIssue
+	Names       map[string]string
+}
+
+func (s *store) fetchedPath() string {
+	return filepath.Join(s.dir, "fetched.json")
+}
+
+func (s *store) loadFetched() (*fetchedStore, error) {
+	content, err := os.ReadFile(s.fetchedPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fetched := &fetchedStore{}
+	return fetched, json.Unmarshal(content, fetched)
+}
+
+func (s *store) saveFetched(fetched *fetchedStore) error {
+	content, err := json.Marshal(fetched)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(s.fetchedPath()+".tmp", content, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(s.fetchedPath()+".tmp", s.fetchedPath())
+}
+
+// Saves the fetched data again when the change says it changed, for forget
+// and the retention.
+func (s *store) updateFetched(change func(*fetchedStore) bool) error {
+	fetched, err := s.loadFetched()
+	if err != nil || fetched == nil || !change(fetched) {
+		return err
+	}
+
+	return s.saveFetched(fetched)
+}
+
+// The GitHub node ID of the PR, which stays the same when its repository is
+// renamed or transferred, or its URL for the other providers.
+func (pr pullRequest) storeKey() string {
+	if pr.Id != "" {
+		return pr.Id
+	}
+
+	return pr.Url
+}
+
+// The PRs and issues fetched again replace the stored ones, since their state
+// may have changed. A PR stored before its node ID was fetched is replaced by
+// the one with the same URL.
+func (fetched *fetchedStore) merge(data *fetchedData, initialDate, endDate time.Time) {
+	prs := make(map[string]pullRequest)
+	byUrl := make(map[string]string)
+	for _, pr := range append(fetched.PRs[:len(fetched.PRs):len(fetched.PRs)], data.prs...) {
+		key := pr.storeKey()
+		if previous, ok := byUrl[pr.Url]; ok && previous != key && (pr.Id == "" || prs[previous].Id == "") {
+			delete(prs, previous)
+		}
+		prs[key] = pr
+		byUrl[pr.Url] = key
+	}
+
+	fetched.PRs = nil
+	for _, pr := range prs {
+		fetched.PRs = append(fetched.PRs, pr)
+	}
+	sort.Slice(fetched.PRs, func(i, j int) bool {
+		return fetched.PRs[i].CreatedAt.After(fetched.PRs[j].CreatedAt)
+	})
+
+	issues := make(map[string]jiraIssue)
+	for _, issue := range fetched.Issues {
+		issues[issue.Key] = issue
+	}
+	for _, issue := range data.issues {
+		issues[issue.Key] = issue
+	}
+
+	fetched.Issues = nil
+	for _, issue := range issues {
+		fetched.Issues = append(fetched.Issues, issue)
+	}
+	sort.Slice(fetched.Issues, func(i, j int) bool {
+		return fetched.Issues[i].Key < fetched.Issues[j].Key
+	})
+
+	if fetched.InitialDate.IsZero() || initialDate.Before(fetched.InitialDate) {
+		fetched.InitialDate = initialDate
+	}
+	if endDate.After(fetched.EndDate) {
+		fetched.EndDate = endDate
+	}
+
+	fetched.GithubOk = fetched.GithubOk || data.githubOk
+	fetched.OtherPRsOk = fetched.OtherPRsOk || data.otherPRsOk
+	fetched.JiraOk = fetched.JiraOk || data.jiraOk
+	fetched.FetchedAt = time.Now().UTC()
+
+	namesMutex.Lock()
+	if fetched.Names == nil {
+		fetched.Names = make(map[string]string, len(names))
+	}
+	for login, name := range names {
+		fetched.Names[login] = name
+	}
+	namesMutex.Unlock()
+}
+
+// Without dates, fetch goes on from the last run: from its end, or from the
+// oldest PR that was still open then, whose state may have changed since.
+func (fetched *fetchedStore) resumeDate() time.Time {
+	start := fetched.EndDate.AddDate(0, 0, -1)
+	for _, pr := range fetched.PRs {
+		if pr.stateAt(fetched.FetchedAt) == prOpen && pr.CreatedAt.Before(start) {
+			start = pr.CreatedAt.Add(-time.Second)
+		}
+	}
+
+	return start
+}
+
+// pull-metrics fetch [<start date> [<end date>]] collects the data into the
+// store, for a nightly job. GitHub requests wait for the rate limit to reset
+// when fewer than GITHUB_RATE_LIMIT_RESERVE points are left, so the people
+// using the same token during the day keep their budget.
+func fetchCommand(s *store, args []string, options reportOptions) {
+	s = requireStore(s)
+
+	fetched, err := s.loadFetched()
+	if err != nil {
+		log.Fatalf("Error reading the fetched data: %v", err)
+	}
+
+	var initialDate, endDate time.Time
+	switch {
+	case len(args) > 0 || reportingRange != "":
+		initialDate, endDate = parseWindow(args)
+	case fetched != nil:
+		initialDate, endDate = fetched.resumeDate(), time.Now()
+	default:
+		fatalf(exitConfig, "pull-metrics fetch <start date> [<end date>] (the dates are optional after the first fetch)")
+	}
+
+	githubRateLimitReserve = envInt("GITHUB_RATE_LIMIT_RESERVE", 1000)
+
+	fmt.Fprintf(progress, "Fetching %s - %s\n", initialDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	data := fetchData(initialDate, endDate, options)
+	publishEvents()
+
+	if fetched == nil {
+		fetched = &fetchedStore{}
+	}
+	fetched.merge(data, initialDate, endDate)
+	fetched.forget(options.forgotten)
+	if cutoff, ok := retentionCutoff(); ok {
+		fetched.expire(cutoff)
+	}
+
+	if err := s.saveFetched(fetched); err != nil {
+		log.Fatalf("Error saving the fetched data: %v", err)
+	}
+
+	fmt.Printf("%d PRs and %d Jira issues fetched, %s - %s stored\n", len(data.prs), len(data.issues),
+		fetched.InitialDate.Format("2006-01-02"), fetched.EndDate.Format("2006-01-02"))
+}
+
+// The data of pull-metrics report, from the store instead of the APIs.
+func loadFetchedData(s *store, initialDate, endDate time.Time, options reportOptions) *fetchedData {
+	fetched, err := requireStore(s).loadFetched()
+	if err != nil {
+		log.Fatalf("Error reading the fetched data: %v", err)
+	}
+	if fetched == nil {
+		fatalf(exitConfig, "Nothing fetched yet, run pull-metrics fetch first")
+	}
+
+	day := func(t time.Time) string { return t.Format("2006-01-02") }
+	if day(initialDate) < day(fetched.InitialDate) || day(endDate) > day(fetched.EndDate) {
+		fmt.Fprintf(progress, "Only %s - %s was fetched, the rest of the window has no data\n",
+			fetched.InitialDate.Format("2006-01-02"), fetched.EndDate.Format("2006-01-02"))
+	}
+
+	for login, name := range fetched.Names {
+		rememberName(login, name)
+	}
+
+	data := &fetchedData{
+		prs:        fetched.PRs,
+		issues:     fetched.Issues,
+		githubOk:   fetched.GithubOk,
+		otherPRsOk: fetched.OtherPRsOk,
+		jiraOk:     fetched.JiraOk,
+		people:     loadOverlays(initialDate, endDate, options),
+		options:    options,
+	}
+
+	data.mergeRenames()
+	data.forget(options.forgotten)
+	data.exclude()
+	scoreComments(data.prs)
+	data.emit()
+
+	return data
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The likelihoods reported by the forecast: the share of the simulations that
+// completed at least the number of items.
+var forecastLikelihoods = []float64{50, 85, 95}
+
+// Completes weeks many times, each week drawing the throughput of one of the
+// past weeks at random, and returns the number of items completed with each
+// likelihood. The seed is fixed, so the same history gives the same answer.
+func monteCarlo(weekly []float64, weeks, trials int) []int {
+	random := rand.New(rand.NewPCG(1, 2))
+
+	totals := make([]float64, trials)
+	for i := range totals {
+		for week := 0; week < weeks; week++ {
+			totals[i] += weekly[random.IntN(len(weekly))]
+		}
+	}
+
+	var forecast []int
+	for _, likelihood := range forecastLikelihoods {
+		forecast = append(forecast, int(math.Floor(percentile(totals, 100-likelihood))))
+	}
+
+	return forecast
+}
+
+// The items completed in each of the weeks before the end, oldest first.
+func weeklyThroughput(completed []time.Time, endDate time.Time, weeks int) []float64 {
+	throughput := make([]float64, weeks)
+	start := endDate.AddDate(0, 0, -7*weeks)
+
+	for _, at := range completed {
+		if !at.After(start) || at.After(endDate) {
+			continue
+		}
+
+		week := int(at.Sub(start) / (7 * 24 * time.Hour))
+		if week >= weeks {
+			week = weeks - 1
+		}
+		throughput[week]++
+	}
+
+	return throughput
+}
+
+// When the issue was last moved to Done.
+func (issue jiraIssue) doneAt() (time.Time, bool) {
+	var done time.Time
+	for _, history := range issue.Changelog.Histories {
+		for _, item := range history.Items {
+			if item.Field != "status" || item.ToString != "Done" {
+				continue
+			}
+			if created, err := time.Parse(jiraTimeLayout, history.Created); err == nil && created.After(done) {
+				done = created
+			}
+		}
+	}
+
+	return done, !done.IsZero() && issue.Fields.Status.Name == "Done"
+}
+
+// pull-metrics forecast <weeks> projects, per roster team, how many PRs will
+// be merged and Jira issues done in the next weeks, from the weekly throughput
+// of the last FORECAST_HISTORY weeks of pull-metrics fetch.
+func forecastCommand(s *store, args []string, format string, options terminalOptions, settings reportOptions) {
+	if len(args) != 1 {
+		fatalf(exitConfig, "pull-metrics forecast <weeks>")
+	}
+
+	weeks, err := strconv.Atoi(args[0])
+	if err != nil || weeks <= 0 {
+		fatalf(exitConfig, "Invalid number of weeks %q", args[0])
+	}
+
+	fetched, err := requireStore(s).loadFetched()
+	if err != nil {
+		log.Fatalf("Error reading the fetched data: %v", err)
+	}
+	if fetched == nil {
+		fatalf(exitConfig, "Nothing fetched yet, run pull-metrics fetch first")
+	}
+
+	for login, name := range fetched.Names {
+		rememberName(login, name)
+	}
+
+	history := envInt("FORECAST_HISTORY", 12)
+	if available := int(fetched.EndDate.Sub(fetched.InitialDate) / (7 * 24 * time.Hour)); available < history {
+		history = available
+	}
+	if history < 1 {
+		fatalf(exitConfig, "The forecast needs at least a week of fetched data")
+	}
+
+	data := &fetchedData{prs: fetched.PRs, issues: fetched.Issues}
+	data.mergeRenames()
+	data.forget(settings.forgotten)
+
+	people := loadRoster(settings.rampUp)
+	team := func(identities ...string) string {
+		if people == nil {
+			return "Everyone"
+		}
+		return people.team(identities...)
+	}
+
+	type series struct{ prs, issues []time.Time }
+	byTeam := make(map[string]*series)
+	add := func(name string) *series {
+		if byTeam[name] == nil {
+			byTeam[name] = &series{}
+		}
+		return byTeam[name]
+	}
+
+	for _, pr := range data.prs {
+		if pr.Merged {
+			t := team(pr.Author.Login, names[pr.Author.Login])
+			add(t).prs = append(add(t).prs, pr.MergedAt)
+		}
+	}
+	for _, issue := range data.issues {
+		if at, ok := issue.doneAt(); ok {
+			t := team(issue.Fields.Assignee.DisplayName)
+			add(t).issues = append(add(t).issues, at)
+		}
+	}
+
+	section := &reportSection{
+		Name:     "Forecast",
+		Title:    "Forecast for the next " + strconv.Itoa(weeks) + " weeks",
+		Summary:  "From the weekly throughput of the " + strconv.Itoa(history) + " weeks before " + formatDate(fetched.EndDate),
+		Header:   table.Row{"Team", "Items", "Median per week"},
+		Centered: []int{3},
+	}
+	for i, likelihood := range forecastLikelihoods {
+		section.Header = append(section.Header, strconv.Itoa(int(likelihood))+"% likely")
+		section.Centered = append(section.Centered, i+4)
+	}
+
+	var teams []string
+	for name := range byTeam {
+		teams = append(teams, name)
+	}
+	sort.Strings(teams)
+
+	trials := envInt("FORECAST_TRIALS", 10000)
+	for _, name := range teams {
+		for _, items := range []struct {
+			label     string
+			completed []time.Time
+			ok        bool
+		}{
+			{"Merged PRs", byTeam[name].prs, fetched.GithubOk || fetched.OtherPRsOk},
+			{"Done issues", byTeam[name].issues, fetched.JiraOk},
+		} {
+			if !items.ok || len(items.completed) == 0 {
+				continue
+			}
+
+			weekly := weeklyThroughput(items.completed, fetched.EndDate, history)
+			row := table.Row{name, items.label, average(median(weekly))}
+			for _, count := range monteCarlo(weekly, weeks, trials) {
+				row = append(row, count)
+			}
+			section.Rows = append(section.Rows, row)
+		}
+	}
+
+	rep := &report{InitialDate: fetched.EndDate, EndDate: fetched.EndDate.AddDate(0, 0, 7*weeks), Sections: []*reportSection{section}}
+	if err := rep.write(os.Stdout, format, options); err != nil {
+		log.Fatalf("Error writing the report: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The merges into the head branch among the last commits of the PR, most of
+// them "Update branch" merges of the base branch.
+func (pr pullRequest) updateMerges() int {
+	merges := 0
+	for _, commit := range pr.Commits.Nodes {
+		if commit.Commit.Parents.TotalCount > 1 {
+			merges++
+		}
+	}
+
+	return merges
+}
+
+// Force pushes and update merges per PR, per repository, as a sign of how
+// often the PRs have to catch up with a busy base branch.
+func frictionSection(prs []pullRequest) *reportSection {
+	type friction struct {
+		prs          int
+		withFriction int
+		forcePushes  int
+		merges       int
+	}
+
+	byRepo := make(map[string]*friction)
+	total := &friction{}
+	for _, pr := range prs {
+		repo := pr.Repository.NameWithOwner
+		if byRepo[repo] == nil {
+			byRepo[repo] = &friction{}
+		}
+
+		forcePushes, merges := pr.ForcePushes.TotalCount, pr.updateMerges()
+		for _, f := range []*friction{byRepo[repo], total} {
+			f.prs++
+			f.forcePushes += forcePushes
+			f.merges += merges
+			if forcePushes+merges > 0 {
+				f.withFriction++
+			}
+		}
+	}
+
+	section := &reportSection{
+		Name:     "Integration friction",
+		Title:    "Integration friction",
+		Summary:  "Force pushes and merges into the head branch, like Update branch, per PR",
+		Header:   table.Row{"Repository", "Total PRs", "Force pushes / PR", "Update merges / PR", "PRs with friction (%)"},
+		Centered: []int{2, 3, 4, 5},
+	}
+
+	for _, repo := range sortedKeys(byRepo) {
+		f := byRepo[repo]
+		section.Rows = append(section.Rows, table.Row{repo, f.prs, averageOf(f.forcePushes, f.prs), averageOf(f.merges, f.prs), percentCell(f.withFriction, f.prs)})
+	}
+
+	if total.prs > 0 {
+		section.Footer = table.Row{"All", total.prs, averageOf(total.forcePushes, total.prs), averageOf(total.merges, total.prs), percentCell(total.withFriction, total.prs)}
+		section.Outputs = map[string]string{
+			"github_force_pushes_per_pr":  formatOutput(float64(total.forcePushes) / float64(total.prs)),
+			"github_update_merges_per_pr": formatOutput(float64(total.merges) / float64(total.prs)),
+		}
+	}
+
+	return section
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Gerrit answers with timestamps in UTC, without the zone.
+type gerritTime struct {
+	time.Time
+}
+
+func (t *gerritTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	parsed, err := time.Parse("2006-01-02 15:04:05.000000000", value)
+	if err != nil {
+		return err
+	}
+
+	t.Time = parsed
+	return nil
+}
+
+type gerritAccount struct {
+	AccountId int `json:"_account_id"`
+	Name      string
+	Email     string
+	Username  string
+}
+
+// The username when the server exposes it, so the changes of the people with
+// the same login on GitHub add up.
+func (account gerritAccount) login() string {
+	switch {
+	case account.Username != "":
+		return account.Username
+	case account.Email != "":
+		return account.Email
+	}
+
+	return fmt.Sprint(account.AccountId)
+}
+
+type gerritChange struct {
+	Project         string
+	Branch          string
+	Number          int `json:"_number"`
+	Subject         string
+	Status          string
+	Owner           gerritAccount
+	Created         gerritTime
+	Updated         gerritTime
+	Submitted       gerritTime
+	Insertions      int
+	Deletions       int
+	WorkInProgress  bool   `json:"work_in_progress"`
+	CommentCount    int    `json:"total_comment_count"`
+	CurrentRevision string `json:"current_revision"`
+	Revisions       map[string]struct {
+		Files map[string]struct {
+			LinesInserted int `json:"lines_inserted"`
+			LinesDeleted  int `json:"lines_deleted"`
+		}
+	}
+	Labels map[string]struct {
+		All []struct {
+			gerritAccount
+			Value int
+			Date  gerritTime
+		}
+	}
+	MoreChanges bool `json:"_more_changes"`
+}
+
+// Maps the change to a pull request: the current patchset gives the files, and
+// the Code-Review votes the reviews.
+func (change gerritChange) pullRequest(baseUrl string) pullRequest {
+	var pr pullRequest
+
+	pr.Author.Login = change.Owner.login()
+	pr.Number = change.Number
+	pr.Url = fmt.Sprintf("%s/c/%s/+/%d", baseUrl, change.Project, change.Number)
+	pr.Title = change.Subject
+	pr.BaseRefName = change.Branch
+	pr.CreatedAt = change.Created.Time
+	pr.UpdatedAt = change.Updated.Time
+	pr.Additions = change.Insertions
+	pr.Deletions = change.Deletions
+	pr.TotalCommentsCount = change.CommentCount
+	pr.IsDraft = change.WorkInProgress
+	pr.Repository.NameWithOwner = change.Project
+
+	switch change.Status {
+	case "MERGED":
+		pr.Closed, pr.ClosedAt = true, change.Submitted.Time
+		pr.Merged, pr.MergedAt = true, change.Submitted.Time
+	case "ABANDONED":
+		pr.Closed, pr.ClosedAt = true, change.Updated.Time
+	}
+
+	if revision, ok := change.Revisions[change.CurrentRevision]; ok {
+		for path, file := range revision.Files {
+			// Magic files like /COMMIT_MSG are not part of the change.
+			if strings.HasPrefix(path, "/") {
+				continue
+			}
+
+			pr.ChangedFiles++
+			pr.Files.Nodes = append(pr.Files.Nodes, pullRequestFile{Path: path, Additions: file.LinesInserted, Deletions: file.LinesDeleted})
+		}
+	}
+
+	for _, vote := range change.Labels["Code-Review"].All {
+		state := ""
+		switch {
+		case vote.Value > 0:
+			state = "APPROVED"
+		case vote.Value < 0:
+			state = "CHANGES_REQUESTED"
+		default:
+			continue
+		}
+
+		review := pullRequestReview{State: state, SubmittedAt: vote.Date.Time}
+		review.Author.Login = vote.login()
+		pr.Reviews.Nodes = append(pr.Reviews.Nodes, review)
+	}
+
+	return pr
+}
+
+func gerritProjects() []string {
+	var projects []string
+	for _, project := range strings.Split(getenv("GERRIT_PROJECTS"), ",") {
+		if project = strings.TrimSpace(project); project != "" {
+			projects = append(projects, project)
+		}
+	}
+
+	return projects
+}
+
+func fetchGerritChanges(initialDate, endDate time.Time) ([]pullRequest, bool) {
+	baseUrl := strings.TrimSuffix(getenv("GERRIT_URL"), "/")
+	if baseUrl == "" {
+		return nil, false
+	}
+
+	user := getenv("GERRIT_USER")
+	token := getenv("GERRIT_TOKEN")
+
+	// Authenticated requests go through /a/, anonymous ones only see the
+	// public projects.
+	apiUrl := baseUrl
+	if user != "" {
+		apiUrl += "/a"
+	}
+
+	// Gerrit filters on the last update, which is never before the creation,
+	// so the changes created in the window are among these.
+	query := fmt.Sprintf(`after:"%s"`, initialDate.UTC().Format("2006-01-02 15:04:05"))
+
+	var projects []string
+	for _, project := range gerritProjects() {
+		projects = append(projects, "project:"+project)
+	}
+	if len(projects) > 0 {
+		query += " (" + strings.Join(projects, " OR ") + ")"
+	}
+
+	client := newHTTPClient("GERRIT")
+
+	var prs []pullRequest
+	for offset := 0; ; {
+		values := url.Values{
+			"q": {query},
+			"o": {"DETAILED_ACCOUNTS", "DETAILED_LABELS", "CURRENT_REVISION", "CURRENT_FILES"},
+			"n": {"100"},
+			"S": {fmt.Sprint(offset)},
+		}
+
+		req, err := http.NewRequest("GET", apiUrl+"/changes/?"+values.Encode(), nil)
+		if err != nil {
+			fatalf(exitConfig, "Error parsing GERRIT_URL: %v", err)
+		}
+
+		if user != "" {
+			req.SetBasicAuth(user, token)
+		}
+		req.Header.Add("Accept", "application/json")
+
+		fmt.Fprintf(progress, "Requesting the changes %d to %d to Gerrit\n", offset+1, offset+100)
+
+		res, err := client.Do(req)
+		if err != nil {
+			fatalf(exitError, "Error requesting the Gerrit changes: %v", err)
+		}
+
+		if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+			res.Body.Close()
+			fatalf(exitAuth, "Gerrit rejected the credentials: %s", res.Status)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			fatalf(exitError, "Error requesting the Gerrit changes: %s", res.Status)
+		}
+
+		// Every JSON answer starts with a line against XSSI.
+		body := bufio.NewReader(res.Body)
+		body.ReadString('\n')
+
+		var changes []gerritChange
+		err = json.NewDecoder(body).Decode(&changes)
+		res.Body.Close()
+		if err != nil {
+			fatalf(exitError, "Error decoding the Gerrit changes: %v", err)
+		}
+
+		for _, change := range changes {
+			if !fetchedIn(change.Created.Time, change.Updated.Time, initialDate, endDate) {
+				continue
+			}
+
+			rememberName(change.Owner.login(), change.Owner.Name)
+			for _, vote := range change.Labels["Code-Review"].All {
+				rememberName(vote.login(), vote.Name)
+			}
+
+			pr := change.pullRequest(baseUrl)
+			pr.Source = "gerrit"
+			prs = append(prs, pr)
+		}
+
+		if len(changes) == 0 || !changes[len(changes)-1].MoreChanges {
+			break
+		}
+
+		offset += len(changes)
+	}
+
+	return prs, true
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+func appendToFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+	return err
+}
+
+// Workflow commands need %, \r and \n escaped in their messages.
+func escapeWorkflowCommand(message string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(message)
+}
+
+// And their properties, like the title, : and , too.
+func escapeWorkflowProperty(property string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(property)
+}
+
+func writeGithubActionsOutputs(rep *report) {
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendToFile(path, rep.markdown()); err != nil {
+			log.Fatalf("Error writing the step summary: %v", err)
+		}
+	} else {
+		fmt.Fprintln(progress, "GITHUB_STEP_SUMMARY not provided. Skipping the step summary.")
+	}
+
+	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+		var lines []string
+		for _, s := range rep.Sections {
+			for name, value := range s.Outputs {
+				lines = append(lines, name+"="+value)
+			}
+		}
+		sort.Strings(lines)
+
+		if err := appendToFile(path, strings.Join(lines, "\n")+"\n"); err != nil {
+			log.Fatalf("Error writing the step outputs: %v", err)
+		}
+	} else {
+		fmt.Fprintln(progress, "GITHUB_OUTPUT not provided. Skipping the step outputs.")
+	}
+
+	// The runner reads the annotations on stderr too, so they stay out of a
+	// json or csv report on stdout.
+	for _, s := range rep.Sections {
+		for _, row := range s.Rows {
+			for i, cell := range row {
+				f, ok := cell.(flagged)
+				if !ok {
+					continue
+				}
+
+				command := "warning"
+				if f.level == critical {
+					command = "error"
+				}
+
+				fmt.Fprintf(os.Stderr, "::%s title=%s::%s\n", command, escapeWorkflowProperty(s.Name), escapeWorkflowCommand(fmt.Sprintf("%v: %v is %v", row[0], s.Header[i], f.value)))
+			}
+		}
+	}
+}
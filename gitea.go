@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type giteaUser struct {
+	Login    string
+	FullName string `json:"full_name"`
+}
+
+type giteaPull struct {
+	Number       int
+	HtmlUrl      string `json:"html_url"`
+	Title        string
+	Base         struct{ Ref string }
+	Head         struct{ Ref string }
+	User         giteaUser
+	State        string
+	Draft        bool
+	Merged       bool
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	MergedAt     *time.Time `json:"merged_at"`
+	Additions    int
+	Deletions    int
+	ChangedFiles int `json:"changed_files"`
+	Comments     int
+}
+
+type giteaReview struct {
+	User        giteaUser
+	State       string
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+var giteaReviewStates = map[string]string{
+	"APPROVED":        "APPROVED",
+	"REQUEST_CHANGES": "CHANGES_REQUESTED",
+	"COMMENT":         "COMMENTED",
+	"PENDING":         "PENDING",
+}
+
+type giteaFile struct {
+	Filename  string
+	Additions int
+	Deletions int
+}
+
+type giteaClient struct {
+	baseUrl string
+	token   string
+	client  *http.Client
+}
+
+func (c *giteaClient) get(path string, out interface{}) {
+	req, err := http.NewRequest("GET", c.baseUrl+"/api/v1"+path, nil)
+	if err != nil {
+		fatalf(exitConfig, "Error parsing GITEA_URL: %v", err)
+	}
+
+	req.Header.Add("Authorization", "token "+c.token)
+	req.Header.Add("Accept", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		fatalf(exitError, "Error requesting %s to Gitea: %v", path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		fatalf(exitAuth, "Gitea rejected the credentials: %s", res.Status)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		fatalf(exitError, "Error requesting %s to Gitea: %s", path, res.Status)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		fatalf(exitError, "Error decoding %s from Gitea: %v", path, err)
+	}
+}
+
+// Maps the pull request to the GitHub model, with its reviews and files.
+func (c *giteaClient) pullRequest(repo string, pull giteaPull) pullRequest {
+	var pr pullRequest
+
+	pr.Author.Login = pull.User.Login
+	pr.Number = pull.Number
+	pr.Url = pull.HtmlUrl
+	pr.Title = pull.Title
+	pr.BaseRefName = pull.Base.Ref
+	pr.HeadRefName = pull.Head.Ref
+	pr.CreatedAt = pull.CreatedAt
+	pr.UpdatedAt = pull.UpdatedAt
+	pr.Additions = pull.Additions
+	pr.Deletions = pull.Deletions
+	pr.ChangedFiles = pull.ChangedFiles
+	pr.TotalCommentsCount = pull.Comments
+	pr.IsDraft = pull.Draft
+	pr.Repository.NameWithOwner = repo
+
+	if pull.State == "closed" && pull.ClosedAt != nil {
+		pr.Closed, pr.ClosedAt = true, *pull.ClosedAt
+	}
+	if pull.Merged && pull.MergedAt != nil {
+		pr.Merged, pr.MergedAt = true, *pull.MergedAt
+	}
+
+	var reviews []giteaReview
+	c.get(fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo, pull.Number), &reviews)
+	for _, r := range reviews {
+		// REQUEST_REVIEW entries are pending requests, not reviews.
+		state, ok := giteaReviewStates[r.State]
+		if !ok || r.User.Login == "" {
+			continue
+		}
+
+		review := pullRequestReview{State: state, SubmittedAt: r.SubmittedAt}
+		review.Author.Login = r.User.Login
+		pr.Reviews.Nodes = append(pr.Reviews.Nodes, review)
+
+		rememberName(r.User.Login, r.User.FullName)
+	}
+
+	for page := 1; ; page++ {
+		var files []giteaFile
+		c.get(fmt.Sprintf("/repos/%s/pulls/%d/files?limit=50&page=%d", repo, pull.Number, page), &files)
+		for _, file := range files {
+			pr.Files.Nodes = append(pr.Files.Nodes, pullRequestFile{Path: file.Filename, Additions: file.Additions, Deletions: file.Deletions})
+		}
+
+		if len(files) < 50 {
+			break
+		}
+	}
+
+	// Older versions do not count the changed files in the pull request.
+	if pr.ChangedFiles == 0 {
+		pr.ChangedFiles = len(pr.Files.Nodes)
+	}
+
+	return pr
+}
+
+func fetchGiteaPRs(initialDate, endDate time.Time) ([]pullRequest, bool) {
+	baseUrl := strings.TrimSuffix(getenv("GITEA_URL"), "/")
+	if baseUrl == "" {
+		return nil, false
+	}
+
+	token := getenv("GITEA_TOKEN")
+	if token == "" {
+		fmt.Fprintln(progress, "GITEA_TOKEN not provided. Skipping Gitea.")
+		return nil, false
+	}
+
+	owner := getenv("GITEA_OWNER")
+
+	var repos []string
+	for _, repo := range strings.Split(getenv("GITEA_REPOS"), ",") {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			if !strings.Contains(repo, "/") {
+				repo = owner + "/" + repo
+			}
+			repos = append(repos, repo)
+		}
+	}
+	if len(repos) == 0 {
+		fmt.Fprintln(progress, "GITEA_REPOS not provided. Skipping Gitea.")
+		return nil, false
+	}
+
+	c := &giteaClient{baseUrl: baseUrl, token: token, client: newHTTPClient("GITEA")}
+
+	var prs []pullRequest
+	for _, repo := range repos {
+		// The most recently updated come first, and a pull request is never
+		// updated before it is created.
+	pages:
+		for page := 1; ; page++ {
+			fmt.Fprintf(progress, "Requesting page %d of %s to Gitea\n", page, repo)
+
+			var pulls []giteaPull
+			c.get(fmt.Sprintf("/repos/%s/pulls?state=all&sort=recentupdate&limit=50&page=%d", repo, page), &pulls)
+
+			for _, pull := range pulls {
+				if !pull.UpdatedAt.After(initialDate) {
+					break pages
+				}
+
+				if !fetchedIn(pull.CreatedAt, pull.UpdatedAt, initialDate, endDate) {
+					continue
+				}
+
+				rememberName(pull.User.Login, pull.User.FullName)
+				pr := c.pullRequest(repo, pull)
+				pr.Source = "gitea"
+				prs = append(prs, pr)
+			}
+
+			if len(pulls) < 50 {
+				break
+			}
+		}
+	}
+
+	return prs, true
+}
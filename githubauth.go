@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const minGithubPageSize = 5
+
+// GitHub does not return more than 100 PRs per page.
+func githubPageSize() int {
+	size := envInt("GITHUB_PAGE_SIZE", 100)
+	if size > 100 {
+		fatalf(exitConfig, "Invalid GITHUB_PAGE_SIZE %d: GitHub returns up to 100 PRs per page", size)
+	}
+
+	return size
+}
+
+// GitHub answers the queries that take too long with a 502 or a generic
+// error, instead of a partial result.
+func isGithubTimeout(err error) bool {
+	message := err.Error()
+	for _, marker := range []string{"502 Bad Gateway", "504 Gateway Timeout", "Timeout", "timeout", "deadline exceeded", "Something went wrong while executing your query"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Set by pull-metrics fetch: the rate limit points left for everybody else.
+var githubRateLimitReserve int
+
+// GitHub Enterprise Server without rate limits answers no reset time. With
+// several tokens, the pool waits instead.
+func waitForGithubBudget(remaining int, resetAt time.Time) {
+	if githubRateLimitReserve == 0 || resetAt.IsZero() || remaining >= githubRateLimitReserve || len(githubTokens()) > 1 {
+		return
+	}
+
+	wait := time.Until(resetAt) + time.Minute
+	fmt.Fprintf(progress, "%d GitHub rate limit points left, waiting %v for the reset\n", remaining, wait.Round(time.Second))
+	time.Sleep(wait)
+}
+
+// The oauth2 client keeps the transport of the GitHub client, but not its
+// timeout.
+func githubOAuthClient(src oauth2.TokenSource) *http.Client {
+	base := newHTTPClient("GITHUB")
+
+	client := oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, base), src)
+	client.Timeout = base.Timeout
+
+	return client
+}
+
+// The web host of github.com or GitHub Enterprise Server, where the OAuth
+// endpoints are.
+func githubWebUrl() string {
+	url := githubApiUrl()
+	switch {
+	case url == "https://api.github.com":
+		return "https://github.com"
+	case strings.HasSuffix(url, "/api/v3"):
+		return strings.TrimSuffix(url, "/api/v3")
+	}
+
+	return url
+}
+
+// pull-metrics login github authorizes the OAuth app of GITHUB_OAUTH_CLIENT_ID
+// with the device flow, so no personal access token is needed, and saves the
+// token as GITHUB_TOKEN.
+func githubDeviceLogin() {
+	clientId := getenv("GITHUB_OAUTH_CLIENT_ID")
+	if clientId == "" {
+		fatalf(exitConfig, "pull-metrics login github needs GITHUB_OAUTH_CLIENT_ID, the client ID of an OAuth app with the device flow enabled")
+	}
+
+	config := &oauth2.Config{
+		ClientID: clientId,
+		Scopes:   []string{"repo", "read:org"},
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: githubWebUrl() + "/login/device/code",
+			TokenURL:      githubWebUrl() + "/login/oauth/access_token",
+			AuthStyle:     oauth2.AuthStyleInParams,
+		},
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, newHTTPClient("GITHUB"))
+
+	device, err := config.DeviceAuth(ctx)
+	if err != nil {
+		fatalf(exitAuth, "Error starting the GitHub login: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Open %s and enter the code %s\n", device.VerificationURI, device.UserCode)
+
+	token, err := config.DeviceAccessToken(ctx, device)
+	if err != nil {
+		fatalf(exitAuth, "Error logging in to GitHub: %v", err)
+	}
+
+	storeCredential("GITHUB_TOKEN", token.AccessToken)
+}
+
+func githubGet(token, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("Accept", "application/vnd.github+json")
+
+	res, err := newHTTPClient("GITHUB").Do(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	return res, nil
+}
+
+func githubGetJSON(url string, out interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Accept", "application/vnd.github+json")
+
+	res, err := githubPoolClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// Checks the token against the REST API, which unlike GraphQL tells which
+// scopes a classic token has and answers 403 or 404 per missing permission.
+func githubTokenProblems(repos []string) []string {
+	token := getenv("GITHUB_TOKEN")
+	owner := getenv("GITHUB_OWNER")
+
+	fineGrained := strings.HasPrefix(token, "github_pat_")
+
+	res, err := githubGet(token, githubApiUrl()+"/user")
+	if err != nil {
+		return []string{fmt.Sprintf("Could not reach the GitHub API: %v", err)}
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return []string{"GITHUB_TOKEN is invalid, expired or revoked. Create a new token"}
+	}
+
+	var problems []string
+
+	// Only classic and OAuth tokens answer with their scopes.
+	if _, classic := res.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]; classic && !fineGrained {
+		scopes := res.Header.Get("X-OAuth-Scopes")
+
+		granted := make(map[string]bool)
+		for _, scope := range strings.Split(scopes, ",") {
+			granted[strings.TrimSpace(scope)] = true
+		}
+
+		if !granted["repo"] {
+			if granted["public_repo"] {
+				problems = append(problems, "The classic token only has the public_repo scope. Add the repo scope to read private repositories")
+			} else {
+				problems = append(problems, fmt.Sprintf("The classic token has the scopes [%s] but needs repo (or public_repo for public repositories)", scopes))
+			}
+		}
+
+		if !granted["read:org"] && !granted["admin:org"] && len(repos) == 1 && repos[0] == "*" {
+			problems = append(problems, "Listing every repository of an organization needs the read:org scope")
+		}
+	}
+
+	for _, repo := range repos {
+		if repo == "*" {
+			continue
+		}
+
+		repoUrl := githubApiUrl() + "/repos/" + owner + "/" + repo
+
+		res, err := githubGet(token, repoUrl)
+		if err != nil {
+			continue
+		}
+
+		if sso := res.Header.Get("X-GitHub-SSO"); sso != "" {
+			problems = append(problems, fmt.Sprintf("The token is not authorized for the SAML SSO of %s. Authorize it: %s", owner, strings.TrimPrefix(sso, "required; url=")))
+			continue
+		}
+
+		if res.StatusCode == http.StatusNotFound {
+			if fineGrained {
+				problems = append(problems, fmt.Sprintf("The fine-grained token cannot see %s/%s. Pick %s as resource owner and add the repository to its Repository access", owner, repo, owner))
+			} else {
+				problems = append(problems, fmt.Sprintf("%s/%s does not exist or the token owner has no access to it", owner, repo))
+			}
+			continue
+		}
+
+		res, err = githubGet(token, repoUrl+"/pulls?state=all&per_page=1")
+		if err != nil {
+			continue
+		}
+
+		if res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusNotFound {
+			problems = append(problems, fmt.Sprintf("The token cannot read the pull requests of %s/%s. Fine-grained tokens need the Pull requests: Read-only permission", owner, repo))
+		}
+	}
+
+	return problems
+}
+
+func printTokenProblems(problems []string) {
+	for _, problem := range problems {
+		log.Printf("  - %s", problem)
+	}
+}
+
+// Fails with the reason the token could not read the repositories, when the
+// REST API can tell it.
+func failGithubQuery(err error, repos ...string) {
+	message := err.Error()
+	if !isAuthError(err) && !strings.Contains(message, "Could not resolve to a Repository") && !strings.Contains(message, "Could not resolve to a RepositoryOwner") {
+		fatalf(exitError, "Error in GraphQL query: %v", err)
+	}
+
+	log.Printf("GitHub rejected the request: %v", err)
+
+	if problems := githubTokenProblems(repos); len(problems) > 0 {
+		printTokenProblems(problems)
+	}
+
+	fatalf(exitAuth, "Fix the GITHUB_TOKEN permissions and run again")
+}
+
+// No PRs at all is more often a token that cannot see the repositories than a
+// quiet period, so the permissions are checked before going on.
+func warnEmptyGithubResults(repos []string) {
+	problems := githubTokenProblems(repos)
+	if len(problems) == 0 {
+		return
+	}
+
+	log.Print("No PRs were found, and GITHUB_TOKEN may be missing permissions:")
+	printTokenProblems(problems)
+}
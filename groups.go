@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Topics of every fetched repository, by name with owner.
+var repoTopics = make(map[string][]string)
+
+// Lists the repositories of the owner with pushes since the start of the
+// report, for GITHUB_REPO="*".
+func fetchOwnerRepos(owner string, initialDate time.Time) []string {
+	var query struct {
+		RepositoryOwner struct {
+			Repositories struct {
+				Nodes []struct {
+					Name       string
+					IsArchived bool
+					PushedAt   time.Time
+				}
+
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				}
+			} `graphql:"repositories(first: 100, orderBy: {direction: DESC, field: PUSHED_AT}, after: $repoCursor)"`
+		} `graphql:"repositoryOwner(login: $owner)"`
+	}
+
+	variables := map[string]interface{}{
+		"owner":      owner,
+		"repoCursor": (*string)(nil),
+	}
+
+	fmt.Fprintf(progress, "Listing the repositories of %s\n", owner)
+
+	var repos []string
+out:
+	for {
+		if err := client.Query(context.Background(), &query, variables); err != nil {
+			failGithubQuery(err, "*")
+		}
+
+		for _, repo := range query.RepositoryOwner.Repositories.Nodes {
+			if repo.PushedAt.Before(initialDate) {
+				break out
+			}
+
+			if !repo.IsArchived {
+				repos = append(repos, repo.Name)
+			}
+		}
+
+		if !query.RepositoryOwner.Repositories.PageInfo.HasNextPage {
+			break
+		}
+
+		variables["repoCursor"] = &query.RepositoryOwner.Repositories.PageInfo.EndCursor
+	}
+
+	return repos
+}
+
+type repoGroups struct {
+	mapping     map[string]string
+	topicPrefix string
+}
+
+// Repositories are grouped by REPO_GROUPS_FILE, with one line per repository
+// (repo,group), or else by their topics starting with REPO_GROUP_TOPIC_PREFIX.
+func loadRepoGroups() *repoGroups {
+	groups := &repoGroups{
+		mapping:     make(map[string]string),
+		topicPrefix: getenv("REPO_GROUP_TOPIC_PREFIX"),
+	}
+
+	path := getenv("REPO_GROUPS_FILE")
+	if path == "" && groups.topicPrefix == "" {
+		return nil
+	}
+
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			fatalf(exitConfig, "Error opening REPO_GROUPS_FILE: %v", err)
+		}
+		defer f.Close()
+
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			fatalf(exitConfig, "Error reading REPO_GROUPS_FILE: %v", err)
+		}
+
+		for i, record := range records {
+			if len(record) != 2 {
+				fatalf(exitConfig, "REPO_GROUPS_FILE line %d: expected repo,group", i+1)
+			}
+
+			groups.mapping[strings.ToLower(strings.TrimSpace(record[0]))] = strings.TrimSpace(record[1])
+		}
+	}
+
+	return groups
+}
+
+// The repository matches the mapping either by name or by name with owner.
+func (groups *repoGroups) group(nameWithOwner string) string {
+	_, name, _ := strings.Cut(nameWithOwner, "/")
+
+	for _, key := range []string{nameWithOwner, name} {
+		if group, ok := groups.mapping[strings.ToLower(key)]; ok {
+			return group
+		}
+	}
+
+	if groups.topicPrefix != "" {
+		for _, topic := range repoTopics[nameWithOwner] {
+			if strings.HasPrefix(topic, groups.topicPrefix) {
+				return strings.TrimPrefix(topic, groups.topicPrefix)
+			}
+		}
+	}
+
+	return "Ungrouped"
+}
+
+func repoGroupsSection(prs []pullRequest, endDate time.Time, groups *repoGroups) *reportSection {
+	type groupStats struct {
+		repos        map[string]bool
+		authors      map[string]bool
+		prs          int
+		mergedPRs    int
+		openPRs      int
+		addedLines   int
+		removedLines int
+	}
+
+	byGroup := make(map[string]*groupStats)
+	for _, pr := range prs {
+		name := groups.group(pr.Repository.NameWithOwner)
+		if byGroup[name] == nil {
+			byGroup[name] = &groupStats{repos: make(map[string]bool), authors: make(map[string]bool)}
+		}
+
+		stats := byGroup[name]
+		stats.repos[pr.Repository.NameWithOwner] = true
+		stats.authors[pr.Author.Login] = true
+		stats.prs++
+		stats.addedLines += pr.Additions
+		stats.removedLines += pr.Deletions
+
+		switch pr.stateAt(endDate) {
+		case prMerged:
+			stats.mergedPRs++
+		case prOpen:
+			stats.openPRs++
+		}
+	}
+
+	var groupNames []string
+	for name := range byGroup {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	section := &reportSection{
+		Name:     "Repository groups",
+		Title:    "Repository groups",
+		Header:   table.Row{"Group", "Repositories", "Authors", "Total PRs", "Merged PRs", "Merged PRs (%)", "Open PRs", "Added lines", "Removed lines"},
+		Centered: []int{2, 3, 4, 5, 6, 7, 8, 9},
+	}
+
+	for _, name := range groupNames {
+		stats := byGroup[name]
+		section.Rows = append(section.Rows, table.Row{
+			name,
+			len(stats.repos),
+			len(stats.authors),
+			stats.prs,
+			stats.mergedPRs,
+			prCounts{prs: stats.prs, merged: stats.mergedPRs}.mergeRateCell(),
+			stats.openPRs,
+			thresholds.prSize(stats.addedLines, stats.addedLines+stats.removedLines, stats.prs),
+			thresholds.prSize(stats.removedLines, stats.addedLines+stats.removedLines, stats.prs),
+		})
+	}
+
+	return section
+}
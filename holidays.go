@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// A public holiday of the bundled dataset: a fixed date, an offset from Easter
+// Sunday when month is 0, or the nth weekday of the month, the last one with
+// nth -1.
+type holidayRule struct {
+	month   time.Month
+	day     int
+	easter  int
+	weekday time.Weekday
+	nth     int
+}
+
+func fixedHoliday(month time.Month, day int) holidayRule {
+	return holidayRule{month: month, day: day}
+}
+
+func easterHoliday(offset int) holidayRule {
+	return holidayRule{easter: offset}
+}
+
+func weekdayHoliday(month time.Month, weekday time.Weekday, nth int) holidayRule {
+	return holidayRule{month: month, weekday: weekday, nth: nth}
+}
+
+// How the holidays falling on a weekend are made up for.
+type holidayObservance int
+
+const (
+	notObserved holidayObservance = iota
+	// Saturday on the Friday before, Sunday on the Monday after, like the US
+	// federal holidays.
+	nearestWeekday
+	// On the next working day that is not a holiday already, like the UK bank
+	// holidays.
+	substituteDay
+)
+
+type countryHolidays struct {
+	rules      []holidayRule
+	observance holidayObservance
+}
+
+// The national public holidays of the countries of the bundled dataset. The
+// regional ones, and the ones decided year by year, come from ICS calendars.
+var bundledHolidays = map[string]countryHolidays{
+	"US": {[]holidayRule{
+		fixedHoliday(time.January, 1), weekdayHoliday(time.January, time.Monday, 3), weekdayHoliday(time.February, time.Monday, 3),
+		weekdayHoliday(time.May, time.Monday, -1), fixedHoliday(time.June, 19), fixedHoliday(time.July, 4),
+		weekdayHoliday(time.September, time.Monday, 1), weekdayHoliday(time.October, time.Monday, 2), fixedHoliday(time.November, 11),
+		weekdayHoliday(time.November, time.Thursday, 4), fixedHoliday(time.December, 25),
+	}, nearestWeekday},
+	"GB": {[]holidayRule{
+		fixedHoliday(time.January, 1), easterHoliday(-2), easterHoliday(1), weekdayHoliday(time.May, time.Monday, 1),
+		weekdayHoliday(time.May, time.Monday, -1), weekdayHoliday(time.August, time.Monday, -1),
+		fixedHoliday(time.December, 25), fixedHoliday(time.December, 26),
+	}, substituteDay},
+	"IE": {[]holidayRule{
+		fixedHoliday(time.January, 1), weekdayHoliday(time.February, time.Monday, 1), fixedHoliday(time.March, 17), easterHoliday(1),
+		weekdayHoliday(time.May, time.Monday, 1), weekdayHoliday(time.June, time.Monday, 1), weekdayHoliday(time.August, time.Monday, 1),
+		weekdayHoliday(time.October, time.Monday, -1), fixedHoliday(time.December, 25), fixedHoliday(time.December, 26),
+	}, substituteDay},
+	"DE": {[]holidayRule{
+		fixedHoliday(time.January, 1), easterHoliday(-2), easterHoliday(1), fixedHoliday(time.May, 1), easterHoliday(39),
+		easterHoliday(50), fixedHoliday(time.October, 3), fixedHoliday(time.December, 25), fixedHoliday(time.December, 26),
+	}, notObserved},
+	"FR": {[]holidayRule{
+		fixedHoliday(time.January, 1), easterHoliday(1), fixedHoliday(time.May, 1), fixedHoliday(time.May, 8), easterHoliday(39),
+		easterHoliday(50), fixedHoliday(time.July, 14), fixedHoliday(time.August, 15), fixedHoliday(time.November, 1),
+		fixedHoliday(time.November, 11), fixedHoliday(time.December, 25),
+	}, notObserved},
+	"NL": {[]holidayRule{
+		fixedHoliday(time.January, 1), easterHoliday(1), fixedHoliday(time.April, 27), easterHoliday(39), easterHoliday(50),
+		fixedHoliday(time.December, 25), fixedHoliday(time.December, 26),
+	}, notObserved},
+	"ES": {[]holidayRule{
+		fixedHoliday(time.January, 1), fixedHoliday(time.January, 6), easterHoliday(-2), fixedHoliday(time.May, 1),
+		fixedHoliday(time.August, 15), fixedHoliday(time.October, 12), fixedHoliday(time.November, 1), fixedHoliday(time.December, 6),
+		fixedHoliday(time.December, 8), fixedHoliday(time.December, 25),
+	}, notObserved},
+	"IT": {[]holidayRule{
+		fixedHoliday(time.January, 1), fixedHoliday(time.January, 6), easterHoliday(1), fixedHoliday(time.April, 25),
+		fixedHoliday(time.May, 1), fixedHoliday(time.June, 2), fixedHoliday(time.August, 15), fixedHoliday(time.November, 1),
+		fixedHoliday(time.December, 8), fixedHoliday(time.December, 25), fixedHoliday(time.December, 26),
+	}, notObserved},
+	"PT": {[]holidayRule{
+		fixedHoliday(time.January, 1), easterHoliday(-2), easterHoliday(0), fixedHoliday(time.April, 25), fixedHoliday(time.May, 1),
+		easterHoliday(60), fixedHoliday(time.June, 10), fixedHoliday(time.August, 15), fixedHoliday(time.October, 5),
+		fixedHoliday(time.November, 1), fixedHoliday(time.December, 1), fixedHoliday(time.December, 8), fixedHoliday(time.December, 25),
+	}, notObserved},
+	"BR": {[]holidayRule{
+		fixedHoliday(time.January, 1), easterHoliday(-48), easterHoliday(-47), easterHoliday(-2), fixedHoliday(time.April, 21),
+		fixedHoliday(time.May, 1), easterHoliday(60), fixedHoliday(time.September, 7), fixedHoliday(time.October, 12),
+		fixedHoliday(time.November, 2), fixedHoliday(time.November, 15), fixedHoliday(time.November, 20), fixedHoliday(time.December, 25),
+	}, notObserved},
+}
+
+// Easter Sunday of the Gregorian calendar.
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func (rule holidayRule) date(year int) time.Time {
+	switch {
+	case rule.month == 0:
+		return easterSunday(year).AddDate(0, 0, rule.easter)
+	case rule.nth > 0:
+		first := time.Date(year, rule.month, 1, 0, 0, 0, 0, time.UTC)
+		offset := (int(rule.weekday) - int(first.Weekday()) + 7) % 7
+		return first.AddDate(0, 0, offset+7*(rule.nth-1))
+	case rule.nth < 0:
+		last := time.Date(year, rule.month+1, 0, 0, 0, 0, 0, time.UTC)
+		offset := (int(last.Weekday()) - int(rule.weekday) + 7) % 7
+		return last.AddDate(0, 0, -offset)
+	}
+
+	return time.Date(year, rule.month, rule.day, 0, 0, 0, 0, time.UTC)
+}
+
+// The days off of the country in the year, the observed ones included.
+func (country countryHolidays) days(year int) []time.Time {
+	var days []time.Time
+	taken := make(map[time.Time]bool)
+	for _, rule := range country.rules {
+		taken[rule.date(year)] = true
+	}
+
+	for _, rule := range country.rules {
+		day := rule.date(year)
+		days = append(days, day)
+
+		switch {
+		case country.observance == nearestWeekday && day.Weekday() == time.Saturday:
+			days = append(days, day.AddDate(0, 0, -1))
+		case country.observance == nearestWeekday && day.Weekday() == time.Sunday:
+			days = append(days, day.AddDate(0, 0, 1))
+		case country.observance == substituteDay && !isWorkingDay(day):
+			substitute := day.AddDate(0, 0, 1)
+			for !isWorkingDay(substitute) || taken[substitute] {
+				substitute = substitute.AddDate(0, 0, 1)
+			}
+			taken[substitute] = true
+			days = append(days, substitute)
+		}
+	}
+
+	return days
+}
+
+// The public holidays per country, and the country of every person: the one
+// of their roster line, or HOLIDAY_COUNTRY.
+type holidayCalendar struct {
+	days           map[string]map[string]bool
+	roster         *roster
+	defaultCountry string
+}
+
+// HOLIDAYS is a comma-separated list of countries, each taking the bundled
+// holidays of its ISO code, e.g. DE, or an ICS calendar, e.g.
+// BR=https://example.com/br.ics or BR=holidays/br.ics. Several calendars of a
+// country add up.
+func loadHolidays(initialDate, endDate time.Time, r *roster) *holidayCalendar {
+	entries := statusList(getenv("HOLIDAYS"))
+	if len(entries) == 0 {
+		return nil
+	}
+
+	calendar := &holidayCalendar{
+		days:           make(map[string]map[string]bool),
+		roster:         r,
+		defaultCountry: strings.ToUpper(getenv("HOLIDAY_COUNTRY")),
+	}
+
+	for _, entry := range entries {
+		country, source, fromICS := strings.Cut(entry, "=")
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if calendar.defaultCountry == "" {
+			calendar.defaultCountry = country
+		}
+		if calendar.days[country] == nil {
+			calendar.days[country] = make(map[string]bool)
+		}
+
+		if fromICS {
+			days, err := readHolidayCalendar(strings.TrimSpace(source), initialDate.Year(), endDate.Year())
+			if err != nil {
+				fatalf(exitConfig, "Error reading the HOLIDAYS calendar of %s: %v", country, err)
+			}
+			for _, day := range days {
+				calendar.days[country][day.Format("2006-01-02")] = true
+			}
+			continue
+		}
+
+		bundled, ok := bundledHolidays[country]
+		if !ok {
+			fatalf(exitConfig, "No bundled holidays for %q in HOLIDAYS. Known: %s. Use %s=<ICS URL or file> for the others", country, strings.Join(sortedKeys(bundledHolidays), ", "), country)
+		}
+		for year := initialDate.Year(); year <= endDate.Year(); year++ {
+			for _, day := range bundled.days(year) {
+				calendar.days[country][day.Format("2006-01-02")] = true
+			}
+		}
+	}
+
+	return calendar
+}
+
+func (calendar *holidayCalendar) isHoliday(day time.Time, identities ...string) bool {
+	country := calendar.defaultCountry
+	if calendar.roster != nil {
+		if entry, ok := calendar.roster.lookup(identities...); ok && entry.country != "" {
+			country = strings.ToUpper(entry.country)
+		}
+	}
+
+	return calendar.days[country][day.Format("2006-01-02")]
+}
+
+func readHolidayCalendar(source string, firstYear, lastYear int) ([]time.Time, error) {
+	var reader io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		res, err := newHTTPClient("HOLIDAYS").Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != 200 {
+			return nil, fmt.Errorf("%s answered %s", source, res.Status)
+		}
+		reader = res.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	return parseHolidayCalendar(reader, firstYear, lastYear)
+}
+
+// The days of the VEVENTs of the calendar. DTEND is exclusive, like in the
+// all day events of the holiday feeds, and the yearly events repeat over the
+// years of the window.
+func parseHolidayCalendar(reader io.Reader, firstYear, lastYear int) ([]time.Time, error) {
+	// Long lines are folded on the next ones, which start with a space.
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var days []time.Time
+	var start, end time.Time
+	yearly := false
+	for _, line := range lines {
+		property, value, _ := strings.Cut(line, ":")
+		name, _, _ := strings.Cut(property, ";")
+
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if value == "VEVENT" {
+				start, end, yearly = time.Time{}, time.Time{}, false
+			}
+		case "DTSTART":
+			start, _ = parseICSDate(value)
+		case "DTEND":
+			end, _ = parseICSDate(value)
+		case "RRULE":
+			yearly = strings.Contains(strings.ToUpper(value), "FREQ=YEARLY")
+		case "END":
+			if value != "VEVENT" || start.IsZero() {
+				continue
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+
+			years := []int{start.Year()}
+			if yearly {
+				years = nil
+				for year := max(firstYear, start.Year()); year <= lastYear; year++ {
+					years = append(years, year)
+				}
+			}
+			for _, year := range years {
+				shift := year - start.Year()
+				for day := start.AddDate(shift, 0, 0); day.Before(end.AddDate(shift, 0, 0)); day = day.AddDate(0, 0, 1) {
+					days = append(days, day)
+				}
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) >= 8 {
+		value = value[:8]
+	}
+
+	return time.Parse("20060102", value)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Chains downstream automation after the report: HOOK_COMMAND runs through the
+// shell with the JSON report on stdin, and HOOK_URL receives it in a POST.
+// Profiles can set them like any other variable.
+func runHooks(rep *report) {
+	command := getenv("HOOK_COMMAND")
+	url := getenv("HOOK_URL")
+	if command == "" && url == "" {
+		return
+	}
+
+	content, err := rep.json()
+	if err != nil {
+		fatalf(exitError, "Error encoding the report for the hooks: %v", err)
+	}
+
+	if command != "" {
+		runHookCommand(rep, command, content)
+	}
+
+	if url != "" {
+		postHook(url, content)
+	}
+}
+
+func runHookCommand(rep *report, command string, content []byte) {
+	shell, option := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, option = "cmd", "/C"
+	}
+
+	cmd := exec.Command(shell, option, command)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = progress
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"PULL_METRICS_START="+rep.InitialDate.Format("2006-01-02"),
+		"PULL_METRICS_END="+rep.EndDate.Format("2006-01-02"),
+	)
+
+	if err := cmd.Run(); err != nil {
+		fatalf(exitError, "Error running HOOK_COMMAND: %v", err)
+	}
+}
+
+func postHook(url string, content []byte) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(content))
+	if err != nil {
+		fatalf(exitConfig, "Error parsing HOOK_URL: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token := getenv("HOOK_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := newHTTPClient("HOOK").Do(req)
+	if err != nil {
+		fatalf(exitError, "Error posting the report to HOOK_URL: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		fatalf(exitError, "Error posting the report to HOOK_URL: %s", res.Status)
+	}
+
+	fmt.Fprintln(progress, "Report posted to HOOK_URL")
+}
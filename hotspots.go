@@ -0,0 +1,124 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+var fixTitle = regexp.MustCompile(`(?i)^(fix|hotfix|bugfix)\b`)
+
+// Whether the PR fixes a defect: it has one of DEFECT_LABELS, or its title
+// starts with fix, hotfix or bugfix.
+func (pr pullRequest) fixesDefect() bool {
+	labels := getenv("DEFECT_LABELS")
+	if labels == "" {
+		labels = "bug,defect"
+	}
+
+	for _, label := range pr.Labels.Nodes {
+		for _, defect := range strings.Split(labels, ",") {
+			if strings.EqualFold(label.Name, strings.TrimSpace(defect)) {
+				return true
+			}
+		}
+	}
+
+	return fixTitle.MatchString(pr.Title)
+}
+
+// The files and directories changed by the most PRs merged by the end date,
+// ten of each. The fix PRs are flagged when they are at least half of the
+// changes, as the hotspots where the defects land.
+func hotspotsSections(prs []pullRequest, endDate time.Time) []*reportSection {
+	type hotspot struct {
+		prs     int
+		fixes   int
+		lines   int
+		authors map[string]bool
+	}
+
+	files := make(map[string]*hotspot)
+	dirs := make(map[string]*hotspot)
+	touch := func(m map[string]*hotspot, key string, pr pullRequest, lines int) {
+		if m[key] == nil {
+			m[key] = &hotspot{authors: make(map[string]bool)}
+		}
+		m[key].prs++
+		m[key].lines += lines
+		m[key].authors[pr.Author.Login] = true
+		if pr.fixesDefect() {
+			m[key].fixes++
+		}
+	}
+
+	for _, pr := range prs {
+		if pr.stateAt(endDate) != prMerged {
+			continue
+		}
+
+		dirLines := make(map[string]int)
+		for _, file := range pr.Files.Nodes {
+			repo := pr.Repository.NameWithOwner
+			touch(files, repo+":"+file.Path, pr, file.Additions+file.Deletions)
+
+			dir := repo
+			if d := path.Dir(file.Path); d != "." {
+				dir += ":" + d
+			}
+			dirLines[dir] += file.Additions + file.Deletions
+		}
+		for dir, lines := range dirLines {
+			touch(dirs, dir, pr, lines)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	section := func(name, key string, hotspots map[string]*hotspot) *reportSection {
+		var keys []string
+		for k := range hotspots {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := hotspots[keys[i]], hotspots[keys[j]]
+			if a.prs != b.prs {
+				return a.prs > b.prs
+			}
+			return keys[i] < keys[j]
+		})
+		if len(keys) > 10 {
+			keys = keys[:10]
+		}
+
+		s := &reportSection{
+			Name:     name,
+			Title:    name,
+			Header:   table.Row{key, "Merged PRs", "Authors", "Changed lines", "Fix PRs"},
+			Centered: []int{2, 3, 4, 5},
+		}
+		for _, k := range keys {
+			h := hotspots[k]
+
+			var fixes interface{} = h.fixes
+			if h.fixes > 1 && h.fixes*2 >= h.prs {
+				fixes = flagged{h.fixes, warning}
+			}
+
+			s.Rows = append(s.Rows, table.Row{k, h.prs, len(h.authors), h.lines, fixes})
+		}
+
+		return s
+	}
+
+	return []*reportSection{
+		section("File hotspots", "File", files),
+		section("Directory hotspots", "Directory", dirs),
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// A GET response kept in HTTP_CACHE_DIR with its validators.
+type cachedResponse struct {
+	Url    string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Sends the ETag and Last-Modified of the previous run with every GET, and
+// answers from the cache when the server replies that nothing changed.
+type cachingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func withCache(next http.RoundTripper) http.RoundTripper {
+	dir := getenv("HTTP_CACHE_DIR")
+	if dir == "" {
+		return next
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		fatalf(exitConfig, "Error creating HTTP_CACHE_DIR: %v", err)
+	}
+
+	return &cachingTransport{dir: dir, next: next}
+}
+
+// The credentials are part of the key, so a token never gets the answers
+// cached for another one.
+func (t *cachingTransport) path(req *http.Request) string {
+	return filepath.Join(t.dir, sha256Hex([]byte(req.URL.String()+"\n"+req.Header.Get("Authorization")))+".json")
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	path := t.path(req)
+
+	var cached *cachedResponse
+	if content, err := os.ReadFile(path); err == nil {
+		cached = &cachedResponse{}
+		if json.Unmarshal(content, cached) != nil {
+			cached = nil
+		}
+	}
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", cached.Status, http.StatusText(cached.Status)),
+			StatusCode:    cached.Status,
+			Proto:         res.Proto,
+			ProtoMajor:    res.ProtoMajor,
+			ProtoMinor:    res.ProtoMinor,
+			Header:        cached.Header,
+			Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	if res.StatusCode != http.StatusOK || (res.Header.Get("ETag") == "" && res.Header.Get("Last-Modified") == "") {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	content, err := json.Marshal(cachedResponse{Url: req.URL.String(), Status: res.StatusCode, Header: res.Header, Body: body})
+	if err == nil {
+		os.WriteFile(path, content, 0o600)
+	}
+
+	return res, nil
+}
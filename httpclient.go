@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The standard transport, before configureHTTP replaces it.
+var baseTransport = http.DefaultTransport.(*http.Transport)
+
+// Settings of a service, like GITHUB_CA_BUNDLE, fall back to the TLS_ ones
+// shared by every service.
+func tlsSetting(service, name string) string {
+	if service != "" {
+		if value := getenv(service + "_" + name); value != "" {
+			return value
+		}
+	}
+
+	return getenv("TLS_" + name)
+}
+
+// Proxies come from HTTP_PROXY, HTTPS_PROXY and NO_PROXY. CA_BUNDLE adds
+// certificates to the system ones, for corporate proxies and internal CAs,
+// and CLIENT_CERT with CLIENT_KEY enable mutual TLS.
+func newTransport(service string) *http.Transport {
+	transport := baseTransport.Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.MaxIdleConnsPerHost = 16
+
+	config := &tls.Config{}
+
+	if bundle := tlsSetting(service, "CA_BUNDLE"); bundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(bundle)
+		if err != nil {
+			fatalf(exitConfig, "Error reading the CA bundle %s: %v", bundle, err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			fatalf(exitConfig, "No certificates found in the CA bundle %s", bundle)
+		}
+
+		config.RootCAs = pool
+	}
+
+	cert, key := tlsSetting(service, "CLIENT_CERT"), tlsSetting(service, "CLIENT_KEY")
+	if cert != "" || key != "" {
+		certificate, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			fatalf(exitConfig, "Error loading the client certificate: %v", err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = config
+
+	return transport
+}
+
+// Limits the requests in flight to a service.
+type limitTransport struct {
+	slots chan struct{}
+	next  http.RoundTripper
+}
+
+func withLimit(limit int, next http.RoundTripper) http.RoundTripper {
+	return &limitTransport{slots: make(chan struct{}, limit), next: next}
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-t.slots }()
+
+	return t.next.RoundTrip(req)
+}
+
+var (
+	clients      = make(map[string]*http.Client)
+	clientsMutex sync.Mutex
+)
+
+// One client per service, so its connections are kept alive and reused. The
+// timeout and the concurrency come from <SERVICE>_TIMEOUT and
+// <SERVICE>_MAX_CONCURRENCY, falling back to HTTP_TIMEOUT and
+// HTTP_MAX_CONCURRENCY. Responses are gzipped when the server supports it.
+func newHTTPClient(service string) *http.Client {
+	clientsMutex.Lock()
+	client, ok := clients[service]
+	clientsMutex.Unlock()
+	if ok {
+		return client
+	}
+
+	timeout := envDuration("HTTP_TIMEOUT", time.Minute)
+	limit := envInt("HTTP_MAX_CONCURRENCY", 8)
+	if service != "" {
+		timeout = envDuration(service+"_TIMEOUT", timeout)
+		limit = envInt(service+"_MAX_CONCURRENCY", limit)
+	}
+
+	client = &http.Client{
+		Transport: withRecording(withCache(withLimit(limit, withDebug(newTransport(service))))),
+		Timeout:   timeout,
+	}
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	if existing, ok := clients[service]; ok {
+		return existing
+	}
+	clients[service] = client
+
+	return client
+}
+
+// Applies the shared TLS_ settings, the debug log and the recording to every
+// client that does not set its own.
+func configureHTTP() {
+	http.DefaultTransport = withRecording(withDebug(newTransport("")))
+}
+
+// GitHub Enterprise Server serves the REST API under /api/v3 and GraphQL under
+// /api/graphql.
+func githubApiUrl() string {
+	if url := getenv("GITHUB_API_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+
+	return "https://api.github.com"
+}
+
+func githubGraphqlUrl() string {
+	url := githubApiUrl()
+	if strings.HasSuffix(url, "/api/v3") {
+		return strings.TrimSuffix(url, "/v3") + "/graphql"
+	}
+
+	return url + "/graphql"
+}
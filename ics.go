@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type calendarEvent struct {
+	uid         string
+	day         time.Time
+	summary     string
+	description string
+	url         string
+}
+
+// Escapes the text values and folds the lines at 75 octets, as RFC 5545 asks.
+func icsText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(value)
+}
+
+func icsLine(b *strings.Builder, line string) {
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && (line[cut]&0xC0) == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// All-day events, with an alarm at 9:00.
+func icsCalendar(events []calendarEvent) string {
+	var b strings.Builder
+	icsLine(&b, "BEGIN:VCALENDAR")
+	icsLine(&b, "VERSION:2.0")
+	icsLine(&b, "PRODID:-//pull-metrics//reminders//EN")
+	icsLine(&b, "CALSCALE:GREGORIAN")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, event := range events {
+		icsLine(&b, "BEGIN:VEVENT")
+		icsLine(&b, "UID:"+event.uid+"@pull-metrics")
+		icsLine(&b, "DTSTAMP:"+stamp)
+		icsLine(&b, "DTSTART;VALUE=DATE:"+event.day.Format("20060102"))
+		icsLine(&b, "DTEND;VALUE=DATE:"+event.day.AddDate(0, 0, 1).Format("20060102"))
+		icsLine(&b, "SUMMARY:"+icsText(event.summary))
+		if event.description != "" {
+			icsLine(&b, "DESCRIPTION:"+icsText(event.description))
+		}
+		if event.url != "" {
+			icsLine(&b, "URL:"+event.url)
+		}
+		icsLine(&b, "BEGIN:VALARM")
+		icsLine(&b, "ACTION:DISPLAY")
+		icsLine(&b, "DESCRIPTION:"+icsText(event.summary))
+		icsLine(&b, "TRIGGER:PT9H")
+		icsLine(&b, "END:VALARM")
+		icsLine(&b, "END:VEVENT")
+	}
+
+	icsLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// The review requests of the open PRs still unanswered past REVIEW_SLA at the
+// end date, per reviewer, as reminders for the next day.
+func staleReviewEvents(prs []pullRequest, endDate time.Time) map[string][]calendarEvent {
+	sla := reviewSLA()
+	day := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	byReviewer := make(map[string][]calendarEvent)
+	for _, pr := range prs {
+		if pr.IsDraft || pr.stateAt(endDate) != prOpen {
+			continue
+		}
+
+		for _, request := range pr.reviewRequests() {
+			if request.requestedAt.After(endDate) || endDate.Sub(request.requestedAt) <= sla {
+				continue
+			}
+			if reviewedAt, ok := pr.firstReviewBy(request.reviewer, request.requestedAt); ok && !reviewedAt.After(endDate) {
+				continue
+			}
+
+			byReviewer[request.reviewer] = append(byReviewer[request.reviewer], calendarEvent{
+				uid:     sha256Hex([]byte(pr.Url + " " + request.reviewer + " " + day.Format("2006-01-02"))),
+				day:     day,
+				summary: fmt.Sprintf("Review %s#%d: %s", pr.Repository.NameWithOwner, pr.Number, pr.Title),
+				description: fmt.Sprintf("%s asked %s for a review on %s, %v ago, past the %v REVIEW_SLA.",
+					pr.Author.Login, request.reviewer, formatDate(request.requestedAt), duration(endDate.Sub(request.requestedAt)), duration(sla)),
+				url: pr.Url,
+			})
+		}
+	}
+
+	return byReviewer
+}
+
+// The first days of the next ICS_PERIODS periods of REPORTING_CALENDAR, when
+// the previous period is reported.
+func reportPeriodEvents(endDate time.Time) []calendarEvent {
+	calendar := loadCalendar()
+
+	var events []calendarEvent
+	_, next := calendar.period(endDate)
+	for i := 0; i < envInt("ICS_PERIODS", 3); i++ {
+		start, _ := calendar.period(next.AddDate(0, 0, -1))
+		events = append(events, calendarEvent{
+			uid:         sha256Hex([]byte("period " + next.Format("2006-01-02"))),
+			day:         next,
+			summary:     fmt.Sprintf("Pull metrics report of %s - %s", formatDate(start), formatDate(next.AddDate(0, 0, -1))),
+			description: "pull-metrics --range last-period",
+		})
+		_, next = calendar.period(next)
+	}
+
+	return events
+}
+
+// Writes reminders.ics with every reminder and a file per reviewer with
+// theirs, both with the next report periods.
+func (data *fetchedData) writeCalendars(dir string, endDate time.Time) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	periods := reportPeriodEvents(endDate)
+	stale := staleReviewEvents(data.prs, endDate)
+
+	// The people who opted out get no calendar, and are named in no reminder.
+	optedOut := optedOutPeople()
+	var reviewers []string
+	for reviewer, events := range stale {
+		if optedOut[strings.ToLower(reviewer)] || optedOut[strings.ToLower(names[reviewer])] {
+			continue
+		}
+		for i := range events {
+			events[i].description = redactOptedOut(events[i].description)
+		}
+		reviewers = append(reviewers, reviewer)
+	}
+	sort.Strings(reviewers)
+
+	all := append([]calendarEvent(nil), periods...)
+	for _, reviewer := range reviewers {
+		all = append(all, stale[reviewer]...)
+
+		content := icsCalendar(append(append([]calendarEvent(nil), periods...), stale[reviewer]...))
+		if err := os.WriteFile(filepath.Join(dir, reviewer+".ics"), []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "reminders.ics"), []byte(icsCalendar(all)), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(progress, "Calendar reminders of %d reviewers written to %s\n", len(reviewers), dir)
+	return nil
+}
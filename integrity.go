@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// The hash of a report, and its minisign signature when REPORT_SIGNING_KEY
+// is set. age keys only encrypt, so they cannot sign.
+type reportIntegrity struct {
+	Sha256    string `json:"sha256"`
+	Signature string `json:"signature,omitempty"`
+}
+
+const integrityMarker = "pull-metrics-integrity"
+
+// REPORT_INTEGRITY embeds the hash in the written reports, and a signing key
+// embeds the signature too.
+func integrityEnabled() bool {
+	return getenv("REPORT_SIGNING_KEY") != "" || getenv("REPORT_INTEGRITY") == "true"
+}
+
+// The same content is signed once, however many outputs write it.
+func (r *report) sign(content []byte) reportIntegrity {
+	sum := sha256.Sum256(content)
+	if integrity, ok := r.signatures[sum]; ok {
+		return integrity
+	}
+
+	integrity := signContent(content)
+	if r.signatures == nil {
+		r.signatures = make(map[[sha256.Size]byte]reportIntegrity)
+	}
+	r.signatures[sum] = integrity
+
+	return integrity
+}
+
+func signContent(content []byte) reportIntegrity {
+	sum := sha256.Sum256(content)
+	integrity := reportIntegrity{Sha256: hex.EncodeToString(sum[:])}
+
+	key := getenv("REPORT_SIGNING_KEY")
+	if key == "" {
+		return integrity
+	}
+
+	dir, err := os.MkdirTemp("", "pull-metrics-sign")
+	if err != nil {
+		fatalf(exitConfig, "Error signing the report: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	message, signature := filepath.Join(dir, "report"), filepath.Join(dir, "report.minisig")
+	if err := os.WriteFile(message, content, 0o600); err != nil {
+		fatalf(exitConfig, "Error signing the report: %v", err)
+	}
+
+	cmd := exec.Command("minisign", "-S", "-s", key, "-m", message, "-x", signature)
+	if password, ok := signingPassword(); ok {
+		cmd.Stdin = strings.NewReader(password + "\n")
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fatalf(exitConfig, "Error signing the report with minisign: %v", err)
+	}
+
+	sig, err := os.ReadFile(signature)
+	if err != nil {
+		fatalf(exitConfig, "Error signing the report: %v", err)
+	}
+	integrity.Signature = base64.StdEncoding.EncodeToString(sig)
+
+	return integrity
+}
+
+// The password of REPORT_SIGNING_KEY, asked once per run, false for a key made
+// without one (minisign -G -W). The runs without a terminal, like serve, read
+// it from REPORT_SIGNING_PASSWORD.
+var signingPassword = sync.OnceValues(func() (string, bool) {
+	if !signingKeyEncrypted(getenv("REPORT_SIGNING_KEY")) {
+		return "", false
+	}
+
+	if password := getenv("REPORT_SIGNING_PASSWORD"); password != "" {
+		return password, true
+	}
+
+	if serving || !term.IsTerminal(int(os.Stdin.Fd())) {
+		fatalf(exitConfig, "REPORT_SIGNING_KEY has a password: set REPORT_SIGNING_PASSWORD, or make a key without one with minisign -G -W")
+	}
+
+	fmt.Fprint(os.Stderr, "Password of REPORT_SIGNING_KEY: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		fatalf(exitConfig, "Error reading the password of REPORT_SIGNING_KEY: %v", err)
+	}
+
+	return string(password), true
+})
+
+// A minisign secret key is an untrusted comment line followed by the key in
+// base64, whose KDF algorithm after the signature algorithm is Sc (scrypt)
+// when a password protects it, or zeros.
+func signingKeyEncrypted(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf(exitConfig, "Error reading REPORT_SIGNING_KEY: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(key) < 4 {
+		fatalf(exitConfig, "REPORT_SIGNING_KEY %s is not a minisign secret key", path)
+	}
+
+	return string(key[2:4]) == "Sc"
+}
+
+// The line appended to the reports that are not JSON, in a comment where the
+// format has one. The hash covers everything before it.
+func integrityTrailer(format string, integrity reportIntegrity) string {
+	fields := "sha256=" + integrity.Sha256
+	if integrity.Signature != "" {
+		fields += " signature=" + integrity.Signature
+	}
+
+	switch format {
+	case formatMarkdown, formatHTML:
+		return fmt.Sprintf("<!-- %s %s -->\n", integrityMarker, fields)
+	case formatCSV:
+		// A record of the section,row,column,value columns.
+		signature := ""
+		if integrity.Signature != "" {
+			signature = "signature=" + integrity.Signature
+		}
+		return fmt.Sprintf("integrity,%s,sha256=%s,%s\n", integrityMarker, integrity.Sha256, signature)
+	}
+
+	return fmt.Sprintf("# %s %s\n", integrityMarker, fields)
+}
+
+// The JSON report with the hash and signature of the report without them.
+func (r *report) signedJSON() ([]byte, error) {
+	out := r.jsonReport()
+
+	content, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	integrity := r.sign(content)
+	out.Integrity = &integrity
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+const jsonIntegrityMember = ",\n  \"integrity\": "
+
+var integrityFields = regexp.MustCompile(`(sha256|signature)=([A-Za-z0-9+/=]+)`)
+
+// Splits a written report into the content that was hashed and its
+// integrity.
+func readIntegrity(document []byte) ([]byte, reportIntegrity, bool) {
+	if bytes.HasPrefix(bytes.TrimSpace(document), []byte("{")) {
+		// The integrity is the last member of the JSON report, so the hashed
+		// content is the document without it, byte for byte: a field added
+		// or a key repeated by hand changes the hash.
+		document = bytes.TrimSuffix(document, []byte("\n"))
+		at := bytes.LastIndex(document, []byte(jsonIntegrityMember))
+		if at < 0 || !bytes.HasSuffix(document, []byte("\n}")) {
+			return nil, reportIntegrity{}, false
+		}
+
+		var integrity reportIntegrity
+		decoder := json.NewDecoder(bytes.NewReader(document[at+len(jsonIntegrityMember) : len(document)-2]))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&integrity); err != nil || decoder.More() {
+			return nil, reportIntegrity{}, false
+		}
+
+		content := append(append([]byte(nil), document[:at]...), "\n}"...)
+		return content, integrity, integrity.Sha256 != ""
+	}
+
+	at := bytes.LastIndex(document, []byte(integrityMarker))
+	if at < 0 {
+		return nil, reportIntegrity{}, false
+	}
+	start := bytes.LastIndexByte(document[:at], '\n') + 1
+
+	var integrity reportIntegrity
+	for _, field := range integrityFields.FindAllStringSubmatch(string(document[at:]), -1) {
+		switch field[1] {
+		case "sha256":
+			integrity.Sha256 = field[2]
+		case "signature":
+			integrity.Signature = field[2]
+		}
+	}
+
+	return document[:start], integrity, integrity.Sha256 != ""
+}
+
+// pull-metrics verify <file> checks that the report was not edited after it
+// was written, and its signature with REPORT_SIGNING_PUBLIC_KEY when signed.
+func verifyCommand(args []string) {
+	if len(args) != 1 {
+		fatalf(exitConfig, "pull-metrics verify <report file>")
+	}
+
+	document, err := os.ReadFile(args[0])
+	if err != nil {
+		fatalf(exitConfig, "Error reading the report: %v", err)
+	}
+
+	content, integrity, ok := readIntegrity(document)
+	if !ok {
+		fatalf(exitIntegrity, "The report has no integrity hash, it was written without REPORT_INTEGRITY")
+	}
+
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != integrity.Sha256 {
+		fatalf(exitIntegrity, "The report was edited after it was written: the hash does not match")
+	}
+
+	if integrity.Signature == "" {
+		fmt.Println("Hash verified, the report is not signed")
+		return
+	}
+
+	key := getenv("REPORT_SIGNING_PUBLIC_KEY")
+	if key == "" {
+		fmt.Println("Hash verified, set REPORT_SIGNING_PUBLIC_KEY to verify the signature")
+		return
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(integrity.Signature)
+	if err != nil {
+		fatalf(exitIntegrity, "Invalid signature: %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "pull-metrics-verify")
+	if err != nil {
+		fatalf(exitConfig, "Error verifying the signature: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	message, signature := filepath.Join(dir, "report"), filepath.Join(dir, "report.minisig")
+	if err := os.WriteFile(message, content, 0o600); err != nil {
+		fatalf(exitConfig, "Error verifying the signature: %v", err)
+	}
+	if err := os.WriteFile(signature, sig, 0o600); err != nil {
+		fatalf(exitConfig, "Error verifying the signature: %v", err)
+	}
+
+	output, err := exec.Command("minisign", "-V", "-p", key, "-m", message, "-x", signature).CombinedOutput()
+	if err != nil {
+		fatalf(exitIntegrity, "The signature does not match: %s", strings.TrimSpace(string(output)))
+	}
+
+	fmt.Println("Hash and signature verified")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// From the first move into an active status to the last move to Done, for
+// the issues that are still Done.
+func (issue jiraIssue) cycleTime() (time.Time, time.Time, bool) {
+	done, ok := issue.doneAt()
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	var start time.Time
+	for _, history := range issue.Changelog.Histories {
+		for _, item := range history.Items {
+			if item.Field != "status" || !jiraActive(item.ToString) {
+				continue
+			}
+			if created, err := time.Parse(jiraTimeLayout, history.Created); err == nil && (start.IsZero() || created.Before(start)) {
+				start = created
+			}
+		}
+	}
+
+	return start, done, !start.IsZero() && start.Before(done)
+}
+
+// The cycle time of the issues started in the window and done by its end, per
+// issue type, so the bugs are not lumped together with the stories. The
+// medians are outputs, for their trend.
+func jiraCycleTimeSection(issues []jiraIssue, initialDate, endDate time.Time) *reportSection {
+	byType := make(map[string][]float64)
+	var all []float64
+	for _, issue := range issues {
+		start, done, ok := issue.cycleTime()
+		if !ok || start.Before(initialDate) || done.After(endDate) {
+			continue
+		}
+
+		issueType := issue.Fields.IssueType.Name
+		byType[issueType] = append(byType[issueType], float64(done.Sub(start)))
+		all = append(all, float64(done.Sub(start)))
+	}
+
+	section := &reportSection{
+		Name:     "Jira cycle time",
+		Title:    "Cycle time by issue type",
+		Summary:  "From the first move into an active status to Done, for the issues started in the window and done by its end",
+		Header:   table.Row{"Issue type", "Done issues", "Median cycle time", "85th percentile"},
+		Centered: []int{2, 3, 4},
+		Outputs:  make(map[string]string),
+	}
+
+	for _, issueType := range sortedKeys(byType) {
+		values := byType[issueType]
+		section.Rows = append(section.Rows, table.Row{issueType, len(values), durationCell(values, 50), durationCell(values, 85)})
+
+		output := "jira_median_cycle_days_" + strings.ToLower(strings.ReplaceAll(issueType, " ", "_"))
+		section.Outputs[output] = formatOutput(median(values) / float64(24*time.Hour))
+	}
+
+	if len(all) > 0 {
+		section.Footer = table.Row{"All", len(all), durationCell(all, 50), durationCell(all, 85)}
+	}
+
+	return section
+}
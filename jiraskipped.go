@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The issues moved to Done in the window without ever being in one of the
+// work statuses, which the In Progress search misses.
+func skippedJiraJQL(jiraProject string, initialDate, endDate time.Time) string {
+	var quoted []string
+	for _, status := range jiraWorkStatuses() {
+		quoted = append(quoted, `"`+status+`"`)
+	}
+
+	return fmt.Sprintf(`project in (%s) and status changed DURING (%s, %s) TO "Done" and status was not in (%s) and issuetype not in (%s) ORDER BY assignee ASC`,
+		jiraProject, initialDate.Format("2006-01-02"), endDate.Format("2006-01-02"), strings.Join(quoted, ", "), jiraExcludedTypes())
+}
+
+// Who moved the issue to Done, and when, when it never was in a work status.
+func (issue jiraIssue) skippedStatuses() (string, time.Time, bool) {
+	work := make(map[string]bool)
+	for _, status := range jiraWorkStatuses() {
+		work[strings.ToLower(status)] = true
+	}
+
+	var by string
+	var done time.Time
+	for _, history := range issue.Changelog.Histories {
+		for _, item := range history.Items {
+			if item.Field != "status" {
+				continue
+			}
+			if work[strings.ToLower(item.ToString)] {
+				return "", time.Time{}, false
+			}
+
+			created, err := time.Parse(jiraTimeLayout, history.Created)
+			if item.ToString == "Done" && err == nil && created.After(done) {
+				by, done = history.Author.DisplayName, created
+			}
+		}
+	}
+
+	return by, done, !done.IsZero()
+}
+
+// The issues done without going through the work statuses, per person and per
+// project.
+func jiraSkippedSections(issues []jiraIssue, initialDate, endDate time.Time, people overlays) []*reportSection {
+	byPerson := make(map[string][]string)
+	byProject := make(map[string]int)
+	for _, issue := range issues {
+		person, done, ok := issue.skippedStatuses()
+		if !ok || done.Before(initialDate) || done.After(endDate) {
+			continue
+		}
+		if people.excludeRampUp && people.roster != nil && people.roster.inRampUp(done, person) {
+			continue
+		}
+
+		byPerson[person] = append(byPerson[person], issue.Key)
+		byProject[issue.Fields.Project.Key]++
+	}
+
+	if len(byPerson) == 0 {
+		return nil
+	}
+
+	summary := fmt.Sprintf("Issues moved to Done without being %s, missing from the Jira table", strings.Join(jiraWorkStatuses(), " or "))
+	persons := &reportSection{
+		Name:     "Skipped statuses",
+		Title:    "Issues that skipped the work statuses",
+		Summary:  summary,
+		Header:   table.Row{"Person", "Skipped issues", "Issues"},
+		Centered: []int{2},
+	}
+	for _, person := range sortedKeys(byPerson) {
+		persons.Rows = append(persons.Rows, table.Row{person, len(byPerson[person]), strings.Join(byPerson[person], ", ")})
+	}
+
+	projects := &reportSection{
+		Name:     "Skipped statuses by project",
+		Title:    "Issues that skipped the work statuses by project",
+		Parent:   "Skipped statuses",
+		Header:   table.Row{"Project", "Skipped issues"},
+		Centered: []int{2},
+	}
+	for _, project := range sortedKeys(byProject) {
+		projects.Rows = append(projects.Rows, table.Row{project, byProject[project]})
+	}
+
+	return []*reportSection{persons, projects}
+}
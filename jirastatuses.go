@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+)
+
+func statusList(value string) []string {
+	var statuses []string
+	for _, status := range strings.Split(value, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// The statuses whose transitions count as starting the work on an issue,
+// JIRA_ACTIVE_STATUSES, In Progress by default. With more than one, the Jira
+// tables have a column per status.
+func jiraActiveStatuses() []string {
+	statuses := statusList(getenv("JIRA_ACTIVE_STATUSES"))
+	if len(statuses) == 0 {
+		return []string{"In Progress"}
+	}
+
+	return statuses
+}
+
+// The active status the name matches, as configured.
+func jiraActiveStatus(name string) (string, bool) {
+	for _, status := range jiraActiveStatuses() {
+		if strings.EqualFold(status, name) {
+			return status, true
+		}
+	}
+
+	return "", false
+}
+
+func jiraActive(name string) bool {
+	_, ok := jiraActiveStatus(name)
+	return ok
+}
+
+// The statuses of the work on an issue, JIRA_WORK_STATUSES, which the issues
+// moved straight to Done skip. The active statuses and In Review by default.
+func jiraWorkStatuses() []string {
+	if statuses := statusList(getenv("JIRA_WORK_STATUSES")); len(statuses) > 0 {
+		return statuses
+	}
+
+	statuses := jiraActiveStatuses()
+	if !jiraActive("In Review") {
+		statuses = append(statuses, "In Review")
+	}
+
+	return statuses
+}
+
+// The JQL condition of the issues moved into an active status in the window.
+func jiraActiveJQL(initialDate, endDate string) string {
+	var conditions []string
+	for _, status := range jiraActiveStatuses() {
+		conditions = append(conditions, `status changed DURING (`+initialDate+`, `+endDate+`) TO "`+status+`"`)
+	}
+
+	return "(" + strings.Join(conditions, " or ") + ")"
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// With JIRA_SUBTASKS=rollup the sub-tasks are searched too, and their
+// transitions count for their parent story and its assignee, for the teams
+// that track the work in sub-tasks.
+func jiraRollUp() bool {
+	switch value := getenv("JIRA_SUBTASKS"); value {
+	case "":
+		return false
+	case "rollup":
+		return true
+	default:
+		fatalf(exitConfig, "Invalid JIRA_SUBTASKS %q: expected rollup", value)
+		return false
+	}
+}
+
+// The issue types left out of the searches.
+func jiraExcludedTypes() string {
+	if jiraRollUp() {
+		return "Epic"
+	}
+
+	return "Epic, sub-task"
+}
+
+// Jira answers the parent of a sub-task with its type and status, but not its
+// assignee, which comes from the parents among the issues or from another
+// search.
+func rollUpSubtasks(jiraBaseUrl, auth string, issues []jiraIssue) {
+	assignees := make(map[string]string)
+	for _, issue := range issues {
+		assignees[issue.Key] = issue.Fields.Assignee.DisplayName
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		if parent := issue.Fields.Parent; parent != nil {
+			if _, ok := assignees[parent.Key]; !ok && !seen[parent.Key] {
+				seen[parent.Key] = true
+				missing = append(missing, parent.Key)
+			}
+		}
+	}
+
+	for start := 0; start < len(missing); start += 50 {
+		keys := missing[start:min(start+50, len(missing))]
+		fmt.Fprintf(progress, "Requesting the assignees of %d parent issues\n", len(keys))
+		for _, parent := range searchJira(jiraBaseUrl, auth, fmt.Sprintf("key in (%s)", strings.Join(keys, ", "))) {
+			assignees[parent.Key] = parent.Fields.Assignee.DisplayName
+		}
+	}
+
+	for i, issue := range issues {
+		if parent := issue.Fields.Parent; parent != nil {
+			issues[i].ParentAssignee = assignees[parent.Key]
+		}
+	}
+}
+
+// The issue a transition counts for: the parent of a rolled up sub-task, with
+// its type, status and assignee, or the issue itself moved by author.
+func (issue jiraIssue) countedAs(author string) (key, issueType, status, person string) {
+	if parent := issue.Fields.Parent; parent != nil && jiraRollUp() {
+		person = issue.ParentAssignee
+		if person == "" {
+			person = author
+		}
+
+		return parent.Key, parent.Fields.IssueType.Name, parent.Fields.Status.Name, person
+	}
+
+	return issue.Key, issue.Fields.IssueType.Name, issue.Fields.Status.Name, author
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"golang.org/x/term"
+)
+
+const (
+	layoutAuto    = "auto"
+	layoutWide    = "wide"
+	layoutCompact = "compact"
+	layoutCards   = "cards"
+)
+
+var abbreviations = map[string]string{
+	"Total PRs":                "PRs",
+	"Merged PRs":               "Merged",
+	"Merged PRs (%)":           "Merged %",
+	"Open PRs":                 "Open",
+	"Added lines":              "+Lines",
+	"Removed lines":            "-Lines",
+	"Changed files":            "Files",
+	"Requested reviewers / PR": "Requested",
+	"Reviewers / PR":           "Reviewers",
+	"Available days":           "Avail.",
+	"PRs / day":                "PRs/d",
+	"Started / day":            "Started/d",
+	"Total started":            "Started",
+	"Spikes started":           "Spikes",
+}
+
+// Returns 0 when stdout is not a terminal and COLUMNS is not set, in which case
+// the tables are never adapted.
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+
+	width, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return width
+}
+
+// Takes the English header, since the abbreviations are English too, and
+// returns it localized. The other long names are cut by width, so the accents
+// of the translations are not split.
+func abbreviate(header interface{}) interface{} {
+	name := fmt.Sprint(header)
+	if short, ok := abbreviations[name]; ok {
+		return tr(short)
+	}
+
+	name = tr(name)
+	if text.RuneWidthWithoutEscSequences(name) > 10 {
+		return text.Trim(name, 9) + "."
+	}
+
+	return name
+}
+
+func (s *reportSection) compactTable(style cellStyle) table.Writer {
+	header := make(table.Row, len(s.Header))
+	for i, name := range s.Header {
+		header[i] = abbreviate(name)
+	}
+
+	compact := table.NewWriter()
+	compact.AppendHeader(header)
+	for _, row := range s.Rows {
+		compact.AppendRow(decorateRow(s.formatRow(row), style))
+	}
+	if s.Footer != nil {
+		compact.AppendFooter(localizedFooter(s.formatRow(s.Footer)))
+	}
+
+	// Text columns (names, URLs...) are the ones that can be trimmed without
+	// losing the numbers.
+	var configs []table.ColumnConfig
+	for i := range s.Header {
+		config := table.ColumnConfig{Number: i + 1}
+		for _, number := range s.Centered {
+			if number == i+1 {
+				config.Align = text.AlignCenter
+				config.AlignFooter = text.AlignCenter
+			}
+		}
+		if s.isTextColumn(i) {
+			config.WidthMax = 16
+			config.WidthMaxEnforcer = text.Trim
+		}
+		configs = append(configs, config)
+	}
+	compact.SetColumnConfigs(configs)
+	compact.SetStyle(table.StyleLight)
+
+	return compact
+}
+
+func (s *reportSection) isTextColumn(column int) bool {
+	for _, row := range s.Rows {
+		if column < len(row) {
+			if _, ok := row[column].(string); !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (s *reportSection) cards(style cellStyle) string {
+	var b strings.Builder
+
+	card := func(row table.Row) {
+		title := fmt.Sprint(row[0])
+		first := 1
+		if len(row) > 1 && s.isTextColumn(1) {
+			if name := fmt.Sprint(row[1]); name != "" {
+				title += " (" + name + ")"
+			}
+			first = 2
+		}
+
+		if style == colorCells {
+			title = text.Colors{text.Bold}.Sprint(title)
+		}
+		fmt.Fprintln(&b, title)
+		for i := first; i < len(row) && i < len(s.Header); i++ {
+			value := fmt.Sprint(decorateCell(s.formatCell(i, row[i]), style))
+			if value == "" {
+				continue
+			}
+
+			value = strings.ReplaceAll(value, "\n", "\n    ")
+			fmt.Fprintf(&b, "  %s: %s\n", tr(fmt.Sprint(s.Header[i])), value)
+		}
+		fmt.Fprintln(&b)
+	}
+
+	for _, row := range s.Rows {
+		card(row)
+	}
+
+	if s.Footer != nil {
+		card(localizedFooter(s.Footer))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (s *reportSection) renderForTerminal(style cellStyle, options terminalOptions) string {
+	switch options.layout {
+	case layoutCompact:
+		return s.compactTable(style).Render()
+	case layoutCards:
+		return s.cards(style)
+	case layoutWide:
+		return s.table(style).Render()
+	}
+
+	rendered := s.table(style).Render()
+	if options.width <= 0 || text.LongestLineLen(rendered) <= options.width {
+		return rendered
+	}
+
+	if compact := s.compactTable(style).Render(); text.LongestLineLen(compact) <= options.width {
+		return compact
+	}
+
+	return s.cards(style)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The fastest median turnaround only ranks the reviewers with at least this
+// many reviews answered, so a single quick review does not win.
+const leaderboardMinReviews = 3
+
+type leaderboardEntry struct {
+	login string
+	value float64
+	cell  interface{}
+}
+
+// The first LEADERBOARD_SIZE entries, the highest values first, or the lowest
+// with ascending. The people who opted out are not ranked.
+func topEntries(entries []leaderboardEntry, ascending bool) []leaderboardEntry {
+	people := optedOutPeople()
+	ranked := entries[:0]
+	for _, entry := range entries {
+		if !people[strings.ToLower(entry.login)] {
+			ranked = append(ranked, entry)
+		}
+	}
+	entries = ranked
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].value != entries[j].value {
+			return (entries[i].value < entries[j].value) == ascending
+		}
+		return entries[i].login < entries[j].login
+	})
+
+	return entries[:min(len(entries), envInt("LEADERBOARD_SIZE", 5))]
+}
+
+// With --leaderboards, the top reviewers, the fastest median review
+// turnarounds and the most PRs merged, for the shout-outs of a weekly summary.
+func leaderboardSection(prs []pullRequest, endDate time.Time) *reportSection {
+	reviews := make(map[string]int)
+	turnarounds := make(map[string][]float64)
+	merged := make(map[string]int)
+
+	for _, pr := range prs {
+		if pr.stateAt(endDate) == prMerged {
+			merged[pr.Author.Login]++
+		}
+
+		reviewed := make(map[string]bool)
+		for _, review := range pr.Reviews.Nodes {
+			reviewer := review.Author.Login
+			if reviewer != "" && reviewer != pr.Author.Login && review.State != "PENDING" && !review.SubmittedAt.After(endDate) && !reviewed[reviewer] {
+				reviewed[reviewer] = true
+				reviews[reviewer]++
+			}
+		}
+
+		for _, request := range pr.reviewRequests() {
+			if reviewedAt, ok := pr.firstReviewBy(request.reviewer, request.requestedAt); ok && !reviewedAt.After(endDate) {
+				turnarounds[request.reviewer] = append(turnarounds[request.reviewer], float64(reviewedAt.Sub(request.requestedAt)))
+			}
+		}
+	}
+
+	var reviewers, fastest, mergers []leaderboardEntry
+	for login, count := range reviews {
+		reviewers = append(reviewers, leaderboardEntry{login, float64(count), count})
+	}
+	for login, values := range turnarounds {
+		if len(values) >= leaderboardMinReviews {
+			fastest = append(fastest, leaderboardEntry{login, median(values), durationCell(values, 50)})
+		}
+	}
+	for login, count := range merged {
+		mergers = append(mergers, leaderboardEntry{login, float64(count), count})
+	}
+
+	boards := [][]leaderboardEntry{topEntries(reviewers, false), topEntries(fastest, true), topEntries(mergers, false)}
+
+	section := &reportSection{
+		Name:     "Leaderboards",
+		Title:    "Leaderboards",
+		Summary:  fmt.Sprintf("The fastest median review turnaround ranks the reviewers with %d reviews or more", leaderboardMinReviews),
+		Header:   table.Row{"Rank", "Top reviewers", "Fastest review turnaround", "Most PRs merged"},
+		Centered: []int{1},
+	}
+
+	rows := max(len(boards[0]), len(boards[1]), len(boards[2]))
+	for i := 0; i < rows; i++ {
+		row := table.Row{i + 1}
+		for _, board := range boards {
+			cell := ""
+			if i < len(board) {
+				cell = fmt.Sprintf("%s (%v)", board[i].login, board[i].cell)
+			}
+			row = append(row, cell)
+		}
+		section.Rows = append(section.Rows, row)
+	}
+
+	return section
+}
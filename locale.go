@@ -0,0 +1,424 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// How the human readable reports write numbers and dates, and their words.
+// CSV and JSON stay in English with plain numbers, so scripts keep working
+// whatever the LOCALE.
+type locale struct {
+	decimal    string
+	dateLayout string
+	words      map[string]string
+}
+
+var locales = map[string]locale{
+	"en": {decimal: ".", dateLayout: "2006-01-02"},
+	"pt-BR": {decimal: ",", dateLayout: "02/01/2006", words: map[string]string{
+		"Pull metrics":                          "Métricas de pull requests",
+		"No activity in this period":            "Sem atividade neste período",
+		"No activity between":                   "Sem atividade entre",
+		"and":                                   "e",
+		"Averages":                              "Médias",
+		"Total":                                 "Total",
+		"ID":                                    "ID",
+		"Name":                                  "Nome",
+		"Total PRs":                             "Total de PRs",
+		"Merged PRs":                            "PRs mesclados",
+		"Merged PRs (%)":                        "PRs mesclados (%)",
+		"Open PRs":                              "PRs abertos",
+		"Added lines":                           "Linhas adicionadas",
+		"Removed lines":                         "Linhas removidas",
+		"Changed files":                         "Arquivos alterados",
+		"Contribution":                          "Contribuição",
+		"URLs":                                  "URLs",
+		"Available days":                        "Dias disponíveis",
+		"PRs / day":                             "PRs / dia",
+		"Started / day":                         "Iniciadas / dia",
+		"On call":                               "Plantão",
+		"Total started":                         "Total iniciadas",
+		"Spikes started":                        "Spikes iniciados",
+		"Closed":                                "Fechadas",
+		"Cohorts":                               "Coortes",
+		"Cohort":                                "Coorte",
+		"People":                                "Pessoas",
+		"PRs / person":                          "PRs / pessoa",
+		"Added lines / person":                  "Linhas adicionadas / pessoa",
+		"Removed lines / person":                "Linhas removidas / pessoa",
+		"Review assignment":                     "Atribuição de revisões",
+		"Repository":                            "Repositório",
+		"Reviewer requested":                    "Revisor solicitado",
+		"Nobody asked":                          "Ninguém solicitado",
+		"Median latency":                        "Latência mediana",
+		"90th percentile latency":               "Latência no percentil 90",
+		"Review response":                       "Resposta às revisões",
+		"Requests":                              "Solicitações",
+		"Reviewed":                              "Revisadas",
+		"Pending":                               "Pendentes",
+		"Median response":                       "Resposta mediana",
+		"Within SLA (%)":                        "Dentro do SLA (%)",
+		"Cross-team reviews":                    "Revisões entre times",
+		"Author team":                           "Time do autor",
+		"Knowledge concentration":               "Concentração de conhecimento",
+		"Area":                                  "Área",
+		"Changed lines":                         "Linhas alteradas",
+		"Top contributor":                       "Maior contribuidor",
+		"Top contributor (%)":                   "Maior contribuidor (%)",
+		"Bus factor":                            "Fator ônibus",
+		"Repository groups":                     "Grupos de repositórios",
+		"Group":                                 "Grupo",
+		"Repositories":                          "Repositórios",
+		"Authors":                               "Autores",
+		"Maintainer response":                   "Resposta dos mantenedores",
+		"External PRs":                          "PRs externos",
+		"Answered":                              "Respondidos",
+		"Median first response":                 "Primeira resposta mediana",
+		"Decided":                               "Decididos",
+		"Median time to decision":               "Tempo mediano até a decisão",
+		"Community health":                      "Saúde da comunidade",
+		"New contributors":                      "Novos contribuidores",
+		"Metric":                                "Métrica",
+		"Value":                                 "Valor",
+		"First PR":                              "Primeiro PR",
+		"First response":                        "Primeira resposta",
+		"Abandoned PRs by author":               "PRs abandonados por autor",
+		"Abandoned PRs by repository":           "PRs abandonados por repositório",
+		"Closed unmerged":                       "Fechados sem merge",
+		"Closed unmerged (%)":                   "Fechados sem merge (%)",
+		"Median time open":                      "Tempo mediano aberto",
+		"Common labels":                         "Labels comuns",
+		"PR aging":                              "Idade dos PRs",
+		"Active PRs":                            "PRs ativos",
+		"Longest open PRs":                      "PRs abertos há mais tempo",
+		"URL":                                   "URL",
+		"Author":                                "Autor",
+		"Time open":                             "Tempo aberto",
+		"State":                                 "Estado",
+		"Who reviews whom":                      "Quem revisa quem",
+		"Reviewers":                             "Revisores",
+		"Review tone":                           "Tom das revisões",
+		"Scored":                                "Avaliados",
+		"Negative":                              "Negativos",
+		"Negative (%)":                          "Negativos (%)",
+		"Median score":                          "Nota mediana",
+		"Most negative threads":                 "Discussões mais negativas",
+		"Lowest score":                          "Menor nota",
+		"Open PRs at the same time":             "PRs abertos ao mesmo tempo",
+		"Issues In Progress at the same time":   "Issues em andamento ao mesmo tempo",
+		"Average WIP":                           "WIP médio",
+		"Peak WIP":                              "WIP máximo",
+		"Person":                                "Pessoa",
+		"Churn by author":                       "Retrabalho por autor",
+		"Churn by repository":                   "Retrabalho por repositório",
+		"Churned lines":                         "Linhas retrabalhadas",
+		"Churn (%)":                             "Retrabalho (%)",
+		"File hotspots":                         "Arquivos mais alterados",
+		"Directory hotspots":                    "Diretórios mais alterados",
+		"File":                                  "Arquivo",
+		"Directory":                             "Diretório",
+		"Fix PRs":                               "PRs de correção",
+		"Role":                                  "Papel",
+		"PRs per author":                        "PRs por autor",
+		"PRs per week":                          "PRs por semana",
+		"Merge rate trend":                      "Tendência da taxa de merge",
+		"Cycle time":                            "Tempo de ciclo",
+		"Days":                                  "Dias",
+		"PRs":                                   "PRs",
+		"PRs per repository":                    "PRs por repositório",
+		"PRs per team":                          "PRs por time",
+		"Issues that skipped the work statuses": "Issues que pularam os status de trabalho",
+		"Issues that skipped the work statuses by project": "Issues que pularam os status de trabalho por projeto",
+		"Skipped issues":              "Issues puladas",
+		"Issues":                      "Issues",
+		"Project":                     "Projeto",
+		"Cycle time by issue type":    "Tempo de ciclo por tipo de issue",
+		"Issue type":                  "Tipo de issue",
+		"Done issues":                 "Issues concluídas",
+		"Median cycle time":           "Tempo de ciclo mediano",
+		"85th percentile":             "Percentil 85",
+		"Bug resolution SLA":          "SLA de resolução de bugs",
+		"Assignee":                    "Responsável",
+		"Resolved bugs":               "Bugs resolvidos",
+		"Breached":                    "Violados",
+		"Open past SLA":               "Abertos além do SLA",
+		"Unreviewed":                  "Sem revisão",
+		"Review coverage (%)":         "Cobertura de revisão (%)",
+		"Force pushes / PR":           "Force pushes / PR",
+		"Update merges / PR":          "Merges de atualização / PR",
+		"PRs with friction (%)":       "PRs com atrito (%)",
+		"Requested reviewers / PR":    "Revisores solicitados / PR",
+		"Reviewers / PR":              "Revisores / PR",
+		"Reviewers per PR":            "Revisores por PR",
+		"Rank":                        "Posição",
+		"Top reviewers":               "Quem mais revisou",
+		"Fastest review turnaround":   "Revisão mais rápida",
+		"Most PRs merged":             "Mais PRs mesclados",
+		"Annotations":                 "Anotações",
+		"Velocity":                    "Velocidade",
+		"Period":                      "Período",
+		"Working days":                "Dias úteis",
+		"Capacity (%)":                "Capacidade (%)",
+		"Merged PRs / person-week":    "PRs mesclados / pessoa-semana",
+		"Merged PRs at full capacity": "PRs mesclados com capacidade total",
+		"Services":                    "Serviços",
+		"Service":                     "Serviço",
+		"Dependency PRs":              "PRs de dependências",
+		"Approved (%)":                "Aprovados (%)",
+		"Median approval latency":     "Latência mediana de aprovação",
+		"Merged unapproved":           "Mesclados sem aprovação",
+		"Major bumps":                 "Atualizações major",
+		"Merged":                      "Mesclados",
+		"Merged %":                    "Mesclados %",
+		"Open":                        "Abertos",
+		"+Lines":                      "+Linhas",
+		"-Lines":                      "-Linhas",
+		"Files":                       "Arquivos",
+		"Requested":                   "Solicitados",
+		"Avail.":                      "Disp.",
+		"PRs/d":                       "PRs/dia",
+		"Started/d":                   "Inic./dia",
+		"Full report":                 "Relatório completo",
+		"Started":                     "Iniciadas",
+	}},
+	"de": {decimal: ",", dateLayout: "02.01.2006", words: map[string]string{
+		"Pull metrics":                          "Pull-Request-Metriken",
+		"No activity in this period":            "Keine Aktivität in diesem Zeitraum",
+		"No activity between":                   "Keine Aktivität zwischen",
+		"and":                                   "und",
+		"Averages":                              "Durchschnitt",
+		"Total":                                 "Gesamt",
+		"ID":                                    "ID",
+		"Name":                                  "Name",
+		"Total PRs":                             "PRs gesamt",
+		"Merged PRs":                            "Gemergte PRs",
+		"Merged PRs (%)":                        "Gemergte PRs (%)",
+		"Open PRs":                              "Offene PRs",
+		"Added lines":                           "Hinzugefügte Zeilen",
+		"Removed lines":                         "Entfernte Zeilen",
+		"Changed files":                         "Geänderte Dateien",
+		"Contribution":                          "Beitrag",
+		"URLs":                                  "URLs",
+		"Available days":                        "Verfügbare Tage",
+		"PRs / day":                             "PRs / Tag",
+		"Started / day":                         "Begonnen / Tag",
+		"On call":                               "Bereitschaft",
+		"Total started":                         "Begonnen gesamt",
+		"Spikes started":                        "Begonnene Spikes",
+		"Closed":                                "Geschlossen",
+		"Cohorts":                               "Kohorten",
+		"Cohort":                                "Kohorte",
+		"People":                                "Personen",
+		"PRs / person":                          "PRs / Person",
+		"Added lines / person":                  "Hinzugefügte Zeilen / Person",
+		"Removed lines / person":                "Entfernte Zeilen / Person",
+		"Review assignment":                     "Review-Zuweisung",
+		"Repository":                            "Repository",
+		"Reviewer requested":                    "Reviewer angefragt",
+		"Nobody asked":                          "Niemand angefragt",
+		"Median latency":                        "Median der Latenz",
+		"90th percentile latency":               "90. Perzentil der Latenz",
+		"Review response":                       "Review-Antwortzeit",
+		"Requests":                              "Anfragen",
+		"Reviewed":                              "Reviewt",
+		"Pending":                               "Ausstehend",
+		"Median response":                       "Median der Antwortzeit",
+		"Within SLA (%)":                        "Innerhalb des SLA (%)",
+		"Cross-team reviews":                    "Teamübergreifende Reviews",
+		"Author team":                           "Team des Autors",
+		"Knowledge concentration":               "Wissenskonzentration",
+		"Area":                                  "Bereich",
+		"Changed lines":                         "Geänderte Zeilen",
+		"Top contributor":                       "Hauptbeitragender",
+		"Top contributor (%)":                   "Hauptbeitragender (%)",
+		"Bus factor":                            "Busfaktor",
+		"Repository groups":                     "Repository-Gruppen",
+		"Group":                                 "Gruppe",
+		"Repositories":                          "Repositories",
+		"Authors":                               "Autoren",
+		"Maintainer response":                   "Antwort der Maintainer",
+		"External PRs":                          "Externe PRs",
+		"Answered":                              "Beantwortet",
+		"Median first response":                 "Median der ersten Antwort",
+		"Decided":                               "Entschieden",
+		"Median time to decision":               "Median bis zur Entscheidung",
+		"Community health":                      "Gesundheit der Community",
+		"New contributors":                      "Neue Beitragende",
+		"Metric":                                "Metrik",
+		"Value":                                 "Wert",
+		"First PR":                              "Erster PR",
+		"First response":                        "Erste Antwort",
+		"Abandoned PRs by author":               "Verworfene PRs nach Autor",
+		"Abandoned PRs by repository":           "Verworfene PRs nach Repository",
+		"Closed unmerged":                       "Ungemergt geschlossen",
+		"Closed unmerged (%)":                   "Ungemergt geschlossen (%)",
+		"Median time open":                      "Median der offenen Zeit",
+		"Common labels":                         "Häufige Labels",
+		"PR aging":                              "Alter der PRs",
+		"Active PRs":                            "Aktive PRs",
+		"Longest open PRs":                      "Am längsten offene PRs",
+		"URL":                                   "URL",
+		"Author":                                "Autor",
+		"Time open":                             "Offene Zeit",
+		"State":                                 "Status",
+		"Who reviews whom":                      "Wer reviewt wen",
+		"Reviewers":                             "Reviewer",
+		"Review tone":                           "Ton der Reviews",
+		"Scored":                                "Bewertet",
+		"Negative":                              "Negativ",
+		"Negative (%)":                          "Negativ (%)",
+		"Median score":                          "Median der Bewertung",
+		"Most negative threads":                 "Negativste Diskussionen",
+		"Lowest score":                          "Niedrigste Bewertung",
+		"Open PRs at the same time":             "Gleichzeitig offene PRs",
+		"Issues In Progress at the same time":   "Gleichzeitig bearbeitete Issues",
+		"Average WIP":                           "Durchschnittliches WIP",
+		"Peak WIP":                              "Maximales WIP",
+		"Person":                                "Person",
+		"Churn by author":                       "Churn nach Autor",
+		"Churn by repository":                   "Churn nach Repository",
+		"Churned lines":                         "Erneut geänderte Zeilen",
+		"Churn (%)":                             "Churn (%)",
+		"File hotspots":                         "Am häufigsten geänderte Dateien",
+		"Directory hotspots":                    "Am häufigsten geänderte Verzeichnisse",
+		"File":                                  "Datei",
+		"Directory":                             "Verzeichnis",
+		"Fix PRs":                               "Fix-PRs",
+		"Role":                                  "Rolle",
+		"PRs per author":                        "PRs pro Autor",
+		"PRs per week":                          "PRs pro Woche",
+		"Merge rate trend":                      "Verlauf der Merge-Rate",
+		"Cycle time":                            "Durchlaufzeit",
+		"Days":                                  "Tage",
+		"PRs":                                   "PRs",
+		"PRs per repository":                    "PRs pro Repository",
+		"PRs per team":                          "PRs pro Team",
+		"Issues that skipped the work statuses": "Issues ohne Arbeitsstatus",
+		"Issues that skipped the work statuses by project": "Issues ohne Arbeitsstatus pro Projekt",
+		"Skipped issues":              "Übersprungene Issues",
+		"Issues":                      "Issues",
+		"Project":                     "Projekt",
+		"Cycle time by issue type":    "Durchlaufzeit pro Issue-Typ",
+		"Issue type":                  "Issue-Typ",
+		"Done issues":                 "Erledigte Issues",
+		"Median cycle time":           "Median der Durchlaufzeit",
+		"85th percentile":             "85. Perzentil",
+		"Bug resolution SLA":          "SLA für die Behebung von Bugs",
+		"Assignee":                    "Bearbeiter",
+		"Resolved bugs":               "Behobene Bugs",
+		"Breached":                    "Verletzt",
+		"Open past SLA":               "Offen über SLA",
+		"Unreviewed":                  "Ohne Review",
+		"Review coverage (%)":         "Review-Abdeckung (%)",
+		"Force pushes / PR":           "Force-Pushes / PR",
+		"Update merges / PR":          "Update-Merges / PR",
+		"PRs with friction (%)":       "PRs mit Reibung (%)",
+		"Requested reviewers / PR":    "Angefragte Reviewer / PR",
+		"Reviewers / PR":              "Reviewer / PR",
+		"Reviewers per PR":            "Reviewer pro PR",
+		"Rank":                        "Platz",
+		"Top reviewers":               "Top-Reviewer",
+		"Fastest review turnaround":   "Schnellste Reviews",
+		"Most PRs merged":             "Meiste gemergte PRs",
+		"Annotations":                 "Anmerkungen",
+		"Velocity":                    "Geschwindigkeit",
+		"Period":                      "Zeitraum",
+		"Working days":                "Arbeitstage",
+		"Capacity (%)":                "Kapazität (%)",
+		"Merged PRs / person-week":    "Gemergte PRs / Personenwoche",
+		"Merged PRs at full capacity": "Gemergte PRs bei voller Kapazität",
+		"Services":                    "Dienste",
+		"Service":                     "Dienst",
+		"Dependency PRs":              "Abhängigkeits-PRs",
+		"Approved (%)":                "Genehmigt (%)",
+		"Median approval latency":     "Median der Genehmigungszeit",
+		"Merged unapproved":           "Ohne Genehmigung gemergt",
+		"Major bumps":                 "Major-Updates",
+		"Merged":                      "Gemergt",
+		"Merged %":                    "Gemergt %",
+		"Open":                        "Offen",
+		"+Lines":                      "+Zeilen",
+		"-Lines":                      "-Zeilen",
+		"Files":                       "Dateien",
+		"Requested":                   "Angefragt",
+		"Avail.":                      "Verfügb.",
+		"PRs/d":                       "PRs/Tag",
+		"Started/d":                   "Begonn./Tag",
+		"Full report":                 "Vollständiger Bericht",
+		"Started":                     "Begonnen",
+	}},
+}
+
+var currentLocale = locales["en"]
+
+func loadLocale() locale {
+	name := getenv("LOCALE")
+	if name == "" {
+		return locales["en"]
+	}
+
+	for tag, l := range locales {
+		if strings.EqualFold(tag, name) || strings.EqualFold(strings.ReplaceAll(tag, "-", "_"), name) {
+			return l
+		}
+	}
+
+	var tags []string
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	fatalf(exitConfig, "Unknown LOCALE %q, expected one of %s", name, strings.Join(tags, ", "))
+	return locale{}
+}
+
+// Translates the fixed words of the report. Anything else, like names, is
+// kept as is.
+func tr(s string) string {
+	if translated, ok := currentLocale.words[s]; ok {
+		return translated
+	}
+
+	return s
+}
+
+func formatDate(t time.Time) string {
+	return t.Format(currentLocale.dateLayout)
+}
+
+func formatDecimal(format string, value float64) string {
+	return strings.Replace(fmt.Sprintf(format, value), ".", currentLocale.decimal, 1)
+}
+
+func localizedHeader(header table.Row) table.Row {
+	localized := make(table.Row, len(header))
+	for i, name := range header {
+		if s, ok := name.(string); ok {
+			localized[i] = tr(s)
+		} else {
+			localized[i] = name
+		}
+	}
+
+	return localized
+}
+
+// Only the label of the footer, in its first cell, is a word.
+func localizedFooter(footer table.Row) table.Row {
+	if len(footer) == 0 {
+		return footer
+	}
+
+	localized := append(table.Row{}, footer...)
+	if s, ok := localized[0].(string); ok {
+		localized[0] = tr(s)
+	}
+
+	return localized
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Shown instead of an average or a percentage with nothing to divide by.
+const notApplicable = "-"
+
+func ratio(part, total float64) (float64, bool) {
+	if total == 0 || math.IsNaN(part) || math.IsNaN(total) {
+		return 0, false
+	}
+
+	return part / total, true
+}
+
+func averageOf(total, count int) interface{} {
+	value, ok := ratio(float64(total), float64(count))
+	if !ok {
+		return notApplicable
+	}
+
+	return average(value)
+}
+
+func percentOf(part, total int) (percent, bool) {
+	value, ok := ratio(float64(part*100), float64(total))
+	return percent(value), ok
+}
+
+func percentCell(part, total int) interface{} {
+	value, ok := percentOf(part, total)
+	if !ok {
+		return notApplicable
+	}
+
+	return value
+}
+
+// Formats a value for the machine readable outputs, leaving it empty when it
+// is undefined, like the median of no values.
+func formatOutput(value float64) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return ""
+	}
+
+	return fmt.Sprintf("%.1f", value)
+}
+
+const (
+	prOpen   = "Open"
+	prMerged = "Merged"
+	prClosed = "Closed"
+)
+
+// Replays the close, reopen and merge events up to the date. The timestamps
+// of the PR only tell the last close, so a PR closed before the date and
+// reopened after it would look open. They are still the fallback when the
+// timeline has no such events, for data fetched without them. A merge is
+// final, so it is trusted before the events.
+func (pr pullRequest) stateAt(date time.Time) string {
+	if pr.CreatedAt.After(date) {
+		return ""
+	}
+
+	if pr.Merged && !pr.MergedAt.After(date) {
+		return prMerged
+	}
+
+	events := pr.stateEvents()
+	if len(events) == 0 {
+		if pr.Closed && !pr.ClosedAt.After(date) {
+			return prClosed
+		}
+
+		return prOpen
+	}
+
+	// Closes and reopens alternate, so the state before the fetched events
+	// is the one the first of them left.
+	state := prOpen
+	if pr.StateEvents.PageInfo.HasPreviousPage && events[0].state == prOpen {
+		state = prClosed
+	}
+
+	for _, e := range events {
+		if e.at.After(date) {
+			break
+		}
+		if state != prMerged {
+			state = e.state
+		}
+	}
+
+	return state
+}
+
+type prStateEvent struct {
+	at    time.Time
+	state string
+}
+
+// The closes, reopens and merge of the PR, oldest first. The data fetched
+// before StateEvents has them in TimelineItems.
+func (pr pullRequest) stateEvents() []prStateEvent {
+	var events []prStateEvent
+	for _, items := range [][]timelineItem{pr.TimelineItems.Nodes, pr.StateEvents.Nodes} {
+		for _, item := range items {
+			switch item.Typename {
+			case "ClosedEvent":
+				events = append(events, prStateEvent{item.ClosedEvent.CreatedAt, prClosed})
+			case "ReopenedEvent":
+				events = append(events, prStateEvent{item.ReopenedEvent.CreatedAt, prOpen})
+			case "MergedEvent":
+				events = append(events, prStateEvent{item.MergedEvent.CreatedAt, prMerged})
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	return events
+}
+
+// The state of a set of PRs as of the end of the report: open PRs were open
+// at the end date, even if they were merged or closed later.
+type prCounts struct {
+	prs            int
+	merged         int
+	open           int
+	closedUnmerged int
+	addedLines     int
+	removedLines   int
+	changedFiles   int
+}
+
+func countPRs(prs []pullRequest, endDate time.Time) prCounts {
+	var counts prCounts
+
+	for _, pr := range prs {
+		counts.prs++
+		counts.addedLines += pr.Additions
+		counts.removedLines += pr.Deletions
+		counts.changedFiles += pr.ChangedFiles
+
+		switch pr.stateAt(endDate) {
+		case prMerged:
+			counts.merged++
+		case prClosed:
+			counts.closedUnmerged++
+		default:
+			counts.open++
+		}
+	}
+
+	return counts
+}
+
+func (counts prCounts) mergeRate() (percent, bool) {
+	return percentOf(counts.merged, counts.prs)
+}
+
+// The merge rate checked against its threshold, or notApplicable without PRs.
+func (counts prCounts) mergeRateCell() interface{} {
+	rate, ok := counts.mergeRate()
+	if !ok {
+		return notApplicable
+	}
+
+	return thresholds.mergeRate(rate)
+}
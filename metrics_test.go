@@ -0,0 +1,276 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var (
+	testStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testEnd   = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func testPR(login string, created time.Time) pullRequest {
+	var pr pullRequest
+	pr.Author.Login = login
+	pr.Url = login + "/" + created.Format(time.RFC3339)
+	pr.CreatedAt = created
+	pr.Additions = 10
+	pr.Deletions = 4
+	pr.ChangedFiles = 2
+
+	return pr
+}
+
+func mergedPR(login string, created, merged time.Time) pullRequest {
+	pr := testPR(login, created)
+	pr.Merged, pr.MergedAt = true, merged
+	pr.Closed, pr.ClosedAt = true, merged
+
+	return pr
+}
+
+func closedPR(login string, created, closed time.Time) pullRequest {
+	pr := testPR(login, created)
+	pr.Closed, pr.ClosedAt = true, closed
+
+	return pr
+}
+
+// The value of a cell without its threshold flag.
+func unflagged(cell interface{}) interface{} {
+	if f, ok := cell.(flagged); ok {
+		return f.value
+	}
+
+	return cell
+}
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		name        string
+		part, total float64
+		want        float64
+		ok          bool
+	}{
+		{"half", 1, 2, 0.5, true},
+		{"zero part", 0, 3, 0, true},
+		{"zero total", 0, 0, 0, false},
+		{"part of nothing", 5, 0, 0, false},
+		{"undefined part", math.NaN(), 2, 0, false},
+		{"undefined total", 1, math.NaN(), 0, false},
+	}
+
+	for _, test := range tests {
+		got, ok := ratio(test.part, test.total)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: ratio(%v, %v) = %v, %v, want %v, %v", test.name, test.part, test.total, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestAverageOf(t *testing.T) {
+	tests := []struct {
+		name         string
+		total, count int
+		want         interface{}
+	}{
+		{"no users", 0, 0, notApplicable},
+		{"total without users", 3, 0, notApplicable},
+		{"no PRs", 0, 2, average(0)},
+		{"average", 6, 4, average(1.5)},
+	}
+
+	for _, test := range tests {
+		if got := averageOf(test.total, test.count); got != test.want {
+			t.Errorf("%s: averageOf(%d, %d) = %v, want %v", test.name, test.total, test.count, got, test.want)
+		}
+	}
+}
+
+func TestPercentCell(t *testing.T) {
+	tests := []struct {
+		name        string
+		part, total int
+		want        interface{}
+	}{
+		{"no PRs", 0, 0, notApplicable},
+		{"none merged", 0, 3, percent(0)},
+		{"quarter", 1, 4, percent(25)},
+		{"all", 2, 2, percent(100)},
+	}
+
+	for _, test := range tests {
+		if got := percentCell(test.part, test.total); got != test.want {
+			t.Errorf("%s: percentCell(%d, %d) = %v, want %v", test.name, test.part, test.total, got, test.want)
+		}
+	}
+}
+
+func TestCountPRs(t *testing.T) {
+	created := testStart.AddDate(0, 0, 2)
+
+	tests := []struct {
+		name      string
+		prs       []pullRequest
+		want      prCounts
+		mergeRate interface{}
+	}{
+		{
+			name:      "no PRs",
+			want:      prCounts{},
+			mergeRate: notApplicable,
+		},
+		{
+			name: "all closed unmerged",
+			prs: []pullRequest{
+				closedPR("ana", created, created.AddDate(0, 0, 1)),
+				closedPR("ana", created, created.AddDate(0, 0, 2)),
+			},
+			want:      prCounts{prs: 2, closedUnmerged: 2, addedLines: 20, removedLines: 8, changedFiles: 4},
+			mergeRate: percent(0),
+		},
+		{
+			name: "merged after the end date",
+			prs: []pullRequest{
+				mergedPR("ana", created, testEnd.AddDate(0, 0, 1)),
+				mergedPR("ana", created, created.AddDate(0, 0, 1)),
+			},
+			want:      prCounts{prs: 2, merged: 1, open: 1, addedLines: 20, removedLines: 8, changedFiles: 4},
+			mergeRate: percent(50),
+		},
+		{
+			name: "closed after the end date",
+			prs: []pullRequest{
+				closedPR("ana", created, testEnd.AddDate(0, 0, 1)),
+			},
+			want:      prCounts{prs: 1, open: 1, addedLines: 10, removedLines: 4, changedFiles: 2},
+			mergeRate: percent(0),
+		},
+	}
+
+	for _, test := range tests {
+		got := countPRs(test.prs, testEnd)
+		if got != test.want {
+			t.Errorf("%s: countPRs = %+v, want %+v", test.name, got, test.want)
+		}
+		if rate := unflagged(got.mergeRateCell()); rate != test.mergeRate {
+			t.Errorf("%s: mergeRateCell = %v, want %v", test.name, rate, test.mergeRate)
+		}
+	}
+}
+
+func TestGithubSectionsFooter(t *testing.T) {
+	created := testStart.AddDate(0, 0, 2)
+
+	tests := []struct {
+		name       string
+		prs        []pullRequest
+		footer     map[int]interface{}
+		medianRate string
+	}{
+		{
+			name:       "no users",
+			medianRate: "",
+		},
+		{
+			name: "PRs created after the end date",
+			prs: []pullRequest{
+				testPR("ana", testEnd.AddDate(0, 0, 1)),
+			},
+			medianRate: "",
+		},
+		{
+			name: "all closed unmerged",
+			prs: []pullRequest{
+				closedPR("ana", created, created.AddDate(0, 0, 1)),
+				closedPR("bob", created, created.AddDate(0, 0, 1)),
+			},
+			footer:     map[int]interface{}{2: average(1), 3: average(0), 6: average(10), 7: average(4), 8: average(2)},
+			medianRate: "0.0",
+		},
+		{
+			name: "merged after the end date",
+			prs: []pullRequest{
+				mergedPR("ana", created, created.AddDate(0, 0, 1)),
+				mergedPR("ana", created, testEnd.AddDate(0, 0, 1)),
+				mergedPR("bob", created, testEnd.AddDate(0, 0, 1)),
+			},
+			footer:     map[int]interface{}{2: average(1.5), 3: average(0.5), 6: average(15), 7: average(6), 8: average(3)},
+			medianRate: "25.0",
+		},
+	}
+
+	for _, test := range tests {
+		section := githubSections(test.prs, testStart, testEnd, false, overlays{})[0]
+
+		if test.footer == nil && section.Footer != nil {
+			t.Errorf("%s: footer %v, want none", test.name, section.Footer)
+		}
+		for column, want := range test.footer {
+			if got := section.Footer[column]; got != want {
+				t.Errorf("%s: footer %s = %v, want %v", test.name, section.Header[column], got, want)
+			}
+		}
+
+		if got := section.Outputs["github_median_merge_rate"]; got != test.medianRate {
+			t.Errorf("%s: github_median_merge_rate = %q, want %q", test.name, got, test.medianRate)
+		}
+	}
+}
+
+func TestStateAt(t *testing.T) {
+	created := testStart.AddDate(0, 0, 2)
+	merged := created.AddDate(0, 0, 3)
+
+	event := func(pr pullRequest, typename string, at time.Time) pullRequest {
+		var item timelineItem
+		item.Typename = typename
+		switch typename {
+		case "ClosedEvent":
+			item.ClosedEvent.CreatedAt = at
+		case "ReopenedEvent":
+			item.ReopenedEvent.CreatedAt = at
+		case "MergedEvent":
+			item.MergedEvent.CreatedAt = at
+		}
+		pr.TimelineItems.Nodes = append(pr.TimelineItems.Nodes, item)
+
+		return pr
+	}
+
+	// Reopened and closed again after the date, past the last events fetched.
+	truncated := testPR("ana", created)
+	truncated.StateEvents.Nodes = event(event(testPR("ana", created), "ReopenedEvent", testEnd.AddDate(0, 0, 1)), "ClosedEvent", testEnd.AddDate(0, 0, 2)).TimelineItems.Nodes
+	truncated.StateEvents.PageInfo.HasPreviousPage = true
+	truncated.Closed, truncated.ClosedAt = true, testEnd.AddDate(0, 0, 2)
+
+	closedAndReopened := event(event(testPR("ana", created), "ClosedEvent", created.AddDate(0, 0, 1)), "ReopenedEvent", testEnd.AddDate(0, 0, 1))
+	closedAndReopened.Closed, closedAndReopened.ClosedAt = false, time.Time{}
+
+	tests := []struct {
+		name string
+		pr   pullRequest
+		date time.Time
+		want string
+	}{
+		{"created after the date", testPR("ana", testEnd.AddDate(0, 0, 1)), testEnd, ""},
+		{"open", testPR("ana", created), testEnd, prOpen},
+		{"merged", mergedPR("ana", created, merged), testEnd, prMerged},
+		{"merged after the date", mergedPR("ana", created, merged), created.AddDate(0, 0, 1), prOpen},
+		{"merge past the fetched timeline", event(mergedPR("ana", created, merged), "ClosedEvent", created.AddDate(0, 0, 1)), testEnd, prMerged},
+		{"closed", closedPR("ana", created, merged), testEnd, prClosed},
+		{"closed and reopened after the date", closedAndReopened, testEnd, prClosed},
+		{"reopened by the date", closedAndReopened, testEnd.AddDate(0, 0, 2), prOpen},
+		{"closed before the fetched events", truncated, testEnd, prClosed},
+		{"reopened in the fetched events", truncated, testEnd.AddDate(0, 0, 1), prOpen},
+		{"closed again in the fetched events", truncated, testEnd.AddDate(0, 0, 2), prClosed},
+	}
+
+	for _, test := range tests {
+		if got := test.pr.stateAt(test.date); got != test.want {
+			t.Errorf("%s: stateAt = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
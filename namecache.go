@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The names of the GitHub users seldom change, so the ones looked up are kept
+// in NAME_CACHE_FILE for NAME_CACHE_TTL across the runs, behind the names of
+// the run in memory. NAME_CACHE_FILE=off disables it.
+type cachedName struct {
+	Name     string
+	CachedAt time.Time
+}
+
+var (
+	nameCache      map[string]cachedName
+	nameCacheOnce  sync.Once
+	nameCacheMutex sync.Mutex
+	// Names looked up since the cache was last saved.
+	nameCacheDirty bool
+)
+
+func nameCachePath() string {
+	path := getenv("NAME_CACHE_FILE")
+	if path == "off" {
+		return ""
+	}
+	if path != "" {
+		return path
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "pull-metrics", "names.json")
+}
+
+// The logins of github.com and of GitHub Enterprise Server are different
+// people.
+func nameCacheKey(login string) string {
+	return githubApiUrl() + " " + login
+}
+
+func loadNameCache() {
+	nameCache = make(map[string]cachedName)
+
+	path := nameCachePath()
+	if path == "" {
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(content, &nameCache); err != nil {
+		fmt.Fprintf(progress, "Ignoring the invalid name cache %s: %v\n", path, err)
+		nameCache = make(map[string]cachedName)
+	}
+}
+
+func cachedNameOf(login string) (string, bool) {
+	nameCacheOnce.Do(loadNameCache)
+
+	nameCacheMutex.Lock()
+	defer nameCacheMutex.Unlock()
+
+	cached, ok := nameCache[nameCacheKey(login)]
+	if !ok || time.Since(cached.CachedAt) > envDuration("NAME_CACHE_TTL", 30*24*time.Hour) {
+		return "", false
+	}
+
+	return cached.Name, true
+}
+
+// Keeps the name for saveCachedNames, once the fetch looked up all of them.
+func cacheName(login, name string) {
+	nameCacheOnce.Do(loadNameCache)
+
+	nameCacheMutex.Lock()
+	defer nameCacheMutex.Unlock()
+
+	nameCache[nameCacheKey(login)] = cachedName{Name: name, CachedAt: time.Now()}
+	nameCacheDirty = true
+}
+
+// Writes the names looked up since the last call.
+func saveCachedNames() {
+	path := nameCachePath()
+	if path == "" {
+		return
+	}
+
+	nameCacheMutex.Lock()
+	defer nameCacheMutex.Unlock()
+
+	if nameCacheDirty {
+		saveNameCache(path)
+		nameCacheDirty = false
+	}
+}
+
+// Removes the forgotten people from the cache, by login or by name, whichever
+// GitHub host they were looked up on.
+func forgetCachedNames(hashes map[string]bool) {
+	nameCacheOnce.Do(loadNameCache)
+
+	path := nameCachePath()
+	if path == "" {
+		return
+	}
+
+	nameCacheMutex.Lock()
+	defer nameCacheMutex.Unlock()
+
+	changed := false
+	for key, cached := range nameCache {
+		_, login, _ := strings.Cut(key, " ")
+		if hashes[identityHash(login)] || (cached.Name != "" && hashes[identityHash(cached.Name)]) {
+			delete(nameCache, key)
+			changed = true
+		}
+	}
+
+	if changed {
+		saveNameCache(path)
+	}
+}
+
+// Called with nameCacheMutex held.
+func saveNameCache(path string) {
+	content, err := json.MarshalIndent(nameCache, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o700)
+	}
+	if err == nil {
+		err = os.WriteFile(path, content, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintf(progress, "Error saving the name cache %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// How the numbers of the tables are written. JSON and CSV keep the raw
+// numbers.
+type numberFormat struct {
+	thousands string
+	precision int
+	durations string
+}
+
+const (
+	durationDays  = "days"
+	durationHours = "hours"
+)
+
+// THOUSANDS_SEPARATOR, PERCENT_PRECISION and DURATION_FORMAT set the defaults,
+// and COLUMN_FORMATS overrides them per column.
+var numberFormats = struct {
+	defaults numberFormat
+	columns  map[string]numberFormat
+}{defaults: numberFormat{precision: 1, durations: durationDays}}
+
+// COLUMN_FORMATS is a list of column=format separated by semicolons, e.g.
+// "Merged PRs (%)=0;Median response=hours;Added lines=plain". A format is a
+// comma-separated list of a precision for the percentages and averages, hours
+// or days for the durations, and plain to leave out the thousands separator.
+func loadNumberFormats() {
+	defaults := numberFormat{
+		thousands: getenv("THOUSANDS_SEPARATOR"),
+		precision: 1,
+		durations: durationDays,
+	}
+
+	if value := getenv("PERCENT_PRECISION"); value != "" {
+		precision, err := strconv.Atoi(value)
+		if err != nil || precision < 0 {
+			fatalf(exitConfig, "Invalid PERCENT_PRECISION %q: expected the number of decimals", value)
+		}
+		defaults.precision = precision
+	}
+
+	if value := getenv("DURATION_FORMAT"); value != "" {
+		if value != durationDays && value != durationHours {
+			fatalf(exitConfig, "Invalid DURATION_FORMAT %q: expected days or hours", value)
+		}
+		defaults.durations = value
+	}
+
+	columns := make(map[string]numberFormat)
+	for _, entry := range strings.Split(getenv("COLUMN_FORMATS"), ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		column, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			fatalf(exitConfig, "Invalid COLUMN_FORMATS entry %q: expected column=format", entry)
+		}
+
+		format := defaults
+		for _, option := range strings.Split(spec, ",") {
+			option = strings.TrimSpace(option)
+			switch option {
+			case durationDays, durationHours:
+				format.durations = option
+			case "plain":
+				format.thousands = ""
+			default:
+				precision, err := strconv.Atoi(option)
+				if err != nil || precision < 0 {
+					fatalf(exitConfig, "Invalid format %q of %s in COLUMN_FORMATS: expected a precision, hours, days or plain", option, column)
+				}
+				format.precision = precision
+			}
+		}
+		columns[strings.TrimSpace(column)] = format
+	}
+
+	numberFormats.defaults = defaults
+	numberFormats.columns = columns
+}
+
+// Inserts the separator every three digits of the integer part.
+func groupThousands(number, separator string) string {
+	if separator == "" {
+		return number
+	}
+
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+
+	integer, fraction := number, ""
+	if i := strings.IndexAny(number, ".,"); i >= 0 && number[i:i+1] == currentLocale.decimal {
+		integer, fraction = number[:i], number[i:]
+	}
+
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(separator)
+		}
+		b.WriteRune(digit)
+	}
+
+	return sign + b.String() + fraction
+}
+
+func formatDuration(d time.Duration, format string) string {
+	if d < time.Hour || format == durationDays {
+		return duration(d).String()
+	}
+
+	return fmt.Sprintf("%dh", int(d.Round(time.Hour).Hours()))
+}
+
+func (format numberFormat) cell(value interface{}) interface{} {
+	switch v := value.(type) {
+	case flagged:
+		return flagged{format.cell(v.value), v.level}
+	case int:
+		return groupThousands(strconv.Itoa(v), format.thousands)
+	case percent:
+		return groupThousands(formatDecimal("%."+strconv.Itoa(format.precision)+"f", float64(v)), format.thousands) + "%"
+	case average:
+		return groupThousands(formatDecimal("%."+strconv.Itoa(format.precision)+"f", float64(v)), format.thousands)
+	case duration:
+		return formatDuration(time.Duration(v), format.durations)
+	}
+
+	return value
+}
+
+// The cells of the row as the tables write them, with the format of their
+// column.
+func (s *reportSection) formatRow(row table.Row) table.Row {
+	if row == nil {
+		return nil
+	}
+
+	formatted := make(table.Row, len(row))
+	for i, value := range row {
+		formatted[i] = s.formatCell(i, value)
+	}
+
+	return formatted
+}
+
+func (s *reportSection) formatCell(column int, value interface{}) interface{} {
+	format := numberFormats.defaults
+	if column < len(s.Header) {
+		if columnFormat, ok := numberFormats.columns[fmt.Sprint(s.Header[column])]; ok {
+			format = columnFormat
+		}
+	}
+
+	return format.cell(value)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type onCallShift struct {
+	identities []string
+	start      time.Time
+	end        time.Time
+}
+
+type onCallSchedule struct {
+	initialDate time.Time
+	endDate     time.Time
+	shifts      []onCallShift
+}
+
+func parseDateOrTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-1-2", value)
+	if err != nil {
+		return t, err
+	}
+
+	if endOfDay {
+		t = t.Add(time.Hour*24 - time.Second)
+	}
+
+	return t, nil
+}
+
+func loadOnCall(initialDate, endDate time.Time) *onCallSchedule {
+	schedule := &onCallSchedule{initialDate: initialDate, endDate: endDate}
+
+	if path := getenv("ONCALL_FILE"); path != "" {
+		schedule.loadFile(path)
+	}
+
+	if token := getenv("PAGERDUTY_TOKEN"); token != "" {
+		schedule.loadPagerDuty(token, strings.Split(getenv("PAGERDUTY_SCHEDULES"), ","))
+	}
+
+	if len(schedule.shifts) == 0 {
+		return nil
+	}
+
+	return schedule
+}
+
+// The file has one shift per line: person,start,end. The person is matched
+// against GitHub logins, GitHub names and Jira display names.
+func (schedule *onCallSchedule) loadFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fatalf(exitConfig, "Error opening ONCALL_FILE: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		fatalf(exitConfig, "Error reading ONCALL_FILE: %v", err)
+	}
+
+	for i, record := range records {
+		if len(record) < 3 {
+			fatalf(exitConfig, "ONCALL_FILE line %d: expected person,start,end", i+1)
+		}
+
+		start, err := parseDateOrTime(strings.TrimSpace(record[1]), false)
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			fatalf(exitConfig, "ONCALL_FILE line %d: %v", i+1, err)
+		}
+
+		end, err := parseDateOrTime(strings.TrimSpace(record[2]), true)
+		if err != nil {
+			fatalf(exitConfig, "ONCALL_FILE line %d: %v", i+1, err)
+		}
+
+		schedule.shifts = append(schedule.shifts, onCallShift{
+			identities: []string{strings.ToLower(strings.TrimSpace(record[0]))},
+			start:      start,
+			end:        end,
+		})
+	}
+}
+
+func (schedule *onCallSchedule) loadPagerDuty(token string, scheduleIds []string) {
+	authorize := func(req *http.Request) {
+		req.Header.Add("Authorization", "Token token="+token)
+		req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	}
+
+	query := url.Values{
+		"since":     {schedule.initialDate.Format(time.RFC3339)},
+		"until":     {schedule.endDate.Format(time.RFC3339)},
+		"include[]": {"users"},
+		"limit":     {"100"},
+	}
+	for _, id := range scheduleIds {
+		if id = strings.TrimSpace(id); id != "" {
+			query.Add("schedule_ids[]", id)
+		}
+	}
+
+	for offset := 0; ; offset += 100 {
+		query.Set("offset", strconv.Itoa(offset))
+
+		var page struct {
+			OnCalls []struct {
+				User struct {
+					Name  string
+					Email string
+				}
+				Start *time.Time
+				End   *time.Time
+			} `json:"oncalls"`
+			More bool
+		}
+
+		fmt.Fprintln(progress, "Requesting on-call shifts to PagerDuty")
+
+		if _, err := sendJSON("GET", "https://api.pagerduty.com/oncalls?"+query.Encode(), authorize, nil, &page); err != nil {
+			fatalf(exitError, "Error requesting PagerDuty on-calls: %v", err)
+		}
+
+		for _, oncall := range page.OnCalls {
+			shift := onCallShift{start: schedule.initialDate, end: schedule.endDate}
+			if oncall.Start != nil {
+				shift.start = *oncall.Start
+			}
+			if oncall.End != nil {
+				shift.end = *oncall.End
+			}
+
+			email := strings.ToLower(oncall.User.Email)
+			login, _, _ := strings.Cut(email, "@")
+			shift.identities = []string{strings.ToLower(oncall.User.Name), email, login}
+
+			schedule.shifts = append(schedule.shifts, shift)
+		}
+
+		if !page.More {
+			break
+		}
+	}
+}
+
+// Returns how long any of the given identities was on call between the dates.
+// Overlapping shifts (e.g. from several schedules) are counted once.
+func (schedule *onCallSchedule) duration(initialDate, endDate time.Time, identities ...string) time.Duration {
+	var intervals [][2]time.Time
+
+	for _, shift := range schedule.shifts {
+		if !shift.matches(identities) {
+			continue
+		}
+
+		start, end := shift.start, shift.end
+		if start.Before(initialDate) {
+			start = initialDate
+		}
+		if end.After(endDate) {
+			end = endDate
+		}
+
+		if end.After(start) {
+			intervals = append(intervals, [2]time.Time{start, end})
+		}
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i][0].Before(intervals[j][0])
+	})
+
+	var total time.Duration
+	var covered time.Time
+	for _, interval := range intervals {
+		if interval[0].Before(covered) {
+			interval[0] = covered
+		}
+		if interval[1].After(interval[0]) {
+			total += interval[1].Sub(interval[0])
+			covered = interval[1]
+		}
+	}
+
+	return total
+}
+
+func (shift onCallShift) matches(identities []string) bool {
+	for _, identity := range identities {
+		identity = strings.ToLower(identity)
+		if identity == "" {
+			continue
+		}
+
+		for _, candidate := range shift.identities {
+			if candidate == identity {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// OPTED_OUT is a comma-separated list of the GitHub logins or Jira names of the
+// people who opted out of the individual reporting. Their work still counts in
+// the team and repository tables, but no per-person table includes them, not
+// even in its totals, and nothing names them.
+func optedOutPeople() map[string]bool {
+	return loadOptedOut().people
+}
+
+// The people who opted out and the pattern finding them in a text. Every row
+// of every section asks for them, so they are built again only when OPTED_OUT
+// changes or the fetch learns a name.
+type optedOutSet struct {
+	list    string
+	names   int
+	people  map[string]bool
+	pattern *regexp.Regexp
+}
+
+var (
+	optedOut      optedOutSet
+	optedOutMutex sync.Mutex
+)
+
+func loadOptedOut() optedOutSet {
+	list := getenv("OPTED_OUT")
+	namesMutex.Lock()
+	known := len(names)
+	namesMutex.Unlock()
+
+	optedOutMutex.Lock()
+	defer optedOutMutex.Unlock()
+
+	if optedOut.people != nil && optedOut.list == list && optedOut.names == known {
+		return optedOut
+	}
+
+	people := make(map[string]bool)
+	for _, person := range statusList(list) {
+		people[strings.ToLower(person)] = true
+		if name, ok := knownName(person); ok && name != "" {
+			people[strings.ToLower(name)] = true
+		}
+	}
+
+	// The longest first, so a name wins over a login it starts with.
+	var alternatives []string
+	for _, person := range sortedKeys(people) {
+		alternatives = append(alternatives, regexp.QuoteMeta(person))
+	}
+	sort.SliceStable(alternatives, func(i, j int) bool { return len(alternatives[i]) > len(alternatives[j]) })
+
+	var pattern *regexp.Regexp
+	if len(alternatives) > 0 {
+		pattern = regexp.MustCompile(`(?i)(?:^|[^\w-])(` + strings.Join(alternatives, "|") + `)`)
+	}
+
+	optedOut = optedOutSet{list: list, names: known, people: people, pattern: pattern}
+	return optedOut
+}
+
+func isOptedOut(identity string) bool {
+	return identity != "" && optedOutPeople()[strings.ToLower(identity)]
+}
+
+const optedOutLabel = "Opted out"
+
+// A copy of the data without anything the people who opted out did: their
+// PRs, reviews, comments, review requests, issues and Jira transitions. The
+// tables with a row per person are built from it, so neither their rows nor
+// their totals, medians and charts include them, while the team and
+// repository tables still count their work.
+func (data *fetchedData) withoutOptedOut() *fetchedData {
+	people := optedOutPeople()
+	optedOut := func(identity string) bool {
+		return identity != "" && people[strings.ToLower(identity)]
+	}
+	isOptedOutAuthor := func(login string) bool {
+		return optedOut(login) || optedOut(names[login])
+	}
+
+	visible := *data
+
+	visible.prs = nil
+	for _, pr := range data.prs {
+		if isOptedOutAuthor(pr.Author.Login) {
+			continue
+		}
+
+		var reviews []pullRequestReview
+		for _, review := range pr.Reviews.Nodes {
+			if !isOptedOutAuthor(review.Author.Login) {
+				reviews = append(reviews, review)
+			}
+		}
+		pr.Reviews.Nodes = reviews
+
+		var comments []pullRequestComment
+		for _, comment := range pr.Comments.Nodes {
+			if !isOptedOutAuthor(comment.Author.Login) {
+				comments = append(comments, comment)
+			}
+		}
+		pr.Comments.Nodes = comments
+
+		var items []timelineItem
+		for _, item := range pr.TimelineItems.Nodes {
+			if item.Typename != "ReviewRequestedEvent" || !isOptedOutAuthor(item.ReviewRequestedEvent.RequestedReviewer.User.Login) {
+				items = append(items, item)
+			}
+		}
+		pr.TimelineItems.Nodes = items
+
+		visible.prs = append(visible.prs, pr)
+	}
+
+	visible.issues = nil
+	for _, issue := range data.issues {
+		if optedOut(issue.Fields.Assignee.DisplayName) || optedOut(issue.ParentAssignee) {
+			continue
+		}
+
+		histories := issue.Changelog.Histories
+		issue.Changelog.Histories = nil
+		for _, history := range histories {
+			if !optedOut(history.Author.DisplayName) {
+				issue.Changelog.Histories = append(issue.Changelog.Histories, history)
+			}
+		}
+
+		visible.issues = append(visible.issues, issue)
+	}
+
+	return &visible
+}
+
+// Whether the section names one of the people, in the identifying columns of
+// a row or in the header.
+func namesAnyOf(s *reportSection, people map[string]bool) bool {
+	columns := []int{0, 1}
+	for i, header := range s.Header {
+		if people[strings.ToLower(fmt.Sprint(header))] {
+			return true
+		}
+		if i > 1 && personColumns[fmt.Sprint(header)] {
+			columns = append(columns, i)
+		}
+	}
+
+	for _, row := range s.Rows {
+		for _, i := range columns {
+			if i < len(row) {
+				if cell, ok := row[i].(string); ok && people[strings.ToLower(cell)] {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// The sections naming the people who opted out are replaced by the ones built
+// without their data, which leave out the sections that only they were in.
+func withVisibleSections(sections, visible []*reportSection) []*reportSection {
+	people := optedOutPeople()
+	if len(people) == 0 {
+		return sections
+	}
+
+	byName := make(map[string]*reportSection)
+	for _, s := range visible {
+		byName[s.Name] = s
+	}
+
+	var kept []*reportSection
+	for _, s := range sections {
+		if !namesAnyOf(s, people) {
+			kept = append(kept, s)
+		} else if replacement, ok := byName[s.Name]; ok {
+			kept = append(kept, replacement)
+		}
+	}
+
+	return kept
+}
+
+// Replaces the people who opted out in a text, like the summary of the review
+// pairs, by optedOutLabel.
+func redactOptedOut(text string) string {
+	pattern := loadOptedOut().pattern
+	if pattern == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		// The word goes on, so it is another one. RE2 has no lookahead, and
+		// the separator after a name can be the one before the next.
+		if end < len(text) && (text[end] == '-' || text[end] == '_' || unicode.IsLetter(rune(text[end])) || unicode.IsDigit(rune(text[end]))) {
+			continue
+		}
+
+		b.WriteString(text[last:start])
+		b.WriteString(optedOutLabel)
+		last = end
+	}
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+// What is left once the per-person sections come from the data without them:
+// the rows and the list entries naming them are left out, their columns and
+// chart bars too, and they are named nowhere else.
+func hideOptedOut(sections []*reportSection) []*reportSection {
+	people := optedOutPeople()
+	if len(people) == 0 {
+		return sections
+	}
+
+	named := func(cell interface{}) bool {
+		s, ok := cell.(string)
+		return ok && people[strings.ToLower(s)]
+	}
+
+	for _, s := range sections {
+		s.Summary = redactOptedOut(s.Summary)
+		for output := range s.Outputs {
+			if redactOptedOut(output) != output {
+				delete(s.Outputs, output)
+			}
+		}
+
+		var hidden, listed []int
+		for i, column := range s.Header {
+			if i > 0 && people[strings.ToLower(fmt.Sprint(column))] {
+				hidden = append(hidden, i)
+			}
+			if i > 0 && personColumns[fmt.Sprint(column)] {
+				listed = append(listed, i)
+			}
+		}
+
+		var rows []table.Row
+		dropped := false
+		for _, row := range s.Rows {
+			mentioned := len(row) > 0 && named(row[0])
+			for _, i := range listed {
+				mentioned = mentioned || (i < len(row) && named(row[i]))
+			}
+			if mentioned {
+				dropped = true
+				continue
+			}
+
+			for i, cell := range row {
+				if text, ok := cell.(string); ok {
+					row[i] = redactOptedOut(text)
+				}
+			}
+			rows = append(rows, withoutColumns(row, hidden))
+		}
+		s.Rows = rows
+		s.Header = withoutColumns(s.Header, hidden)
+
+		// A total over the rows left would give away theirs by subtraction.
+		if dropped || len(hidden) > 0 {
+			s.Footer = nil
+		}
+
+		// The centered columns are numbered from 1.
+		var centered []int
+		for _, column := range s.Centered {
+			shift := 0
+			for _, i := range hidden {
+				if i == column-1 {
+					shift = -1
+					break
+				}
+				if i < column-1 {
+					shift++
+				}
+			}
+			if shift >= 0 {
+				centered = append(centered, column-shift)
+			}
+		}
+		s.Centered = centered
+
+		for i, chart := range s.Charts {
+			kept := barChart{Title: chart.Title}
+			for j, label := range chart.Labels {
+				if !people[strings.ToLower(label)] {
+					kept.Labels = append(kept.Labels, label)
+					kept.Values = append(kept.Values, chart.Values[j])
+				}
+			}
+			s.Charts[i] = kept
+		}
+	}
+
+	return sections
+}
+
+func withoutColumns(row table.Row, columns []int) table.Row {
+	if len(columns) == 0 {
+		return row
+	}
+
+	var kept table.Row
+	for i, cell := range row {
+		hidden := false
+		for _, column := range columns {
+			hidden = hidden || i == column
+		}
+		if !hidden {
+			kept = append(kept, cell)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	formatTable    = "table"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatCSV      = "csv"
+	formatJSON     = "json"
+)
+
+// Progress messages go to stdout, unless stdout is reserved for a machine
+// readable report.
+var progress io.Writer = &lockedWriter{w: os.Stdout}
+
+// Serializes the writes of the providers collected concurrently, so their
+// lines do not mix.
+type lockedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.w.Write(p)
+}
+
+func (r *report) hasActivity() bool {
+	for _, s := range r.Sections {
+		if len(s.Rows) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func jsonCell(cell interface{}) interface{} {
+	switch v := cell.(type) {
+	case flagged:
+		return jsonCell(v.value)
+	case int:
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	case percent, average:
+		return jsonCell(cellFloat(v))
+	case duration, time.Duration:
+		return fmt.Sprint(v)
+	}
+
+	return fmt.Sprint(cell)
+}
+
+func cellFloat(cell interface{}) float64 {
+	f, _ := cellNumber(cell)
+	return math.Round(f*10) / 10
+}
+
+type jsonSection struct {
+	Name    string                   `json:"name"`
+	Title   string                   `json:"title,omitempty"`
+	Summary string                   `json:"summary,omitempty"`
+	Columns []string                 `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+	Footer  map[string]interface{}   `json:"footer,omitempty"`
+	Outputs map[string]string        `json:"outputs,omitempty"`
+}
+
+// The reports written before schema_version have none, nor entities. The
+// integrity stays the last member, which verify strips to find the hashed
+// content.
+type jsonReport struct {
+	SchemaVersion int              `json:"schema_version,omitempty"`
+	Start         string           `json:"start"`
+	End           string           `json:"end"`
+	Activity      bool             `json:"activity"`
+	Sections      []jsonSection    `json:"sections"`
+	Entities      []event          `json:"entities,omitempty"`
+	Integrity     *reportIntegrity `json:"integrity,omitempty"`
+}
+
+func (s *reportSection) columns() []string {
+	var columns []string
+	for _, column := range s.Header {
+		columns = append(columns, fmt.Sprint(column))
+	}
+
+	return columns
+}
+
+func (s *reportSection) jsonRow(row []interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	for i, column := range s.columns() {
+		if i < len(row) {
+			values[column] = jsonCell(row[i])
+		}
+	}
+
+	return values
+}
+
+func (r *report) json() ([]byte, error) {
+	return json.MarshalIndent(r.jsonReport(), "", "  ")
+}
+
+func (r *report) jsonReport() jsonReport {
+	out := jsonReport{
+		SchemaVersion: schemaVersion,
+		Start:         r.InitialDate.Format("2006-01-02"),
+		End:           r.EndDate.Format("2006-01-02"),
+		Activity:      r.hasActivity(),
+		Sections:      []jsonSection{},
+		Entities:      r.Entities,
+	}
+
+	for _, s := range r.Sections {
+		section := jsonSection{
+			Name:    s.Name,
+			Title:   s.Title,
+			Summary: s.Summary,
+			Columns: s.columns(),
+			Rows:    []map[string]interface{}{},
+			Outputs: s.Outputs,
+		}
+
+		for _, row := range s.Rows {
+			section.Rows = append(section.Rows, s.jsonRow(row))
+		}
+
+		if s.Footer != nil {
+			section.Footer = s.jsonRow(s.Footer)
+		}
+
+		out.Sections = append(out.Sections, section)
+	}
+
+	return out
+}
+
+// One line per cell, so the sections with different columns fit in a single
+// file: section,row,column,value. The row is the first cell of the row.
+func (r *report) csv(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"section", "row", "column", "value"})
+
+	for _, s := range r.Sections {
+		columns := s.columns()
+
+		rows := s.Rows
+		if s.Footer != nil {
+			rows = append(rows[:len(rows):len(rows)], s.Footer)
+		}
+
+		for _, row := range rows {
+			for i := 1; i < len(row) && i < len(columns); i++ {
+				value := jsonCell(row[i])
+				if value == nil {
+					value = ""
+				}
+				writer.Write([]string{s.Name, fmt.Sprint(row[0]), columns[i], fmt.Sprint(value)})
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *report) htmlDocument() string {
+	title := html.EscapeString(tr("Pull metrics"))
+	return fmt.Sprintf("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title></head><body>\n<h1>%s %s - %s</h1>\n%s</body></html>\n",
+		title, title, html.EscapeString(formatDate(r.InitialDate)), html.EscapeString(formatDate(r.EndDate)), r.renderHTML())
+}
+
+// Writes the report, with its hash and signature embedded when
+// REPORT_INTEGRITY or REPORT_SIGNING_KEY is set.
+func (r *report) write(w io.Writer, format string, options terminalOptions) error {
+	if !integrityEnabled() {
+		return r.render(w, format, options)
+	}
+
+	if format == formatJSON {
+		content, err := r.signedJSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(content))
+		return err
+	}
+
+	var content bytes.Buffer
+	if err := r.render(&content, format, options); err != nil {
+		return err
+	}
+	if content.Len() > 0 && !bytes.HasSuffix(content.Bytes(), []byte("\n")) {
+		content.WriteByte('\n')
+	}
+
+	integrity := r.sign(content.Bytes())
+	if _, err := w.Write(content.Bytes()); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, integrityTrailer(format, integrity))
+	return err
+}
+
+func (r *report) render(w io.Writer, format string, options terminalOptions) error {
+	switch format {
+	case formatMarkdown:
+		_, err := io.WriteString(w, r.markdown())
+		return err
+	case formatHTML:
+		_, err := io.WriteString(w, r.htmlDocument())
+		return err
+	case formatCSV:
+		return r.csv(w)
+	case formatJSON:
+		content, err := r.json()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(content))
+		return err
+	}
+
+	if !r.hasActivity() {
+		_, err := fmt.Fprintf(w, "%s %s %s %s\n", tr("No activity between"), formatDate(r.InitialDate), tr("and"), formatDate(r.EndDate))
+		return err
+	}
+
+	r.print(w, options)
+	return nil
+}
+
+func validFormat(format string) bool {
+	switch format {
+	case formatTable, formatMarkdown, formatHTML, formatCSV, formatJSON:
+		return true
+	}
+
+	return false
+}
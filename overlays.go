@@ -0,0 +1,58 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Per-person context that is not part of the GitHub or Jira data. It is added
+// as extra columns to every per-person table and used to filter and segment
+// the metrics.
+type overlays struct {
+	initialDate   time.Time
+	endDate       time.Time
+	onCall        *onCallSchedule
+	availability  *availabilityCalendar
+	roster        *roster
+	excludeRampUp bool
+	cohorts       bool
+}
+
+func (people overlays) header(items string) table.Row {
+	var header table.Row
+
+	if people.availability != nil {
+		header = append(header, "Available days", items+" / day")
+	}
+
+	if people.onCall != nil {
+		header = append(header, "On call")
+	}
+
+	return header
+}
+
+func (people overlays) cells(items int, identities ...string) table.Row {
+	var row table.Row
+
+	if people.availability != nil {
+		days := people.availability.workingDays(people.initialDate, people.endDate, identities...)
+
+		if days > 0 {
+			row = append(row, average(days), average(float64(items)/days))
+		} else {
+			row = append(row, average(days), "")
+		}
+	}
+
+	if people.onCall != nil {
+		if d := people.onCall.duration(people.initialDate, people.endDate, identities...); d > 0 {
+			row = append(row, duration(d))
+		} else {
+			row = append(row, "")
+		}
+	}
+
+	return row
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Groups the files by their first OWNERSHIP_DEPTH directories. Files in the
+// root of the repository are grouped under the repository itself.
+func ownershipArea(repo, file string, depth int) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return repo
+	}
+
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+
+	return repo + ":" + strings.Join(parts, "/")
+}
+
+// The smallest number of people that authored more than half of the changes.
+func busFactor(changes []int, total int) int {
+	sort.Sort(sort.Reverse(sort.IntSlice(changes)))
+
+	covered := 0
+	for i, lines := range changes {
+		covered += lines
+		if covered*2 > total {
+			return i + 1
+		}
+	}
+
+	return len(changes)
+}
+
+// Uses the changed lines of the merged PRs to find the areas of the code that
+// depend on a single person.
+func ownershipSection(prs []pullRequest, endDate time.Time) *reportSection {
+	depth := envInt("OWNERSHIP_DEPTH", 2)
+	minLines := envInt("OWNERSHIP_MIN_LINES", 1)
+
+	byArea := make(map[string]map[string]int)
+	for _, pr := range prs {
+		if pr.stateAt(endDate) != prMerged {
+			continue
+		}
+
+		for _, file := range pr.Files.Nodes {
+			area := ownershipArea(pr.Repository.NameWithOwner, file.Path, depth)
+			if byArea[area] == nil {
+				byArea[area] = make(map[string]int)
+			}
+			byArea[area][pr.Author.Login] += file.Additions + file.Deletions
+		}
+	}
+
+	type areaStats struct {
+		area      string
+		total     int
+		top       string
+		topShare  percent
+		people    int
+		busFactor int
+	}
+
+	var areas []areaStats
+	concentrated := 0
+	for area, byPerson := range byArea {
+		stats := areaStats{area: area, people: len(byPerson)}
+
+		var changes []int
+		for login, lines := range byPerson {
+			stats.total += lines
+			changes = append(changes, lines)
+			if lines > byPerson[stats.top] || (lines == byPerson[stats.top] && login < stats.top) {
+				stats.top = login
+			}
+		}
+
+		if stats.total < minLines {
+			continue
+		}
+
+		stats.topShare = percent(float64(byPerson[stats.top]*100) / float64(stats.total))
+		stats.busFactor = busFactor(changes, stats.total)
+
+		if _, ok := thresholds.ownership(stats.topShare).(flagged); ok {
+			concentrated++
+		}
+
+		areas = append(areas, stats)
+	}
+
+	sort.Slice(areas, func(i, j int) bool {
+		if areas[i].topShare != areas[j].topShare {
+			return areas[i].topShare > areas[j].topShare
+		}
+		return areas[i].area < areas[j].area
+	})
+
+	section := &reportSection{
+		Name:     "Ownership",
+		Title:    "Knowledge concentration",
+		Summary:  fmt.Sprintf("%d of %d areas had more than %.0f%% of the merged changes authored by a single person", concentrated, len(areas), thresholds.maxOwnership),
+		Header:   table.Row{"Area", "Changed lines", "People", "Top contributor", "Top contributor (%)", "Bus factor"},
+		Centered: []int{2, 3, 5, 6},
+	}
+
+	for _, stats := range areas {
+		section.Rows = append(section.Rows, table.Row{
+			stats.area,
+			stats.total,
+			stats.people,
+			stats.top,
+			thresholds.ownership(stats.topShare),
+			stats.busFactor,
+		})
+	}
+
+	return section
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Who reviews whom, once per reviewer and PR. Rows are the authors, columns
+// the reviewers. Authors with a single reviewer are flagged, and so are the
+// pairs that only review each other, as possible rubber-stamping.
+func reviewPairsSection(prs []pullRequest, endDate time.Time) *reportSection {
+	counts := make(map[string]map[string]int)
+	reviewerSet := make(map[string]bool)
+
+	for _, pr := range prs {
+		author := pr.Author.Login
+
+		seen := make(map[string]bool)
+		for _, review := range pr.Reviews.Nodes {
+			reviewer := review.Author.Login
+			if reviewer == "" || reviewer == author || review.State == "PENDING" || review.SubmittedAt.After(endDate) || seen[reviewer] {
+				continue
+			}
+			seen[reviewer] = true
+			reviewerSet[reviewer] = true
+
+			if counts[author] == nil {
+				counts[author] = make(map[string]int)
+			}
+			counts[author][reviewer]++
+		}
+	}
+
+	var authors, reviewers []string
+	for author := range counts {
+		authors = append(authors, author)
+	}
+	for reviewer := range reviewerSet {
+		reviewers = append(reviewers, reviewer)
+	}
+	sort.Strings(authors)
+	sort.Strings(reviewers)
+
+	onlyReviewer := func(author string) (string, bool) {
+		if len(counts[author]) != 1 {
+			return "", false
+		}
+		for reviewer := range counts[author] {
+			return reviewer, true
+		}
+		return "", false
+	}
+
+	var single, exclusive []string
+	for _, author := range authors {
+		reviewer, ok := onlyReviewer(author)
+		if !ok {
+			continue
+		}
+
+		if back, ok := onlyReviewer(reviewer); ok && back == author {
+			if author < reviewer {
+				exclusive = append(exclusive, author+" <-> "+reviewer)
+			}
+		} else {
+			single = append(single, author)
+		}
+	}
+
+	var summary []string
+	if len(exclusive) > 0 {
+		summary = append(summary, "Only review each other: "+strings.Join(exclusive, ", "))
+	}
+	if len(single) > 0 {
+		summary = append(summary, "Reviewed by a single person: "+strings.Join(single, ", "))
+	}
+
+	section := &reportSection{
+		Name:    "Review pairs",
+		Title:   "Who reviews whom",
+		Summary: strings.Join(summary, ". "),
+		Header:  table.Row{"Author"},
+	}
+
+	for i, reviewer := range reviewers {
+		section.Header = append(section.Header, reviewer)
+		section.Centered = append(section.Centered, i+2)
+	}
+	section.Header = append(section.Header, "Reviewers")
+	section.Centered = append(section.Centered, len(reviewers)+2)
+
+	for _, author := range authors {
+		row := table.Row{author}
+
+		reviewer, onlyOne := onlyReviewer(author)
+		back, reciprocal := onlyReviewer(reviewer)
+		reciprocal = onlyOne && reciprocal && back == author
+
+		for _, column := range reviewers {
+			var cell interface{} = ""
+			if count := counts[author][column]; count > 0 {
+				cell = count
+				if reciprocal {
+					cell = flagged{count, critical}
+				}
+			}
+			row = append(row, cell)
+		}
+
+		var distinct interface{} = len(counts[author])
+		if onlyOne {
+			distinct = flagged{fmt.Sprint(len(counts[author])), warning}
+		}
+		section.Rows = append(section.Rows, append(row, distinct))
+	}
+
+	return section
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// The result of the differential.revision.search Conduit method, with the
+// reviewers attachment.
+type phabricatorRevision struct {
+	Id     int
+	Phid   string
+	Fields struct {
+		Title      string
+		Uri        string
+		AuthorPHID string
+		Status     struct {
+			Value  string
+			Closed bool
+		}
+		DateCreated  int64
+		DateModified int64
+		DateClosed   int64
+	}
+	Attachments struct {
+		Reviewers struct {
+			Reviewers []struct {
+				ReviewerPHID string
+				Status       string
+			}
+		}
+	}
+}
+
+// The result of the user.search Conduit method.
+type phabricatorUser struct {
+	Phid   string
+	Fields struct {
+		Username string
+		RealName string
+	}
+}
+
+// Reads the data of a Conduit export, as the whole answer, its result or the
+// list itself.
+func readConduitData(path string, data interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var answer struct {
+		Result struct {
+			Data json.RawMessage
+		}
+		Data json.RawMessage
+	}
+	if json.Unmarshal(content, &answer) == nil {
+		switch {
+		case answer.Result.Data != nil:
+			content = answer.Result.Data
+		case answer.Data != nil:
+			content = answer.Data
+		}
+	}
+
+	return json.Unmarshal(content, data)
+}
+
+// Maps the revision to a pull request. Phabricator does not export line counts
+// nor when each reviewer acted, so the reviews are dated when the revision was
+// last closed or modified.
+func (revision phabricatorRevision) pullRequest(logins map[string]string) pullRequest {
+	var pr pullRequest
+
+	login := func(phid string) string {
+		if login, ok := logins[phid]; ok {
+			return login
+		}
+		return phid
+	}
+
+	closedAt := time.Unix(revision.Fields.DateClosed, 0)
+	if revision.Fields.DateClosed == 0 {
+		closedAt = time.Unix(revision.Fields.DateModified, 0)
+	}
+
+	pr.Author.Login = login(revision.Fields.AuthorPHID)
+	pr.Number = revision.Id
+	pr.Url = revision.Fields.Uri
+	pr.Title = revision.Fields.Title
+	pr.CreatedAt = time.Unix(revision.Fields.DateCreated, 0)
+	pr.UpdatedAt = time.Unix(revision.Fields.DateModified, 0)
+	pr.IsDraft = revision.Fields.Status.Value == "draft"
+	pr.Repository.NameWithOwner = "Phabricator"
+
+	if revision.Fields.Status.Closed {
+		pr.Closed, pr.ClosedAt = true, closedAt
+	}
+
+	// "closed" is the published status of the older installs.
+	if status := revision.Fields.Status.Value; status == "published" || status == "closed" {
+		pr.Merged, pr.MergedAt = true, closedAt
+	}
+
+	for _, reviewer := range revision.Attachments.Reviewers.Reviewers {
+		state := ""
+		switch reviewer.Status {
+		case "accepted":
+			state = "APPROVED"
+		case "rejected":
+			state = "CHANGES_REQUESTED"
+		default:
+			continue
+		}
+
+		review := pullRequestReview{State: state, SubmittedAt: closedAt}
+		review.Author.Login = login(reviewer.ReviewerPHID)
+		pr.Reviews.Nodes = append(pr.Reviews.Nodes, review)
+	}
+
+	return pr
+}
+
+// Imports the exported revisions into the store as one snapshot per month, so
+// the history goes on after moving off Phabricator.
+func importPhabricator(s *store, args []string) {
+	flags := flag.NewFlagSet("import-phabricator", flag.ExitOnError)
+	revisionsPath := flags.String("revisions", "", "Export of differential.revision.search with the reviewers attachment")
+	usersPath := flags.String("users", "", "Export of user.search, to report usernames instead of PHIDs")
+	flags.Parse(args)
+
+	if *revisionsPath == "" {
+		fatalf(exitConfig, "pull-metrics import-phabricator --revisions <file> [--users <file>]")
+	}
+	s = requireStore(s)
+
+	var revisions []phabricatorRevision
+	if err := readConduitData(*revisionsPath, &revisions); err != nil {
+		fatalf(exitConfig, "Error reading %s: %v", *revisionsPath, err)
+	}
+
+	logins := make(map[string]string)
+	if *usersPath != "" {
+		var users []phabricatorUser
+		if err := readConduitData(*usersPath, &users); err != nil {
+			fatalf(exitConfig, "Error reading %s: %v", *usersPath, err)
+		}
+
+		for _, user := range users {
+			logins[user.Phid] = user.Fields.Username
+			rememberName(user.Fields.Username, user.Fields.RealName)
+		}
+	}
+
+	if len(revisions) == 0 {
+		fmt.Println("No revisions to import")
+		return
+	}
+
+	var prs []pullRequest
+	first, last := time.Now(), time.Time{}
+	for _, revision := range revisions {
+		pr := revision.pullRequest(logins)
+		prs = append(prs, pr)
+
+		if pr.CreatedAt.Before(first) {
+			first = pr.CreatedAt
+		}
+		if pr.CreatedAt.After(last) {
+			last = pr.CreatedAt
+		}
+	}
+
+	imported := 0
+	for month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.Local); !month.After(last); month = month.AddDate(0, 1, 0) {
+		initialDate := month.Add(-time.Nanosecond)
+		endDate := month.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+		rep := &report{
+			InitialDate: month,
+			EndDate:     endDate,
+			Sections:    githubSections(prs, initialDate, endDate, false, overlays{initialDate: month, endDate: endDate}),
+		}
+
+		if !rep.hasActivity() {
+			continue
+		}
+
+		snap, err := s.saveAt(rep, endDate.UTC(), append([]string{"import-phabricator"}, args...))
+		if err != nil {
+			log.Fatalf("Error saving the snapshot of %s: %v", month.Format("2006-01"), err)
+		}
+
+		fmt.Printf("Imported %s as %s\n", month.Format("2006-01"), snap.Id)
+		imported++
+	}
+
+	fmt.Printf("Imported %d revisions into %d snapshots\n", len(revisions), imported)
+}
@@ -0,0 +1,586 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	graphql "github.com/hasura/go-graphql-client"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"golang.org/x/oauth2"
+)
+
+type publishData struct {
+	Start string
+	End   string
+	Date  string
+	Body  string
+}
+
+func newPublishData(rep *report, body string) publishData {
+	return publishData{
+		Start: rep.InitialDate.Format("2006-01-02"),
+		End:   rep.EndDate.Format("2006-01-02"),
+		Date:  time.Now().Format("2006-01-02"),
+		Body:  body,
+	}
+}
+
+func executeTemplate(name, tmpl string, data publishData) string {
+	t, err := template.New(name).Parse(tmpl)
+	if err != nil {
+		fatalf(exitConfig, "Error parsing %s: %v", name, err)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		fatalf(exitConfig, "Error executing %s: %v", name, err)
+	}
+
+	return b.String()
+}
+
+func executeTemplateFile(name, path, fallback string, data publishData) string {
+	if path == "" {
+		return executeTemplate(name, fallback, data)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf(exitConfig, "Error reading %s: %v", name, err)
+	}
+
+	return executeTemplate(name, string(content), data)
+}
+
+func sendJSON(method, url string, authorize func(*http.Request), in, out interface{}) (int, error) {
+	return sendServiceJSON("", method, url, authorize, in, out)
+}
+
+// Sends the request with the client of the service, like GITHUB, so its
+// GITHUB_* settings apply to GitHub Enterprise Server too.
+func sendServiceJSON(service, method, url string, authorize func(*http.Request), in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewBuffer(payload)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return 0, err
+	}
+
+	authorize(req)
+	req.Header.Add("Accept", "application/json")
+	if in != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	res, err := newHTTPClient(service).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		message, _ := io.ReadAll(res.Body)
+		return res.StatusCode, fmt.Errorf("%s %s returned %s: %s", method, url, res.Status, strings.TrimSpace(string(message)))
+	}
+
+	if out != nil {
+		return res.StatusCode, json.NewDecoder(res.Body).Decode(out)
+	}
+
+	return res.StatusCode, nil
+}
+
+func publishReport(rep *report) {
+	publishToConfluence(rep)
+	publishToDocsRepo(rep)
+	publishToGithubIssue(rep)
+	publishToTeams(rep)
+}
+
+func publishToConfluence(rep *report) {
+	baseUrl := strings.TrimSuffix(getenv("CONFLUENCE_BASE_URL"), "/")
+	if baseUrl == "" {
+		return
+	}
+
+	space := getenv("CONFLUENCE_SPACE")
+	if space == "" {
+		fmt.Fprintln(progress, "CONFLUENCE_SPACE not provided. Skipping Confluence publishing.")
+		return
+	}
+
+	user := getenv("CONFLUENCE_USER")
+	token := getenv("CONFLUENCE_TOKEN")
+	authorize := func(req *http.Request) {
+		if user != "" {
+			req.SetBasicAuth(user, token)
+		} else {
+			req.Header.Add("Authorization", "Bearer "+token)
+		}
+	}
+
+	titleTemplate := getenv("CONFLUENCE_TITLE")
+	if titleTemplate == "" {
+		titleTemplate = "Pull metrics {{.Start}} - {{.End}}"
+	}
+
+	title := executeTemplate("CONFLUENCE_TITLE", titleTemplate, newPublishData(rep, ""))
+	body := executeTemplateFile("CONFLUENCE_TEMPLATE", getenv("CONFLUENCE_TEMPLATE"), "{{.Body}}", newPublishData(rep, rep.renderHTML()))
+
+	type version struct {
+		Number int `json:"number"`
+	}
+
+	type page struct {
+		Id    string `json:"id,omitempty"`
+		Type  string `json:"type"`
+		Title string `json:"title"`
+		Space struct {
+			Key string `json:"key"`
+		} `json:"space"`
+		Ancestors []struct {
+			Id string `json:"id"`
+		} `json:"ancestors,omitempty"`
+		Version *version `json:"version,omitempty"`
+		Body    struct {
+			Storage struct {
+				Value          string `json:"value"`
+				Representation string `json:"representation"`
+			} `json:"storage"`
+		} `json:"body"`
+	}
+
+	var existing struct {
+		Results []struct {
+			Id      string
+			Version version
+		}
+	}
+
+	query := url.Values{"spaceKey": {space}, "title": {title}, "expand": {"version"}}
+	if _, err := sendJSON("GET", baseUrl+"/rest/api/content?"+query.Encode(), authorize, nil, &existing); err != nil {
+		fatalf(exitError, "Error looking up the Confluence page: %v", err)
+	}
+
+	p := page{Type: "page", Title: title}
+	p.Space.Key = space
+	p.Body.Storage.Value = body
+	p.Body.Storage.Representation = "storage"
+
+	// Every run of the same period becomes a new version of the same page, so
+	// Confluence keeps the history while each period gets a page of its own.
+	if len(existing.Results) > 0 {
+		p.Id = existing.Results[0].Id
+		p.Version = &version{Number: existing.Results[0].Version.Number + 1}
+
+		if _, err := sendJSON("PUT", baseUrl+"/rest/api/content/"+p.Id, authorize, p, nil); err != nil {
+			fatalf(exitError, "Error updating the Confluence page: %v", err)
+		}
+
+		fmt.Fprintf(progress, "Updated Confluence page \"%s\"\n", title)
+		return
+	}
+
+	if parent := getenv("CONFLUENCE_PARENT_ID"); parent != "" {
+		p.Ancestors = append(p.Ancestors, struct {
+			Id string `json:"id"`
+		}{Id: parent})
+	}
+
+	if _, err := sendJSON("POST", baseUrl+"/rest/api/content", authorize, p, nil); err != nil {
+		fatalf(exitError, "Error creating the Confluence page: %v", err)
+	}
+
+	fmt.Fprintf(progress, "Created Confluence page \"%s\"\n", title)
+}
+
+func publishToDocsRepo(rep *report) {
+	repo := getenv("DOCS_REPO")
+	if repo == "" {
+		return
+	}
+
+	token := getenv("DOCS_TOKEN")
+	if token == "" {
+		token = getenv("GITHUB_TOKEN")
+	}
+
+	authorize := func(req *http.Request) {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+
+	pathTemplate := getenv("DOCS_PATH")
+	if pathTemplate == "" {
+		pathTemplate = "pull-metrics/{{.Start}}_{{.End}}.md"
+	}
+
+	path := executeTemplate("DOCS_PATH", pathTemplate, newPublishData(rep, ""))
+	content := executeTemplateFile("DOCS_TEMPLATE", getenv("DOCS_TEMPLATE"), "{{.Body}}", newPublishData(rep, rep.markdown()))
+	branch := getenv("DOCS_BRANCH")
+
+	contentsUrl := githubApiUrl() + "/repos/" + repo + "/contents/" + path
+
+	var existing struct {
+		Sha string
+	}
+
+	lookupUrl := contentsUrl
+	if branch != "" {
+		lookupUrl += "?ref=" + url.QueryEscape(branch)
+	}
+
+	if status, err := sendServiceJSON("GITHUB", "GET", lookupUrl, authorize, nil, &existing); err != nil && status != http.StatusNotFound {
+		fatalf(exitError, "Error looking up %s in %s: %v", path, repo, err)
+	}
+
+	commit := map[string]string{
+		"message": fmt.Sprintf("Pull metrics report %s", path),
+		"content": base64.StdEncoding.EncodeToString([]byte(content)),
+	}
+	if existing.Sha != "" {
+		commit["sha"] = existing.Sha
+	}
+	if branch != "" {
+		commit["branch"] = branch
+	}
+
+	if _, err := sendServiceJSON("GITHUB", "PUT", contentsUrl, authorize, commit, nil); err != nil {
+		fatalf(exitError, "Error committing %s to %s: %v", path, repo, err)
+	}
+
+	fmt.Fprintf(progress, "Committed %s to %s\n", path, repo)
+}
+
+func publishToGithubIssue(rep *report) {
+	repo := getenv("REPORT_REPO")
+	if repo == "" {
+		return
+	}
+
+	owner, name, found := strings.Cut(repo, "/")
+	if !found {
+		fatalf(exitConfig, "REPORT_REPO must be in the owner/repo form, got %q", repo)
+	}
+
+	token := getenv("REPORT_TOKEN")
+	if token == "" {
+		token = getenv("GITHUB_TOKEN")
+	}
+
+	titleTemplate := getenv("REPORT_TITLE")
+	if titleTemplate == "" {
+		titleTemplate = "Pull metrics {{.Start}} - {{.End}}"
+	}
+
+	title := executeTemplate("REPORT_TITLE", titleTemplate, newPublishData(rep, ""))
+	body := rep.markdown()
+
+	if category := getenv("REPORT_DISCUSSION_CATEGORY"); category != "" {
+		publishToGithubDiscussion(token, owner, name, category, title, body)
+		return
+	}
+
+	authorize := func(req *http.Request) {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+
+	label := getenv("REPORT_LABEL")
+	if label == "" {
+		label = "pull-metrics"
+	}
+
+	// The issue of an earlier run with the same title, in every page of the
+	// labeled ones.
+	for page := 1; ; page++ {
+		var issues []struct {
+			Number  int
+			Title   string
+			HtmlUrl string `json:"html_url"`
+		}
+
+		query := url.Values{"state": {"all"}, "labels": {label}, "per_page": {"100"}, "page": {fmt.Sprint(page)}}
+		if _, err := sendServiceJSON("GITHUB", "GET", githubApiUrl()+"/repos/"+repo+"/issues?"+query.Encode(), authorize, nil, &issues); err != nil {
+			fatalf(exitError, "Error looking up the report issue: %v", err)
+		}
+
+		for _, issue := range issues {
+			if issue.Title == title {
+				if _, err := sendServiceJSON("GITHUB", "PATCH", fmt.Sprintf("%s/repos/%s/issues/%d", githubApiUrl(), repo, issue.Number), authorize, map[string]string{"body": body}, nil); err != nil {
+					fatalf(exitError, "Error updating the report issue: %v", err)
+				}
+
+				fmt.Fprintf(progress, "Updated %s\n", issue.HtmlUrl)
+				return
+			}
+		}
+
+		if len(issues) < 100 {
+			break
+		}
+	}
+
+	var created struct {
+		HtmlUrl string `json:"html_url"`
+	}
+
+	issue := map[string]interface{}{"title": title, "body": body, "labels": []string{label}}
+	if _, err := sendServiceJSON("GITHUB", "POST", githubApiUrl()+"/repos/"+repo+"/issues", authorize, issue, &created); err != nil {
+		fatalf(exitError, "Error creating the report issue: %v", err)
+	}
+
+	fmt.Fprintf(progress, "Created %s\n", created.HtmlUrl)
+}
+
+func publishToGithubDiscussion(token, owner, name, category, title, body string) {
+	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	client := graphql.NewClient(githubGraphqlUrl(), githubOAuthClient(src))
+
+	var repoQuery struct {
+		Repository struct {
+			Id                   graphql.ID
+			DiscussionCategories struct {
+				Nodes []struct {
+					Id   graphql.ID
+					Name string
+				}
+			} `graphql:"discussionCategories(first: 100)"`
+		} `graphql:"repository(owner: $owner, name: $repo)"`
+	}
+
+	variables := map[string]interface{}{
+		"owner": owner,
+		"repo":  name,
+	}
+
+	if err := client.Query(context.Background(), &repoQuery, variables); err != nil {
+		fatalf(exitError, "Error looking up the discussion categories: %v", err)
+	}
+
+	var categoryId graphql.ID
+	for _, c := range repoQuery.Repository.DiscussionCategories.Nodes {
+		if strings.EqualFold(c.Name, category) {
+			categoryId = c.Id
+		}
+	}
+
+	if categoryId == "" {
+		fatalf(exitConfig, "Discussion category %q not found in %s/%s", category, owner, name)
+	}
+
+	var searchQuery struct {
+		Search struct {
+			Nodes []struct {
+				Discussion struct {
+					Id    graphql.ID
+					Title string
+					Url   string
+				} `graphql:"... on Discussion"`
+			}
+		} `graphql:"search(query: $query, type: DISCUSSION, first: 20)"`
+	}
+
+	variables = map[string]interface{}{
+		"query": fmt.Sprintf("repo:%s/%s in:title \"%s\"", owner, name, title),
+	}
+
+	if err := client.Query(context.Background(), &searchQuery, variables); err != nil {
+		fatalf(exitError, "Error looking up the report discussion: %v", err)
+	}
+
+	for _, node := range searchQuery.Search.Nodes {
+		if node.Discussion.Title != title {
+			continue
+		}
+
+		type UpdateDiscussionInput struct {
+			DiscussionId graphql.ID `json:"discussionId"`
+			Body         string     `json:"body"`
+		}
+
+		var mutation struct {
+			UpdateDiscussion struct {
+				Discussion struct {
+					Url string
+				}
+			} `graphql:"updateDiscussion(input: $input)"`
+		}
+
+		input := UpdateDiscussionInput{DiscussionId: node.Discussion.Id, Body: body}
+		if err := client.Mutate(context.Background(), &mutation, map[string]interface{}{"input": input}); err != nil {
+			fatalf(exitError, "Error updating the report discussion: %v", err)
+		}
+
+		fmt.Fprintf(progress, "Updated %s\n", mutation.UpdateDiscussion.Discussion.Url)
+		return
+	}
+
+	type CreateDiscussionInput struct {
+		RepositoryId graphql.ID `json:"repositoryId"`
+		CategoryId   graphql.ID `json:"categoryId"`
+		Title        string     `json:"title"`
+		Body         string     `json:"body"`
+	}
+
+	var mutation struct {
+		CreateDiscussion struct {
+			Discussion struct {
+				Url string
+			}
+		} `graphql:"createDiscussion(input: $input)"`
+	}
+
+	input := CreateDiscussionInput{
+		RepositoryId: repoQuery.Repository.Id,
+		CategoryId:   categoryId,
+		Title:        title,
+		Body:         body,
+	}
+	if err := client.Mutate(context.Background(), &mutation, map[string]interface{}{"input": input}); err != nil {
+		fatalf(exitError, "Error creating the report discussion: %v", err)
+	}
+
+	fmt.Fprintf(progress, "Created %s\n", mutation.CreateDiscussion.Discussion.Url)
+}
+
+// Teams cards are limited to 28 KB, so the card has the headline section, at
+// most teamsMaxRows of its rows, and the outputs of every section, with a
+// link to the full report at TEAMS_REPORT_URL, which can use the fields of
+// REPORT_TITLE.
+const teamsMaxRows = 15
+
+func publishToTeams(rep *report) {
+	webhookUrl := getenv("TEAMS_WEBHOOK_URL")
+	if webhookUrl == "" {
+		return
+	}
+
+	textBlock := func(text string, bold bool) map[string]interface{} {
+		block := map[string]interface{}{"type": "TextBlock", "text": text, "wrap": true}
+		if bold {
+			block["weight"] = "Bolder"
+		}
+		return block
+	}
+
+	tableRow := func(row table.Row) map[string]interface{} {
+		var cells []interface{}
+		for _, value := range row {
+			cells = append(cells, map[string]interface{}{
+				"type":  "TableCell",
+				"items": []interface{}{textBlock(fmt.Sprint(decorateCell(value, emojiCells)), false)},
+			})
+		}
+		return map[string]interface{}{"type": "TableRow", "cells": cells}
+	}
+
+	body := []interface{}{
+		map[string]interface{}{
+			"type":   "TextBlock",
+			"text":   fmt.Sprintf("%s %s - %s", tr("Pull metrics"), formatDate(rep.InitialDate), formatDate(rep.EndDate)),
+			"size":   "Large",
+			"weight": "Bolder",
+		},
+	}
+
+	if len(rep.Sections) > 0 {
+		s := rep.Sections[0]
+		if s.Title != "" {
+			body = append(body, textBlock(tr(s.Title), true))
+		}
+
+		if s.Summary != "" {
+			body = append(body, textBlock(s.Summary, false))
+		}
+
+		var columns []interface{}
+		for range s.Header {
+			columns = append(columns, map[string]interface{}{"width": 1})
+		}
+
+		rows := []interface{}{tableRow(localizedHeader(s.Header))}
+		for i, row := range s.Rows {
+			if i == teamsMaxRows {
+				break
+			}
+			rows = append(rows, tableRow(s.formatRow(row)))
+		}
+		if s.Footer != nil {
+			rows = append(rows, tableRow(localizedFooter(s.formatRow(s.Footer))))
+		}
+
+		body = append(body, map[string]interface{}{
+			"type":             "Table",
+			"columns":          columns,
+			"rows":             rows,
+			"firstRowAsHeader": true,
+		})
+
+		if len(s.Rows) > teamsMaxRows {
+			body = append(body, textBlock(fmt.Sprintf("%d more rows in the full report", len(s.Rows)-teamsMaxRows), false))
+		}
+	}
+
+	outputs := make(map[string]string)
+	for _, s := range rep.Sections {
+		for name, value := range s.Outputs {
+			outputs[name] = value
+		}
+	}
+
+	var facts []interface{}
+	for _, name := range sortedKeys(outputs) {
+		facts = append(facts, map[string]interface{}{"title": name, "value": outputs[name]})
+	}
+	if len(facts) > 0 {
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.5",
+		"msteams": map[string]interface{}{"width": "Full"},
+		"body":    body,
+	}
+
+	if reportUrl := getenv("TEAMS_REPORT_URL"); reportUrl != "" {
+		card["actions"] = []interface{}{map[string]interface{}{
+			"type":  "Action.OpenUrl",
+			"title": tr("Full report"),
+			"url":   executeTemplate("TEAMS_REPORT_URL", reportUrl, newPublishData(rep, "")),
+		}}
+	}
+
+	message := map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content":     card,
+			},
+		},
+	}
+
+	if _, err := sendJSON("POST", webhookUrl, func(*http.Request) {}, message, nil); err != nil {
+		fatalf(exitError, "Error posting the report to Teams: %v", err)
+	}
+
+	fmt.Fprintln(progress, "Posted the report to Teams")
+}
@@ -2,89 +2,217 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"context"
 	"time"
 	"sort"
-	"bytes"
 	"strings"
 	"flag"
 	"encoding/json"
+	"sync"
 
 	"net/http"
+	"net/url"
 	"encoding/base64"
 
-	"github.com/joho/godotenv"
-
-	"golang.org/x/oauth2"
 	graphql "github.com/hasura/go-graphql-client"
 
 	"github.com/jedib0t/go-pretty/v6/table"
-	"github.com/jedib0t/go-pretty/v6/text"
 )
 
 var client *graphql.Client
 
-func getNameById(login string)string {
-	var query struct {
+type pullRequest struct {
+	Id string
+	Author struct {
+		Login string
 		User struct {
-			Name string
-		} `graphql:"user(login: $login)"`
+			Id string
+		} `graphql:"... on User"`
 	}
-
-	variables := map[string]interface{} {
-		"login": login,
+	Number int
+	Url string
+	Title string
+	BaseRefName string
+	HeadRefName string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Additions int
+	Deletions int
+	ChangedFiles int
+	TotalCommentsCount int
+	Closed bool
+	ClosedAt time.Time
+	Merged bool
+	MergedAt time.Time
+	IsDraft bool
+	Repository struct {
+		Id string
+		NameWithOwner string
 	}
+	AuthorAssociation string
+	IsCrossRepository bool
+	HeadRepositoryOwner struct {
+		Login string
+	}
+	TimelineItems struct {
+		Nodes []timelineItem
+	} `graphql:"timelineItems(first: 30, itemTypes: [READY_FOR_REVIEW_EVENT, REVIEW_REQUESTED_EVENT, ASSIGNED_EVENT])"`
+	// The last closes, reopens and merge, apart so the review requests of a
+	// busy PR do not push them out.
+	StateEvents struct {
+		Nodes []timelineItem
+		PageInfo struct {
+			HasPreviousPage bool
+		}
+	} `graphql:"stateEvents: timelineItems(last: 30, itemTypes: [CLOSED_EVENT, REOPENED_EVENT, MERGED_EVENT])"`
+	Reviews struct {
+		Nodes []pullRequestReview
+	} `graphql:"reviews(first: 30)"`
+	Files struct {
+		Nodes []pullRequestFile
+	} `graphql:"files(first: $fileCount)"`
+	Comments struct {
+		Nodes []pullRequestComment
+	} `graphql:"comments(first: 30)"`
+	Labels struct {
+		Nodes []pullRequestLabel
+	} `graphql:"labels(first: 10)"`
+	ForcePushes struct {
+		TotalCount int
+	} `graphql:"forcePushes: timelineItems(first: 1, itemTypes: [HEAD_REF_FORCE_PUSHED_EVENT])"`
+	Commits struct {
+		Nodes []pullRequestCommit
+	} `graphql:"commits(last: 30)"`
+	Churn *prChurn `graphql:"-"`
+	AuthorRole string `graphql:"-"`
+	// The provider of the PR, empty for GitHub.
+	Source string `graphql:"-" json:",omitempty"`
+}
 
-	if err := client.Query(context.Background(), &query, variables); err != nil {
-		return ""
+type pullRequestReview struct {
+	Author struct {
+		Login string
+		User struct {
+			Id string
+		} `graphql:"... on User"`
 	}
+	AuthorAssociation string
+	State string
+	SubmittedAt time.Time
+	Body string
+}
 
-	return query.User.Name
+type pullRequestComment struct {
+	Author struct {
+		Login string
+		User struct {
+			Id string
+		} `graphql:"... on User"`
+	}
+	AuthorAssociation string
+	CreatedAt time.Time
+	Body string
 }
 
-func printMetricsForGithub(initialDate, endDate time.Time, printUrls bool) {
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" {
-		fmt.Println("GITHUB_TOKEN not provided. Skipping this report.")
-		return
+type pullRequestCommit struct {
+	Commit struct {
+		Parents struct {
+			TotalCount int
+		} `graphql:"parents(first: 2)"`
 	}
+}
 
-	githubOwner := os.Getenv("GITHUB_OWNER")
-	if githubOwner == "" {
-		fmt.Println("GITHUB_OWNER not provided. Skipping this report.")
-		return
+type pullRequestFile struct {
+	Path string
+	Additions int
+	Deletions int
+}
+
+// The fragments share the JSON keys, so every fragment gets the values and
+// Typename tells which one the item really is.
+type timelineItem struct {
+	Typename string `graphql:"__typename"`
+	ReadyForReviewEvent struct {
+		CreatedAt time.Time
+	} `graphql:"... on ReadyForReviewEvent"`
+	ReviewRequestedEvent struct {
+		CreatedAt time.Time
+		RequestedReviewer struct {
+			User struct {
+				Login string
+				Id string
+			} `graphql:"... on User"`
+		}
+	} `graphql:"... on ReviewRequestedEvent"`
+	AssignedEvent struct {
+		CreatedAt time.Time
+	} `graphql:"... on AssignedEvent"`
+	ClosedEvent struct {
+		CreatedAt time.Time
+	} `graphql:"... on ClosedEvent"`
+	ReopenedEvent struct {
+		CreatedAt time.Time
+	} `graphql:"... on ReopenedEvent"`
+	MergedEvent struct {
+		CreatedAt time.Time
+	} `graphql:"... on MergedEvent"`
+}
+
+var names = make(map[string]string)
+
+// The providers fill the names concurrently.
+var namesMutex sync.Mutex
+
+func knownName(login string) (string, bool) {
+	namesMutex.Lock()
+	defer namesMutex.Unlock()
+
+	name, ok := names[login]
+	return name, ok
+}
+
+// Keeps the first name found for the login.
+func rememberName(login, name string) {
+	namesMutex.Lock()
+	defer namesMutex.Unlock()
+
+	if _, ok := names[login]; !ok {
+		names[login] = name
 	}
+}
 
-	githubRepo := os.Getenv("GITHUB_REPO")
-	if githubOwner == "" {
-		fmt.Println("GITHUB_REPO not provided. Skipping this report.")
-		return
+func getNameById(login string)string {
+	if name, ok := knownName(login); ok {
+		return name
+	}
+	if name, ok := cachedNameOf(login); ok {
+		rememberName(login, name)
+		return name
 	}
 
-	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: githubToken})
-	httpClient := oauth2.NewClient(context.Background(), src)
+	var query struct {
+		User struct {
+			Name string
+		} `graphql:"user(login: $login)"`
+	}
 
-	client = graphql.NewClient("https://api.github.com/graphql", httpClient)
+	variables := map[string]interface{} {
+		"login": login,
+	}
 
-	type pullRequest struct {
-		Author struct {
-			Login string
-		}
-		Url string
-		Title string
-		CreatedAt time.Time
-		Additions int
-		Deletions int
-		ChangedFiles int
-		TotalCommentsCount int
-		Closed bool
-		ClosedAt time.Time
-		Merged bool
-		MergedAt time.Time
+	if err := client.Query(context.Background(), &query, variables); err != nil {
+		return ""
 	}
 
+	rememberName(login, query.User.Name)
+	cacheName(login, query.User.Name)
+	return query.User.Name
+}
+
+func fetchRepoPRs(githubOwner, githubRepo string, initialDate, endDate time.Time) []pullRequest {
 	var query struct {
 		Repository struct {
 			PullRequest struct {
@@ -94,29 +222,86 @@ func printMetricsForGithub(initialDate, endDate time.Time, printUrls bool) {
 					HasNextPage bool
 					EndCursor string
 				}
-			} `graphql:"pullRequests(first: 100, orderBy: {direction: DESC, field: CREATED_AT}, after: $prCursor)"`
+			} `graphql:"pullRequests(first: $pageSize, orderBy: $orderBy, after: $prCursor)"`
+			NameWithOwner string
+			RepositoryTopics struct {
+				Nodes []struct {
+					Topic struct {
+						Name string
+					}
+				}
+			} `graphql:"repositoryTopics(first: 20)"`
 		} `graphql:"repository(owner: $owner, name: $repo)"`
+		RateLimit struct {
+			Remaining int
+			ResetAt time.Time
+		}
 	}
 
 	variables := map[string]interface{}{
 		"owner":	githubOwner,
 		"repo":		githubRepo,
 		"prCursor":	(*string)(nil),
+		"pageSize":	githubPageSize(),
+		"orderBy":	githubOrder(),
+		"fileCount":	100,
 	}
 
+	// The pages are ordered by creation, or by update for --select, but imported and transferred PRs can
+	// be out of order, so the scan goes on GITHUB_OVERSCAN past the start of
+	// the window before stopping.
+	overscanUntil := initialDate.Add(-envDuration("GITHUB_OVERSCAN", 24*time.Hour))
+	var previous time.Time
+	outOfOrder, lateFound := 0, 0
+	pastWindow := false
+
 	var allPRs []pullRequest
+	warnedRename := false
 	out:
 	for {
 		if ptr, ok := variables["prCursor"].(*string); ok && ptr == nil {
-			fmt.Println("Requesting first page")
+			fmt.Fprintf(progress, "Requesting first page of %s\n", githubRepo)
 		} else {
-			fmt.Printf("Requesting page with node: %s\n", *ptr)
+			fmt.Fprintf(progress, "Requesting page of %s with node: %s\n", githubRepo, *ptr)
 		}
 
 		// This is very stupid, but we need to reset the slice before each iteration
 		query.Repository.PullRequest.Nodes = nil
 		if err := client.Query(context.Background(), &query, variables); err != nil {
-			log.Fatalf("Error in GraphQL query: %v", err)
+			// Pages of huge PRs can time out, so they are asked again in
+			// smaller pages for the rest of the repository.
+			pageSize := variables["pageSize"].(int)
+			if isGithubTimeout(err) && pageSize > minGithubPageSize {
+				variables["pageSize"] = max(pageSize/2, minGithubPageSize)
+				fmt.Fprintf(progress, "GitHub timed out, retrying with pages of %d PRs\n", variables["pageSize"])
+				continue
+			}
+
+			// The last resort are the PRs without their files, whose sizes
+			// then come from the REST API.
+			if isGithubTimeout(err) && variables["fileCount"].(int) > 0 {
+				variables["fileCount"] = 0
+				fmt.Fprintln(progress, "GitHub timed out, retrying the page without the changed files")
+				continue
+			}
+
+			failGithubQuery(err, githubRepo)
+		}
+		variables["fileCount"] = 100
+		waitForGithubBudget(query.RateLimit.Remaining, query.RateLimit.ResetAt)
+
+		// GitHub follows the renames and transfers, and answers the new name.
+		if !warnedRename && !strings.EqualFold(query.Repository.NameWithOwner, githubOwner + "/" + githubRepo) {
+			warnedRename = true
+			fmt.Fprintf(progress, "Warning: %s/%s was renamed or transferred to %s, its PRs are reported under the new name. Update GITHUB_REPO\n", githubOwner, githubRepo, query.Repository.NameWithOwner)
+		}
+
+		if _, ok := repoTopics[query.Repository.NameWithOwner]; !ok {
+			var topics []string
+			for _, node := range query.Repository.RepositoryTopics.Nodes {
+				topics = append(topics, node.Topic.Name)
+			}
+			repoTopics[query.Repository.NameWithOwner] = topics
 		}
 
 		if len(query.Repository.PullRequest.Nodes) == 0 {
@@ -124,14 +309,28 @@ func printMetricsForGithub(initialDate, endDate time.Time, printUrls bool) {
 		}
 
 		for _, pr := range query.Repository.PullRequest.Nodes {
+			if !previous.IsZero() && pr.scanDate().After(previous) {
+				outOfOrder++
+			}
+			previous = pr.scanDate()
+
 			if pr.CreatedAt.After(endDate) {
 				continue
 			}
 
-			if pr.CreatedAt.After(initialDate) {
+			if pr.scanDate().After(initialDate) {
+				if pastWindow {
+					lateFound++
+				}
+				if pr.sizeApproximate() {
+					githubRestSize(&pr)
+				}
 				allPRs = append(allPRs, pr)
 			} else {
-				break out
+				pastWindow = true
+				if !pr.scanDate().After(overscanUntil) {
+					break out
+				}
 			}
 		}
 
@@ -142,145 +341,481 @@ func printMetricsForGithub(initialDate, endDate time.Time, printUrls bool) {
 		variables["prCursor"] = &query.Repository.PullRequest.PageInfo.EndCursor
 	}
 
-	fmt.Printf("%d PRs were created between %v - %v\n", len(allPRs), initialDate, endDate)
+	if outOfOrder > 0 {
+		fmt.Fprintf(progress, "Warning: %d PRs of %s were not in creation order, %d of them found in the window after older ones. PRs further than GITHUB_OVERSCAN out of order may be missing\n", outOfOrder, githubRepo, lateFound)
+	}
 
-	var prByUser map[string][]pullRequest = make(map[string][]pullRequest)
+	return allPRs
+}
 
-	for _, pr := range allPRs {
-		prByUser[pr.Author.Login] = append(prByUser[pr.Author.Login], pr)
+// Creates the GraphQL client and lists the repositories of the report, or
+// returns false when GitHub is not configured.
+func connectGithub(initialDate time.Time) (string, []string, bool) {
+	if len(githubTokens()) == 0 {
+		fmt.Fprintln(progress, "GITHUB_TOKEN not provided. Skipping this report.")
+		return "", nil, false
 	}
 
-	var sortedLogins []string
-	for login, _ := range prByUser {
-		sortedLogins = append(sortedLogins, login)
+	githubOwner := getenv("GITHUB_OWNER")
+	if githubOwner == "" {
+		fmt.Fprintln(progress, "GITHUB_OWNER not provided. Skipping this report.")
+		return "", nil, false
 	}
-	sort.Strings(sortedLogins)
 
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"ID", "Name", "Total PRs", "Merged PRs", "Merged PRs (%)", "Open PRs", "Added lines" , "Removed lines", "Changed files", "URLs"})
+	var githubRepos []string
+	for _, repo := range strings.Split(getenv("GITHUB_REPO"), ",") {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			githubRepos = append(githubRepos, repo)
+		}
+	}
+	if len(githubRepos) == 0 {
+		fmt.Fprintln(progress, "GITHUB_REPO not provided. Skipping this report.")
+		return "", nil, false
+	}
 
-	fmt.Print("Parsing data ")
-	totalPRs 			:= 0
-	totalMergedPRs		:= 0
-	totalAddedLines		:= 0
-	totalRemovedLines	:= 0
-	totalChangedFiles	:= 0
-	for _, login := range sortedLogins {
-		fmt.Print(".")
+	client = graphql.NewClient(githubGraphqlUrl(), githubPoolClient())
 
-		name := getNameById(login)
+	if len(githubRepos) == 1 && githubRepos[0] == "*" {
+		githubRepos = fetchOwnerRepos(githubOwner, initialDate)
+	}
 
-		mergedPRs 		:= 0
-		openPRs			:= 0
-		addedLines 		:= 0
-		removedLines 	:= 0
-		changedFiles 	:= 0
-		urls			:= ""
-		for _, pr := range prByUser[login] {
-			addedLines 		+= pr.Additions
-			removedLines 	+= pr.Deletions
-			changedFiles 	+= pr.ChangedFiles
-
-			if pr.Merged && !pr.MergedAt.After(endDate) {
-				mergedPRs++
-			} else if !pr.Closed || pr.ClosedAt.After(endDate) {
-				openPRs++
+	return githubOwner, githubRepos, true
+}
+
+func fetchGithubPRs(initialDate, endDate time.Time) ([]pullRequest, bool) {
+	githubOwner, githubRepos, ok := connectGithub(initialDate)
+	if !ok {
+		return nil, false
+	}
+
+	// A transferred or renamed repository answers under its old name too, so
+	// the PRs are kept once per node ID.
+	var allPRs []pullRequest
+	fetched := make(map[string]bool)
+	for _, githubRepo := range githubRepos {
+		repoPRs := fetchRepoPRs(githubOwner, githubRepo, initialDate, endDate)
+		fetchChurn(githubOwner, githubRepo, repoPRs, initialDate, endDate)
+		fetchAuthorRoles(githubOwner, githubRepo, repoPRs)
+		for _, pr := range repoPRs {
+			if !fetched[pr.storeKey()] {
+				fetched[pr.storeKey()] = true
+				allPRs = append(allPRs, pr)
+			}
+		}
+	}
+
+	if len(allPRs) == 0 {
+		warnEmptyGithubResults(githubRepos)
+	}
+
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, pr := range allPRs {
+		logins := []string{pr.Author.Login}
+		for _, request := range pr.reviewRequests() {
+			logins = append(logins, request.reviewer)
+		}
+
+		for _, login := range logins {
+			if _, ok := knownName(login); ok || seen[login] {
+				continue
+			}
+			seen[login] = true
+
+			if name, ok := cachedNameOf(login); ok {
+				rememberName(login, name)
+			} else {
+				unknown = append(unknown, login)
 			}
+		}
+	}
+
+	fmt.Fprintf(progress, "Requesting the names of %d GitHub users\n", len(unknown))
+	for _, login := range unknown {
+		getNameById(login)
+	}
+	saveCachedNames()
 
+	return allPRs, true
+}
+
+func windowPRs(prs []pullRequest, initialDate, endDate time.Time, people overlays) []pullRequest {
+	var allPRs []pullRequest
+	for _, pr := range prs {
+		if !pr.selectedIn(initialDate, endDate) {
+			continue
+		}
+
+		if people.excludeRampUp && people.roster != nil && people.roster.inRampUp(pr.CreatedAt, pr.Author.Login) {
+			continue
+		}
+
+		allPRs = append(allPRs, pr)
+	}
+
+	return allPRs
+}
+
+func githubSections(prs []pullRequest, initialDate, endDate time.Time, printUrls bool, people overlays) []*reportSection {
+	allPRs := windowPRs(prs, initialDate, endDate, people)
+
+	section := &reportSection{
+		Name: "GitHub",
+		Summary: fmt.Sprintf("%d PRs were %s between %v - %v", len(allPRs), prSelection, initialDate, endDate),
+		Header: table.Row{"ID", "Name", "Total PRs", "Merged PRs", "Merged PRs (%)", "Open PRs", "Added lines" , "Removed lines", "Changed files", "Requested reviewers / PR", "Reviewers / PR"},
+		Centered: []int{3, 4, 5, 6, 7, 8, 9, 10, 11},
+	}
+
+	section.Header = append(section.Header, people.header("PRs")...)
+	for _, metric := range derivedMetrics {
+		section.Header = append(section.Header, metric.name)
+	}
+	for column := 12; column <= len(section.Header); column++ {
+		section.Centered = append(section.Centered, column)
+	}
+
+	// The column only shows when someone outside the team contributed.
+	externalPRs := 0
+	for _, pr := range allPRs {
+		if pr.external(people) {
+			externalPRs++
+		}
+	}
+	// So is the role, when AUTHOR_ROLES read it.
+	withRoles := false
+	for _, pr := range allPRs {
+		if pr.AuthorRole != "" {
+			withRoles = true
+		}
+	}
+	if withRoles {
+		section.Header = append(section.Header, "Role")
+	}
+
+	if externalPRs > 0 {
+		section.Header = append(section.Header, "Contribution")
+		section.Summary += fmt.Sprintf(", %d of them by external contributors", externalPRs)
+	}
+
+	section.Header = append(section.Header, "URLs")
+
+	approximate := 0
+	for _, pr := range allPRs {
+		if pr.sizeApproximate() {
+			approximate++
+		}
+	}
+
+	if approximate > 0 {
+		section.Summary += fmt.Sprintf(". The changed files of %d PRs with huge diffs are incomplete, so their size is approximate", approximate)
+	}
+
+	stats := aggregate(allPRs, window{initialDate, endDate}, prSelection)
+
+	type cohortStats struct {
+		people int
+		prs int
+		mergedPRs int
+		addedLines int
+		removedLines int
+	}
+	cohorts := make(map[string]*cohortStats)
+	var cohortNames []string
+
+	var prsPerAuthor, mergeRates []float64
+	for _, user := range stats {
+		login := user.login
+		name := names[login]
+
+		counts := user.counts
+
+		mergedPRs 		:= counts.merged
+		openPRs			:= counts.open
+		addedLines 		:= counts.addedLines
+		removedLines 	:= counts.removedLines
+		changedFiles 	:= counts.changedFiles
+		urls			:= ""
+		for _, pr := range user.prs {
 			if printUrls {
+				url := pr.Url
+				if pr.sizeApproximate() {
+					url += " (size approximate)"
+				}
+
 				if urls == "" {
-					urls = pr.Url
+					urls = url
 				} else {
-					urls += "\n" + pr.Url
+					urls += "\n" + url
 				}
 			}
 		}
 
-		numPRs := len(prByUser[login])
+		numPRs := counts.prs
 
-		t.AppendRow([]interface{}{
+		row := table.Row{
 			login,
 			name,
 			numPRs,
 			mergedPRs,
-			fmt.Sprintf("%.1f%%", float64(mergedPRs*100)/float64(numPRs)),
+			counts.mergeRateCell(),
 			openPRs,
-			addedLines,
-			removedLines,
+			thresholds.prSize(addedLines, addedLines+removedLines, numPRs),
+			thresholds.prSize(removedLines, addedLines+removedLines, numPRs),
 			changedFiles,
-			urls,
-		})
-		t.AppendSeparator()
-
-		totalPRs 			+= numPRs
-		totalMergedPRs		+= mergedPRs
-		totalAddedLines		+= addedLines
-		totalRemovedLines	+= removedLines
-		totalChangedFiles	+= changedFiles
-	}
-
-	fmt.Println()
-	fmt.Println()
-
-	t.AppendFooter(table.Row{
-		"Averages",
-		"",
-		fmt.Sprintf("%.1f", float64(totalPRs)/float64(len(sortedLogins))),
-		fmt.Sprintf("%.1f", float64(totalMergedPRs)/float64(len(sortedLogins))),
-		"",
-		"",
-		fmt.Sprintf("%.1f", float64(totalAddedLines)/float64(len(sortedLogins))),
-		fmt.Sprintf("%.1f", float64(totalRemovedLines)/float64(len(sortedLogins))),
-		fmt.Sprintf("%.1f", float64(totalChangedFiles)/float64(len(sortedLogins))),
-	})
-
-    t.SetColumnConfigs([]table.ColumnConfig{
-        {Number: 3, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 4, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 5, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 6, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 7, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 8, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 9, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-    })
-	t.Render()
-}
-
-func printMetricsForJira(initialDate, endDate time.Time) {
-	jiraBaseUrl := os.Getenv("JIRA_BASE_URL")
-	if jiraBaseUrl == "" {
-		fmt.Println("JIRA_BASE_URL not provided. Skipping this report.")
-		return
+		}
+		requested, reviewing := reviewerCells(user.prs, endDate)
+		row = append(row, requested, reviewing)
+		row = append(row, people.cells(numPRs, login, name)...)
+		vars := derivedVars(counts, user.comments, user.reviewsGiven, user.reviewRequests)
+		for _, metric := range derivedMetrics {
+			row = append(row, metric.cell(vars))
+		}
+		if withRoles {
+			row = append(row, roleCell(user.prs))
+		}
+		if externalPRs > 0 {
+			row = append(row, contributionCell(user.prs, people))
+		}
+		section.Rows = append(section.Rows, append(row, urls))
+
+		prsPerAuthor = append(prsPerAuthor, float64(numPRs))
+		if rate, ok := counts.mergeRate(); ok {
+			mergeRates = append(mergeRates, float64(rate))
+		}
+
+		if people.roster != nil {
+			groups := []string{people.roster.cohort(endDate, login)}
+			if entry, ok := people.roster.lookup(login); ok && entry.seniority != "" {
+				groups = append(groups, "Seniority: " + entry.seniority)
+			}
+
+			for _, group := range groups {
+				if cohorts[group] == nil {
+					cohorts[group] = &cohortStats{}
+					cohortNames = append(cohortNames, group)
+				}
+
+				cohorts[group].people++
+				cohorts[group].prs += numPRs
+				cohorts[group].mergedPRs += mergedPRs
+				cohorts[group].addedLines += addedLines
+				cohorts[group].removedLines += removedLines
+			}
+		}
 	}
 
-	jiraUser := os.Getenv("JIRA_USER")
-	if jiraUser == "" {
-		fmt.Println("JIRA_USER not provided. Skipping this report.")
-		return
+	total := totalStats(stats).counts
+
+	section.Outputs = map[string]string{
+		"github_total_prs": fmt.Sprint(total.prs),
+		"github_merged_prs": fmt.Sprint(total.merged),
+		"github_open_prs": fmt.Sprint(total.open),
+		"github_authors": fmt.Sprint(len(stats)),
+		"github_median_prs_per_author": formatOutput(median(prsPerAuthor)),
+		"github_median_merge_rate": formatOutput(median(mergeRates)),
 	}
 
-	jiraToken := os.Getenv("JIRA_TOKEN")
-	if jiraToken == "" {
-		fmt.Println("JIRA_TOKEN not provided. Skipping this report.")
-		return
+	if len(stats) > 0 {
+		requested, reviewing := reviewerCells(allPRs, endDate)
+		section.Footer = table.Row{
+			"Averages",
+			"",
+			averageOf(total.prs, len(stats)),
+			averageOf(total.merged, len(stats)),
+			"",
+			"",
+			averageOf(total.addedLines, len(stats)),
+			averageOf(total.removedLines, len(stats)),
+			averageOf(total.changedFiles, len(stats)),
+			requested,
+			reviewing,
+		}
 	}
 
-	jiraProject := os.Getenv("JIRA_PROJECT")
-	if jiraProject == "" {
-		fmt.Println("JIRA_PROJECT not provided. Skipping this report.")
-		return
+	section.Charts = prCharts(stats, allPRs, initialDate, endDate)
+
+	sections := []*reportSection{section}
+
+	if people.cohorts && people.roster != nil {
+		sort.Strings(cohortNames)
+
+		cohortSection := &reportSection{
+			Name: "Cohorts",
+			Title: "Cohorts",
+			Header: table.Row{"Cohort", "People", "Total PRs", "PRs / person", "Merged PRs (%)", "Added lines / person", "Removed lines / person"},
+			Centered: []int{2, 3, 4, 5, 6, 7},
+		}
+
+		for _, name := range cohortNames {
+			cohort := cohorts[name]
+			cohortSection.Rows = append(cohortSection.Rows, table.Row{
+				name,
+				cohort.people,
+				cohort.prs,
+				averageOf(cohort.prs, cohort.people),
+				percentCell(cohort.mergedPRs, cohort.prs),
+				averageOf(cohort.addedLines, cohort.people),
+				averageOf(cohort.removedLines, cohort.people),
+			})
+		}
+
+		sections = append(sections, cohortSection)
 	}
 
-	type jiraReport struct {
-		Total int
-		Issues []struct {
+	sections = append(sections, reviewAssignmentSection(allPRs, endDate))
+	sections = append(sections, reviewResponseSection(allPRs, endDate))
+	sections = append(sections, reviewCoverageSections(allPRs, endDate, people.roster)...)
+	sections = append(sections, reviewerCountSection(allPRs, endDate))
+
+	if section := maintainerResponseSection(allPRs, endDate, people); section != nil {
+		sections = append(sections, section)
+	}
+
+	sections = append(sections, abandonedSections(allPRs, endDate)...)
+	sections = append(sections, churnSections(allPRs)...)
+	sections = append(sections, frictionSection(allPRs))
+	sections = append(sections, dependencySection(allPRs, endDate))
+	sections = append(sections, agingSections(prs, initialDate, endDate)...)
+	sections = append(sections, prWipSection(prs, initialDate, endDate))
+	sections = append(sections, toneSections(allPRs, endDate)...)
+
+	if people.roster != nil && people.roster.hasTeams() {
+		sections = append(sections, reviewMatrixSection(allPRs, endDate, people.roster))
+	}
+
+	sections = append(sections, reviewPairsSection(allPRs, endDate))
+
+	sections = append(sections, ownershipSection(allPRs, endDate))
+	sections = append(sections, hotspotsSections(allPRs, endDate)...)
+
+	if groups := loadRepoGroups(); groups != nil {
+		sections = append(sections, repoGroupsSection(allPRs, endDate, groups))
+	}
+
+	if services := loadServices(); services != nil {
+		sections = append(sections, servicesSection(allPRs, endDate, services))
+	}
+
+	return sections
+}
+
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+type jiraCount struct {
+	totalInProgress int
+	spikeInProgress int
+	closed int
+	entered map[string]int
+}
+
+func (count *jiraCount) add(issueType, status string) {
+	count.totalInProgress++
+
+	if issueType == "Spike" {
+		count.spikeInProgress++
+	}
+
+	if strings.EqualFold(status, "Done") || strings.EqualFold(status, "Rejected") {
+		count.closed++
+	}
+}
+
+// A move into one of the active statuses.
+func (count *jiraCount) enter(status string) {
+	if count.entered == nil {
+		count.entered = make(map[string]int)
+	}
+
+	count.entered[status]++
+}
+
+func jiraProjects() []string {
+	var projects []string
+
+	list := getenv("JIRA_PROJECTS")
+	if list == "" {
+		list = getenv("JIRA_PROJECT")
+	}
+
+	for _, project := range strings.Split(list, ",") {
+		if project = strings.TrimSpace(project); project != "" {
+			projects = append(projects, project)
+		}
+	}
+
+	return projects
+}
+
+func jiraSection(name, title, summary string, countByPerson map[string]jiraCount, people overlays) *reportSection {
+	var sortedPersons []string
+	for person := range countByPerson {
+		sortedPersons = append(sortedPersons, person)
+	}
+	sort.Strings(sortedPersons)
+
+	section := &reportSection{
+		Name: name,
+		Title: title,
+		Summary: summary,
+		Header: table.Row{"Name", "Total started"},
+	}
+
+	statuses := jiraActiveStatuses()
+	if len(statuses) > 1 {
+		for _, status := range statuses {
+			section.Header = append(section.Header, status)
+		}
+	}
+
+	section.Header = append(section.Header, "Spikes started", "Closed")
+	section.Header = append(section.Header, people.header("Started")...)
+	for column := 2; column <= len(section.Header); column++ {
+		section.Centered = append(section.Centered, column)
+	}
+
+	for _, person := range sortedPersons {
+		count := countByPerson[person]
+		row := table.Row{
+			person,
+			count.totalInProgress,
+		}
+		if len(statuses) > 1 {
+			for _, status := range statuses {
+				row = append(row, count.entered[status])
+			}
+		}
+		row = append(row, count.spikeInProgress, count.closed)
+		row = append(row, people.cells(count.totalInProgress, person)...)
+		section.Rows = append(section.Rows, row)
+	}
+
+	return section
+}
+
+type jiraIssue struct {
+	Key string
+	Fields struct {
+		Summary string
+		Project struct {
+			Key string
+		}
+		Assignee struct {
+			DisplayName string
+		}
+		IssueType struct {
+			Name string
+		}
+		Status struct {
+			Name string
+		}
+		Priority struct {
+			Name string
+		}
+		Created string
+		ResolutionDate string
+		Parent *struct {
 			Key string
 			Fields struct {
-				Summary string
-				Assignee struct {
-					DisplayName string
-				}
 				IssueType struct {
 					Name string
 				}
@@ -288,148 +823,735 @@ func printMetricsForJira(initialDate, endDate time.Time) {
 					Name string
 				}
 			}
-			Changelog struct {
-				Histories []struct {
-					Author struct {
-						DisplayName string
-					}
-					Items []struct {
-						Field string
-						ToString string
-					}
-				}
+		} `json:",omitempty"`
+	}
+	ParentAssignee string `json:",omitempty"`
+	// The tracker feeding the Jira report, empty for Jira.
+	Source string `json:",omitempty"`
+	Changelog struct {
+		Histories []struct {
+			Author struct {
+				DisplayName string
+			}
+			Created string
+			Items []struct {
+				Field string
+				FromString string
+				ToString string
 			}
 		}
 	}
+}
 
-	client := &http.Client{}
+func fetchJiraIssues(initialDate, endDate time.Time) ([]jiraIssue, bool) {
+	jiraBaseUrl, auth, jiraProject, ok := connectJira()
+	if !ok {
+		return nil, false
+	}
 
-	totalIssues := 0
-	countByPerson := make(map[string]struct{
-		totalInProgress int
-		spikeInProgress int
-		closed int
-	})
-
-	payload := `{
-		"fields": ["summary", "assignee", "issuetype", "status"],
-		"expand": ["changelog"],
-		"jql": "project = \"%s\" and status changed DURING (%s, %s) TO \"In Progress\" and issuetype not in (Epic, sub-task) ORDER BY assignee ASC",
-		"startAt": %d
-	}`
+	jql := fmt.Sprintf(`project in (%s) and %s and issuetype not in (%s) ORDER BY assignee ASC`,
+		jiraProject, jiraActiveJQL(initialDate.Format("2006-01-02"), endDate.Format("2006-01-02")), jiraExcludedTypes())
+
+	issues := searchJira(jiraBaseUrl, auth, jql)
+	issues = append(issues, searchJira(jiraBaseUrl, auth, skippedJiraJQL(jiraProject, initialDate, endDate))...)
+	if jiraRollUp() {
+		rollUpSubtasks(jiraBaseUrl, auth, issues)
+	}
+
+	// The bugs of the SLA report, when they were not found already.
+	if len(loadBugSLAs()) > 0 {
+		found := make(map[string]bool)
+		for _, issue := range issues {
+			found[issue.Key] = true
+		}
+		for _, bug := range searchJira(jiraBaseUrl, auth, bugJQL(jiraProject, initialDate, endDate)) {
+			if !found[bug.Key] {
+				issues = append(issues, bug)
+			}
+		}
+	}
+
+	return issues, true
+}
+
+// The Jira URL, the basic credentials and the quoted projects for a JQL
+// query, or false when the Jira report is skipped.
+func connectJira() (string, string, string, bool) {
+	jiraBaseUrl := getenv("JIRA_BASE_URL")
+	if jiraBaseUrl == "" {
+		fmt.Fprintln(progress, "JIRA_BASE_URL not provided. Skipping this report.")
+		return "", "", "", false
+	}
+
+	jiraUser := getenv("JIRA_USER")
+	if jiraUser == "" {
+		fmt.Fprintln(progress, "JIRA_USER not provided. Skipping this report.")
+		return "", "", "", false
+	}
+
+	jiraToken := getenv("JIRA_TOKEN")
+	if jiraToken == "" {
+		fmt.Fprintln(progress, "JIRA_TOKEN not provided. Skipping this report.")
+		return "", "", "", false
+	}
+
+	projects := jiraProjects()
+	if len(projects) == 0 {
+		fmt.Fprintln(progress, "JIRA_PROJECTS not provided. Skipping this report.")
+		return "", "", "", false
+	}
+
+	quotedProjects := make([]string, len(projects))
+	for i, project := range projects {
+		quotedProjects[i] = `"` + project + `"`
+	}
+
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(jiraUser + ":" + jiraToken))
+
+	return jiraBaseUrl, auth, strings.Join(quotedProjects, ", "), true
+}
+
+// All the pages of a JQL search, with the changelog of the issues.
+func searchJira(jiraBaseUrl, auth, jql string) []jiraIssue {
+	type jiraReport struct {
+		Total int
+		Issues []jiraIssue
+	}
+
+	client := newHTTPClient("JIRA")
+
+	var issues []jiraIssue
+
+	// A GET search, unlike a POST one, can be answered with 304 Not Modified
+	// when HTTP_CACHE_DIR keeps the previous pages.
 	offset := 0
 
 	for {
-		body := []byte(fmt.Sprintf(payload, jiraProject, initialDate.Format("2006-01-02"), endDate.Format("2006-01-02"), offset))
+		query := url.Values{
+			"fields": {"summary,assignee,issuetype,status,project,parent,priority,created,resolutiondate"},
+			"expand": {"changelog"},
+			"jql": {jql},
+			"startAt": {fmt.Sprint(offset)},
+		}
 
-		req, err := http.NewRequest("POST", jiraBaseUrl + "/rest/api/2/search", bytes.NewBuffer(body))
+		req, err := http.NewRequest("GET", jiraBaseUrl + "/rest/api/2/search?" + query.Encode(), nil)
 		if err != nil {
-			log.Fatal(err)
+			fatalf(exitError, "%v", err)
 		}
 
-		auth := jiraUser + ":" + jiraToken
-		req.Header.Add("Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(auth)))
+		req.Header.Add("Authorization", auth)
 		req.Header.Add("Accept", "application/json")
-		req.Header.Add("Content-Type", "application/json")
 
-		fmt.Println("Requesting the 50 items to JIRA")
+		fmt.Fprintln(progress, "Requesting the 50 items to JIRA")
 
 		res, err := client.Do(req)
 		if err != nil {
-			log.Fatal(err)
+			fatalf(exitError, "%v", err)
 		}
 
 		defer res.Body.Close()
 
+		if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+			fatalf(exitAuth, "JIRA rejected the credentials: %s", res.Status)
+		}
+
 		report := &jiraReport{}
 		err = json.NewDecoder(res.Body).Decode(report)
 		if err != nil {
-			log.Fatal(err)
+			fatalf(exitError, "%v", err)
+		}
+
+		issues = append(issues, report.Issues...)
+
+		offset += 50
+
+		if offset > report.Total {
+			break
 		}
+	}
+
+	return issues
+}
+
+func jiraSections(issues []jiraIssue, initialDate, endDate time.Time, byProject bool, people overlays) []*reportSection {
+	totalIssues := 0
+	countByPerson := make(map[string]jiraCount)
+	countByProject := make(map[string]map[string]jiraCount)
+
+	// The rolled up sub-tasks count once for their parent.
+	counted := make(map[string]bool)
+
+	for _, issue := range issues {
+		next:
+		for i:=len(issue.Changelog.Histories)-1; i>=0; i-- {
+			for _, item := range issue.Changelog.Histories[i].Items {
+				if item.Field == "status" && jiraActive(item.ToString) && !jiraActive(item.FromString) {
+					created, err := time.Parse(jiraTimeLayout, issue.Changelog.Histories[i].Created)
+					if err == nil && (created.Before(initialDate) || created.After(endDate)) {
+						continue
+					}
+
+					key, issueType, status, author := issue.countedAs(issue.Changelog.Histories[i].Author.DisplayName)
+					if counted[key] {
+						break next
+					}
+					counted[key] = true
 
-		totalIssues = report.Total
-		for _, issue := range report.Issues {
-			next:
-			for i:=len(issue.Changelog.Histories)-1; i>=0; i-- {
-				for _, item := range issue.Changelog.Histories[i].Items {
-					if item.Field == "status" && item.ToString == "In Progress" {
-						person := countByPerson[issue.Changelog.Histories[i].Author.DisplayName]
-						person.totalInProgress++
+					totalIssues++
 
-						if issue.Fields.IssueType.Name == "Spike" {
-							person.spikeInProgress++
+					if people.excludeRampUp && people.roster != nil && err == nil && people.roster.inRampUp(created, author) {
+						break next
+					}
+
+					person := countByPerson[author]
+					person.add(issueType, status)
+					countByPerson[author] = person
+
+					if byProject {
+						projectKey := issue.Fields.Project.Key
+						if countByProject[projectKey] == nil {
+							countByProject[projectKey] = make(map[string]jiraCount)
 						}
 
-						if strings.EqualFold(issue.Fields.Status.Name, "Done") || strings.EqualFold(issue.Fields.Status.Name, "Rejected") {
-							person.closed++
+						projectPerson := countByProject[projectKey][author]
+						projectPerson.add(issueType, status)
+						countByProject[projectKey][author] = projectPerson
+					}
+					break next
+				}
+			}
+		}
+	}
+
+	// With several active statuses, every move into each of them counts in its
+	// column, once per issue.
+	if len(jiraActiveStatuses()) > 1 {
+		entered := make(map[string]bool)
+		for _, issue := range issues {
+			for _, history := range issue.Changelog.Histories {
+				created, err := time.Parse(jiraTimeLayout, history.Created)
+				if err == nil && (created.Before(initialDate) || created.After(endDate)) {
+					continue
+				}
+
+				for _, item := range history.Items {
+					status, ok := jiraActiveStatus(item.ToString)
+					if item.Field != "status" || !ok {
+						continue
+					}
+
+					key, _, _, author := issue.countedAs(history.Author.DisplayName)
+					if entered[key+"\n"+status] {
+						continue
+					}
+					entered[key+"\n"+status] = true
+
+					if people.excludeRampUp && people.roster != nil && err == nil && people.roster.inRampUp(created, author) {
+						continue
+					}
+
+					person := countByPerson[author]
+					person.enter(status)
+					countByPerson[author] = person
+
+					if byProject {
+						projectKey := issue.Fields.Project.Key
+						if countByProject[projectKey] == nil {
+							countByProject[projectKey] = make(map[string]jiraCount)
 						}
 
-						countByPerson[issue.Changelog.Histories[i].Author.DisplayName] = person
-						break next
+						projectPerson := countByProject[projectKey][author]
+						projectPerson.enter(status)
+						countByProject[projectKey][author] = projectPerson
 					}
 				}
 			}
 		}
+	}
 
-		offset += 50
+	sections := []*reportSection{
+		jiraSection("Jira", "", fmt.Sprintf("%d tickets were moved into progress between %v - %v", totalIssues, initialDate, endDate), countByPerson, people),
+	}
 
-		if offset > report.Total {
-			break
+	var startedPerPerson []float64
+	totalClosed := 0
+	for _, count := range countByPerson {
+		startedPerPerson = append(startedPerPerson, float64(count.totalInProgress))
+		totalClosed += count.closed
+	}
+
+	sections[0].Outputs = map[string]string{
+		"jira_started_issues": fmt.Sprint(totalIssues),
+		"jira_closed_issues": fmt.Sprint(totalClosed),
+		"jira_people": fmt.Sprint(len(countByPerson)),
+		"jira_median_started_per_person": formatOutput(median(startedPerPerson)),
+	}
+
+	if byProject {
+		var projectKeys []string
+		for project := range countByProject {
+			projectKeys = append(projectKeys, project)
+		}
+		sort.Strings(projectKeys)
+
+		for _, project := range projectKeys {
+			section := jiraSection("Jira " + project, "Project " + project, "", countByProject[project], people)
+			section.Parent = "Jira"
+			sections = append(sections, section)
+		}
+	}
+
+	sections = append(sections, jiraWipSection(issues, initialDate, endDate))
+	sections = append(sections, jiraCycleTimeSection(issues, initialDate, endDate))
+	if section := bugSLASection(issues, initialDate, endDate); section != nil {
+		sections = append(sections, section)
+	}
+	sections = append(sections, jiraSkippedSections(issues, initialDate, endDate, people)...)
+
+	return sections
+}
+
+type reportOptions struct {
+	printUrls     bool
+	jiraByProject bool
+	rampUp        int
+	excludeRampUp bool
+	cohorts       bool
+	leaderboards  bool
+	velocity      bool
+	forgotten     map[string]bool
+}
+
+type fetchedData struct {
+	prs        []pullRequest
+	issues     []jiraIssue
+	githubOk   bool
+	otherPRsOk bool
+	jiraOk     bool
+	people     overlays
+	options    reportOptions
+}
+
+func loadOverlays(initialDate, endDate time.Time, options reportOptions) overlays {
+	r := loadRoster(options.rampUp)
+
+	return overlays{
+		initialDate: initialDate,
+		endDate: endDate,
+		onCall: loadOnCall(initialDate, endDate),
+		availability: loadAvailability(loadHolidays(initialDate, endDate, r)),
+		roster: r,
+		excludeRampUp: options.excludeRampUp,
+		cohorts: options.cohorts,
+	}
+}
+
+func fetchData(initialDate, endDate time.Time, options reportOptions) *fetchedData {
+	data := &fetchedData{
+		people: loadOverlays(initialDate, endDate, options),
+		options: options,
+	}
+
+	// The providers are collected concurrently, and merged in this order.
+	var githubPRs, gerritPRs, giteaPRs []pullRequest
+	var tickets, stories, tasks []jiraIssue
+	var gerritOk, giteaOk, ticketsOk, shortcutOk, asanaOk bool
+
+	providers := []struct {
+		name  string
+		fetch func() bool
+	}{
+		{"GitHub", func() bool { githubPRs, data.githubOk = fetchGithubPRs(initialDate, endDate); return data.githubOk }},
+		{"Gerrit", func() bool { gerritPRs, gerritOk = fetchGerritChanges(initialDate, endDate); return gerritOk }},
+		{"Gitea", func() bool { giteaPRs, giteaOk = fetchGiteaPRs(initialDate, endDate); return giteaOk }},
+		{"Jira", func() bool { data.issues, data.jiraOk = fetchJiraIssues(initialDate, endDate); return data.jiraOk }},
+		{"Tickets", func() bool { tickets, ticketsOk = fetchTickets(initialDate, endDate); return ticketsOk }},
+		{"Shortcut", func() bool { stories, shortcutOk = fetchShortcutStories(initialDate, endDate); return shortcutOk }},
+		{"Asana", func() bool { tasks, asanaOk = fetchAsanaTasks(initialDate, endDate); return asanaOk }},
+	}
+
+	// A failure while serving panics in the goroutine of its provider, so it is
+	// raised again here, where the refresh recovers it.
+	var failure refreshFailure
+	var failureOnce sync.Once
+
+	var wg sync.WaitGroup
+	for _, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					f, ok := r.(refreshFailure)
+					if !ok {
+						panic(r)
+					}
+					failureOnce.Do(func() { failure = f })
+				}
+			}()
+
+			start := time.Now()
+			if provider.fetch() {
+				fmt.Fprintf(progress, "%s collected in %v\n", provider.name, time.Since(start).Round(time.Millisecond))
+			}
+		}()
+	}
+	wg.Wait()
+	if failure != "" {
+		panic(failure)
+	}
+
+	fmt.Fprintln(progress)
+
+	// Gerrit changes and Gitea PRs are counted next to the GitHub ones.
+	data.prs = append(append(githubPRs, gerritPRs...), giteaPRs...)
+	data.otherPRsOk = gerritOk || giteaOk
+
+	// The tickets of TICKETS_URL, the Shortcut stories and the Asana tasks
+	// feed the Jira report.
+	data.issues = append(append(append(data.issues, tickets...), stories...), tasks...)
+	data.jiraOk = data.jiraOk || ticketsOk || shortcutOk || asanaOk
+
+	data.mergeRenames()
+	data.forget(options.forgotten)
+	data.exclude()
+	scoreComments(data.prs)
+	data.emit()
+
+	return data
+}
+
+func (data *fetchedData) sections(initialDate, endDate time.Time) []*reportSection {
+	sections := data.allSections(initialDate, endDate)
+	if len(optedOutPeople()) > 0 {
+		sections = withVisibleSections(sections, data.withoutOptedOut().allSections(initialDate, endDate))
+	}
+
+	return composeSections(icView(hideOptedOut(sections)), reportTemplate)
+}
+
+func (data *fetchedData) allSections(initialDate, endDate time.Time) []*reportSection {
+	var sections []*reportSection
+
+	people := data.people
+	people.initialDate, people.endDate = initialDate, endDate
+
+	if data.githubOk || data.otherPRsOk {
+		sections = append(sections, githubSections(data.prs, initialDate, endDate, data.options.printUrls, people)...)
+
+		if data.options.leaderboards {
+			sections = append(sections, leaderboardSection(windowPRs(data.prs, initialDate, endDate, people), endDate))
+		}
+
+		if data.options.velocity {
+			sections = append(sections, velocitySection(windowPRs(data.prs, initialDate, endDate, people), initialDate, endDate, people))
 		}
 	}
 
-	fmt.Printf("%d tickets were moved into progress between %v - %v\n", totalIssues, initialDate, endDate)
+	if data.jiraOk {
+		sections = append(sections, jiraSections(data.issues, initialDate, endDate, data.options.jiraByProject, people)...)
+	}
 
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"Name", "Total started", "Spikes started", "Closed"})
+	return sections
+}
 
-	for person, count := range countByPerson {
-		t.AppendRow([]interface{}{
-			person,
-			count.totalInProgress,
-			count.spikeInProgress,
-			count.closed,
-		})
-		t.AppendSeparator()
+// Set by --range, which replaces the dates in the arguments.
+var reportingRange string
+
+// The window of a --replay is the recorded one.
+func parseWindow(args []string) (time.Time, time.Time) {
+	if replayDir != "" {
+		return replayedWindow()
 	}
-    t.SetColumnConfigs([]table.ColumnConfig{
-        {Number: 2, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 3, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-        {Number: 4, Align: text.AlignCenter, AlignFooter: text.AlignCenter},
-    })
-	t.Render()
+
+	initialDate, endDate := requestedWindow(args)
+	saveRecordedWindow(initialDate, endDate)
+
+	return initialDate, endDate
 }
 
-func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+func requestedWindow(args []string) (time.Time, time.Time) {
+	if reportingRange != "" {
+		return rangeWindow(reportingRange)
+	}
+
+	initialDate, err := time.Parse("2006-1-2", args[0])
+	if err != nil {
+		fatalf(exitConfig, "Error parsing the time: %v", err)
 	}
 
+	endDate := time.Now()
+	if len(args) > 1 {
+		if date, err := time.Parse("2006-1-2", args[1]); err == nil {
+			endDate = date.Add(time.Hour * 24 - time.Second)
+		}
+	}
+
+	return initialDate, endDate
+}
+
+func main() {
 	printUrlsPtr := flag.Bool("urls", false, "Print URLs of the PRs")
+	jiraByProjectPtr := flag.Bool("jira-by-project", false, "Print a breakdown of the Jira report per project")
+	rampUpPtr := flag.Int("ramp-up", 90, "Length in days of the ramp-up period of new joiners in the roster")
+	excludeRampUpPtr := flag.Bool("exclude-ramp-up", false, "Exclude the work done by people in the roster during their ramp-up period")
+	cohortsPtr := flag.Bool("cohorts", false, "Print the GitHub metrics per roster cohort (new joiners, tenured, seniority)")
+	leaderboardsPtr := flag.Bool("leaderboards", false, "Print the top reviewers, fastest review turnarounds and most PRs merged")
+	velocityPtr := flag.Bool("velocity", false, "Print the merged PRs of every roster team per REPORTING_CALENDAR period over the available days of its people")
+	tuiPtr := flag.Bool("tui", false, "Browse the report interactively instead of printing it")
+	noColorPtr := flag.Bool("no-color", false, "Do not use colors to highlight the values over the thresholds")
+	failOnPtr := flag.String("fail-on", "", "Comma-separated conditions that make the run exit with an error: partial (some data could not be fetched), thresholds (a value crossed a threshold)")
+	ghaPtr := flag.Bool("gha", false, "Write the report to the GitHub Actions step summary and outputs, and annotate the values over the thresholds")
+	layoutPtr := flag.String("layout", layoutAuto, "Table layout: auto, wide, compact or cards. auto picks the first one that fits in the terminal")
+	envFilePtr := flag.String("env-file", "", "Load the configuration from this file instead of .env or $XDG_CONFIG_HOME/pull-metrics/.env")
+	explainPtr := flag.Bool("explain", false, "Print the definition and data source of the metrics and exit")
+	formatPtr := flag.String("format", formatTable, "Output format: table, markdown, html, csv or json. Progress messages go to stderr for csv and json")
+	debugHttpPtr := flag.String("debug-http", "", "Log every HTTP request and response, with the credentials redacted, to this file")
+	profilePtr := flag.String("profile", "", "Apply the variables of this profile from PROFILES_FILE or profiles.json")
+	recordPtr := flag.String("record", "", "Save the raw API responses to this directory, to reproduce the report later with --replay")
+	replayPtr := flag.String("replay", "", "Answer the API requests from a directory saved with --record instead of the network")
+	eventsPtr := flag.String("events", "", "Stream every PR, review and Jira issue as NDJSON to this file, or to stdout with -, once they are fetched and filtered")
+	flag.StringVar(&prSelection, "select", selectCreated, "Which event of a PR must fall in the window: created, merged, closed or updated")
+	templatePtr := flag.String("template", "", "Render the report through this Go text/template file instead of -format. Progress messages go to stderr")
+	chartsDirPtr := flag.String("charts-dir", "", "Also write PNG and SVG charts of the merge rate trend, the cycle time and the PRs per team to this directory")
+	icsPtr := flag.String("ics", "", "Write calendar reminders of the review requests past REVIEW_SLA and of the next report periods to this directory, one file per reviewer")
+	chartsPtr := flag.Bool("charts", false, "Draw bar charts of the PRs per author and per week below the tables")
+	viewPtr := flag.String("view", "", "Report view: manager compares the people, ic shows VIEW_PERSON against the team median only. Defaults to VIEW or manager")
+	reportTemplatePtr := flag.String("report-template", "", "Show the sections of this template of REPORT_TEMPLATES_FILE or report-templates.json, in its order")
+	flag.StringVar(&reportWindows, "windows", "", "Report several windows from a single fetch, e.g. 2024-01,2024-02 or 2024-01-01..2024-01-15, followed by their trend")
+	flag.StringVar(&reportingRange, "range", "", "Report current-period or last-period of REPORTING_CALENDAR instead of the dates in the arguments")
 	flag.Parse()
 
+	if *explainPtr {
+		printExplanation(os.Stdout)
+		return
+	}
+
+	loadEnv(*envFilePtr)
+	applyProfile(*profilePtr)
+	openDebugLog(*debugHttpPtr)
+	openRecording(*recordPtr, *replayPtr)
+	configureHTTP()
+	currentLocale = loadLocale()
+	reportTemplate = loadReportTemplate(*reportTemplatePtr)
+	reportView = loadView(*viewPtr)
+	loadNumberFormats()
+
+	if !validSelection(prSelection) {
+		fatalf(exitConfig, "Unknown selection %q, expected created, merged, closed or updated", prSelection)
+	}
+
+	if !validFormat(*formatPtr) {
+		fatalf(exitConfig, "Unknown format %q", *formatPtr)
+	}
+
+	// The report goes to stderr when the events take stdout.
+	output := io.Writer(os.Stdout)
+	if *eventsPtr == "-" {
+		output = os.Stderr
+	}
+	openEvents(*eventsPtr)
+	configureQueues()
+
+	if *formatPtr == formatCSV || *formatPtr == formatJSON || *templatePtr != "" || *eventsPtr == "-" {
+		progress = &lockedWriter{w: os.Stderr}
+	}
+
+	switch *layoutPtr {
+	case layoutAuto, layoutWide, layoutCompact, layoutCards:
+	default:
+		fatalf(exitConfig, "Unknown layout %q", *layoutPtr)
+	}
+
+	failOn := make(map[string]bool)
+	for _, condition := range strings.Split(*failOnPtr, ",") {
+		switch condition = strings.TrimSpace(condition); condition {
+		case "":
+		case "partial", "thresholds":
+			failOn[condition] = true
+		default:
+			fatalf(exitConfig, "Unknown --fail-on condition %q", condition)
+		}
+	}
+
+	snapshots := openStore(*profilePtr)
+
+	options := reportOptions{
+		printUrls: *printUrlsPtr,
+		jiraByProject: *jiraByProjectPtr,
+		rampUp: *rampUpPtr,
+		excludeRampUp: *excludeRampUpPtr,
+		cohorts: *cohortsPtr,
+		leaderboards: *leaderboardsPtr,
+		velocity: *velocityPtr,
+	}
+
+	if snapshots != nil {
+		forgotten, err := snapshots.forgotten()
+		if err != nil {
+			log.Fatalf("Error reading the forgotten people: %v", err)
+		}
+		options.forgotten = forgotten
+
+		if annotations, err = snapshots.annotations(); err != nil {
+			log.Fatalf("Error reading the annotations: %v", err)
+		}
+	}
+
 	argsTail := flag.Args()
 
-	if len(argsTail) < 1 {
-		log.Fatal("pull-metrics <start date> [<end date>]. E.g.: pull-metrics 2024-02-28 [2024-03-15]")
+	// pull-metrics report renders the data of pull-metrics fetch.
+	fromStore := false
+
+	if len(argsTail) > 0 {
+		switch argsTail[0] {
+		case "serve":
+			serve(options, snapshots)
+			return
+		case "fetch":
+			fetchCommand(snapshots, argsTail[1:], options)
+			return
+		case "report":
+			fromStore = true
+			argsTail = argsTail[1:]
+		case "login":
+			loginCommand(argsTail[1:])
+			return
+		case "healthcheck":
+			healthcheck()
+			return
+		case "verify":
+			verifyCommand(argsTail[1:])
+			return
+		case "history":
+			printHistory(snapshots)
+			return
+		case "forget":
+			forgetCommand(snapshots, argsTail[1:])
+			return
+		case "annotate":
+			annotateCommand(snapshots, argsTail[1:])
+			return
+		case "community":
+			communityReport(argsTail[1:], *formatPtr, terminalOptions{
+				colors: !*noColorPtr && os.Getenv("NO_COLOR") == "",
+				layout: *layoutPtr,
+				width: terminalWidth(),
+			}, options.forgotten)
+			return
+		case "consistency":
+			consistencyReport(argsTail[1:], *formatPtr, terminalOptions{
+				colors: !*noColorPtr && os.Getenv("NO_COLOR") == "",
+				layout: *layoutPtr,
+				width: terminalWidth(),
+			}, options)
+			return
+		case "forecast":
+			forecastCommand(snapshots, argsTail[1:], *formatPtr, terminalOptions{
+				colors: !*noColorPtr && os.Getenv("NO_COLOR") == "",
+				layout: *layoutPtr,
+				width: terminalWidth(),
+			}, options)
+			return
+		case "import-phabricator":
+			importPhabricator(snapshots, argsTail[1:])
+			return
+		case "diff":
+			if len(argsTail) != 3 {
+				fatalf(exitConfig, "pull-metrics diff <snapshot id> <snapshot id>")
+			}
+			printDiff(snapshots, argsTail[1], argsTail[2])
+			return
+		}
+	}
+
+	if len(argsTail) < 1 && reportingRange == "" && replayDir == "" && reportWindows == "" {
+		fatalf(exitConfig, "pull-metrics <start date> [<end date>] | fetch [<start date>] | report <start date> [<end date>] | serve | login github | login <variable> | healthcheck | verify <report file> | history | diff <id> <id> | forget --user <login> | annotate [<date>[..<date>] <text>] | community <start date> [<end date>] | consistency <start date> [<end date>] | forecast <weeks> | import-phabricator --revisions <file>. E.g.: pull-metrics 2024-02-28 [2024-03-15]")
+	}
+
+	var windows []labeledWindow
+	var initialDate, endDate time.Time
+	if reportWindows != "" {
+		windows = parseWindows(reportWindows)
+		initialDate, endDate = windowsSpan(windows)
+	} else {
+		initialDate, endDate = parseWindow(argsTail)
+	}
+
+	thresholds = loadThresholds()
+	derivedMetrics = loadDerivedMetrics()
+
+	var data *fetchedData
+	if fromStore {
+		data = loadFetchedData(snapshots, initialDate, endDate, options)
+	} else {
+		data = fetchData(initialDate, endDate, options)
+		publishEvents()
+	}
+
+	if *tuiPtr {
+		runTUI(initialDate, endDate, data.prs, data.sections)
+		return
+	}
+
+	var rep *report
+	if windows != nil {
+		rep = data.windowsReport(windows)
+	} else {
+		rep = &report{InitialDate: initialDate, EndDate: endDate, Sections: data.sections(initialDate, endDate)}
+	}
+	// The hooks, the uploads and the publish targets read the JSON report too.
+	rep.Entities = data.reportEntities(rep.InitialDate, rep.EndDate)
+
+	if *chartsDirPtr != "" {
+		if err := data.writeChartFiles(*chartsDirPtr, initialDate, endDate); err != nil {
+			log.Fatalf("Error writing the charts: %v", err)
+		}
+	}
+
+	if *icsPtr != "" {
+		if err := data.writeCalendars(*icsPtr, endDate); err != nil {
+			log.Fatalf("Error writing the calendar reminders: %v", err)
+		}
 	}
 
-	initialDate, err := time.Parse("2006-1-2", argsTail[0])
+	var err error
+	if *templatePtr != "" {
+		err = rep.writeTemplate(output, *templatePtr)
+	} else {
+		err = rep.write(output, *formatPtr, terminalOptions{
+			colors: !*noColorPtr && os.Getenv("NO_COLOR") == "",
+			layout: *layoutPtr,
+			width: terminalWidth(),
+			charts: *chartsPtr,
+		})
+	}
 	if err != nil {
-		log.Fatalf("Error parsing the time: %v", err)
+		log.Fatalf("Error writing the report: %v", err)
 	}
 
-	endDate := time.Now()
-	if len(argsTail) > 1 {
-		if date, err := time.Parse("2006-1-2", argsTail[1]); err == nil {
-			endDate = date.Add(time.Hour * 24 - time.Second)
+	publishReport(rep)
+	uploadReport(rep)
+
+	if snapshots != nil {
+		if _, err := snapshots.save(rep); err != nil {
+			log.Fatalf("Error saving the snapshot: %v", err)
 		}
 	}
 
-	printMetricsForGithub(initialDate, endDate, *printUrlsPtr)
+	runHooks(rep)
 
-	fmt.Println()
+	writeBadges(reportBadges(windowPRs(data.prs, initialDate, endDate, data.people), endDate))
+
+	if *ghaPtr {
+		writeGithubActionsOutputs(rep)
+	}
 
-	printMetricsForJira(initialDate, endDate)
+	if failOn["partial"] && partialFetch.Load() {
+		os.Exit(exitPartial)
+	}
+
+	if failOn["thresholds"] && rep.violatesThresholds() {
+		os.Exit(exitThresholds)
+	}
 }
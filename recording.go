@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Set by --record and --replay: the directory the raw API responses are
+// saved to, or answered from without touching the network, so a report can
+// be reproduced after the PRs are edited or deleted.
+var recordDir, replayDir string
+
+// A response saved by --record, under the hash of its request.
+type recordedResponse struct {
+	Method string
+	Url    string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type recordingTransport struct {
+	next http.RoundTripper
+}
+
+func withRecording(next http.RoundTripper) http.RoundTripper {
+	if recordDir == "" && replayDir == "" {
+		return next
+	}
+
+	return &recordingTransport{next: next}
+}
+
+func openRecording(record, replay string) {
+	if record != "" && replay != "" {
+		fatalf(exitConfig, "--record and --replay cannot be used together")
+	}
+
+	if record != "" {
+		if err := os.MkdirAll(record, 0o700); err != nil {
+			fatalf(exitConfig, "Error creating the recording directory: %v", err)
+		}
+	}
+
+	if replay != "" {
+		if _, err := os.Stat(replay); err != nil {
+			fatalf(exitConfig, "Error opening the recording: %v", err)
+		}
+	}
+
+	recordDir, replayDir = record, replay
+}
+
+// The method, URL and body identify a request: GraphQL queries all go to the
+// same URL. The credentials are left out, so any token replays a recording.
+func recordingPath(dir string, req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil && req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		if body, err = io.ReadAll(reader); err != nil {
+			return "", err
+		}
+	}
+
+	key := req.Method + "\n" + req.URL.String() + "\n" + string(body)
+	return filepath.Join(dir, sha256Hex([]byte(key))+".json"), nil
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	dir := recordDir
+	if replayDir != "" {
+		dir = replayDir
+	}
+
+	path, err := recordingPath(dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if replayDir != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s is not in the recording", req.Method, redactUrl(req.URL))
+		}
+
+		var recorded recordedResponse
+		if err := json.Unmarshal(content, &recorded); err != nil {
+			return nil, fmt.Errorf("error reading the recording of %s: %v", redactUrl(req.URL), err)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+			StatusCode:    recorded.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header,
+			Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := res.Header.Clone()
+	for name := range sensitiveHeaders {
+		header.Del(name)
+	}
+
+	content, err := json.Marshal(recordedResponse{Method: req.Method, Url: redactUrl(req.URL), Status: res.StatusCode, Header: header, Body: body})
+	if err == nil {
+		err = os.WriteFile(path, content, 0o600)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error recording %s: %v", redactUrl(req.URL), err)
+	}
+
+	return res, nil
+}
+
+// The window of the recording, which the replay reuses so that an end date
+// of now asks for the same data.
+type recordedWindow struct {
+	InitialDate time.Time
+	EndDate     time.Time
+}
+
+func saveRecordedWindow(initialDate, endDate time.Time) {
+	if recordDir == "" {
+		return
+	}
+
+	content, err := json.MarshalIndent(recordedWindow{initialDate, endDate}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(recordDir, "window.json"), content, 0o600)
+	}
+	if err != nil {
+		fatalf(exitConfig, "Error saving the window of the recording: %v", err)
+	}
+}
+
+func replayedWindow() (time.Time, time.Time) {
+	content, err := os.ReadFile(filepath.Join(replayDir, "window.json"))
+	if err != nil {
+		fatalf(exitConfig, "Error reading the window of the recording: %v", err)
+	}
+
+	var window recordedWindow
+	if err := json.Unmarshal(content, &window); err != nil {
+		fatalf(exitConfig, "Error reading the window of the recording: %v", err)
+	}
+
+	return window.InitialDate, window.EndDate
+}
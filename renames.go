@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func (data *fetchedData) mergeRenames() {
+	data.mergeRenamedLogins()
+	data.mergeRenamedRepositories()
+}
+
+// GitHub answers the current login of a user, so the PRs fetched before a
+// rename, and kept in the store, still have the old one. Every login of a node
+// ID becomes the one of its most recent activity, so a renamed person keeps a
+// single row, and a login taken over by someone else is not merged with them.
+func (data *fetchedData) mergeRenamedLogins() {
+	type activity struct {
+		login string
+		at    time.Time
+	}
+
+	latest := make(map[string]activity)
+	seen := func(id, login string, at time.Time) {
+		if id == "" || login == "" {
+			return
+		}
+		if last, ok := latest[id]; !ok || at.After(last.at) {
+			latest[id] = activity{login, at}
+		}
+	}
+
+	for _, pr := range data.prs {
+		seen(pr.Author.User.Id, pr.Author.Login, pr.UpdatedAt)
+		for _, review := range pr.Reviews.Nodes {
+			seen(review.Author.User.Id, review.Author.Login, review.SubmittedAt)
+		}
+		for _, comment := range pr.Comments.Nodes {
+			seen(comment.Author.User.Id, comment.Author.Login, comment.CreatedAt)
+		}
+		for _, item := range pr.TimelineItems.Nodes {
+			reviewer := item.ReviewRequestedEvent.RequestedReviewer.User
+			seen(reviewer.Id, reviewer.Login, item.ReviewRequestedEvent.CreatedAt)
+		}
+	}
+
+	renamed := make(map[string]bool)
+	current := func(id string, login *string) {
+		if last, ok := latest[id]; ok && id != "" && *login != last.login {
+			if !renamed[*login] {
+				renamed[*login] = true
+				fmt.Fprintf(progress, "%s was renamed %s, their PRs and reviews are merged\n", *login, last.login)
+			}
+			if name, ok := knownName(*login); ok {
+				rememberName(last.login, name)
+			}
+			*login = last.login
+		}
+	}
+
+	for i := range data.prs {
+		pr := &data.prs[i]
+		current(pr.Author.User.Id, &pr.Author.Login)
+		for j := range pr.Reviews.Nodes {
+			current(pr.Reviews.Nodes[j].Author.User.Id, &pr.Reviews.Nodes[j].Author.Login)
+		}
+		for j := range pr.Comments.Nodes {
+			current(pr.Comments.Nodes[j].Author.User.Id, &pr.Comments.Nodes[j].Author.Login)
+		}
+		for j := range pr.TimelineItems.Nodes {
+			reviewer := &pr.TimelineItems.Nodes[j].ReviewRequestedEvent.RequestedReviewer.User
+			current(reviewer.Id, &reviewer.Login)
+		}
+	}
+}
+
+// The same for the repositories renamed or transferred: the stored PRs are
+// reported under the name of the most recently updated PR of the repository
+// node ID.
+func (data *fetchedData) mergeRenamedRepositories() {
+	type activity struct {
+		name string
+		at   time.Time
+	}
+
+	latest := make(map[string]activity)
+	for _, pr := range data.prs {
+		id := pr.Repository.Id
+		if last, ok := latest[id]; id != "" && (!ok || pr.UpdatedAt.After(last.at)) {
+			latest[id] = activity{pr.Repository.NameWithOwner, pr.UpdatedAt}
+		}
+	}
+
+	renamed := make(map[string]bool)
+	for i := range data.prs {
+		repository := &data.prs[i].Repository
+		if last, ok := latest[repository.Id]; ok && repository.Id != "" && repository.NameWithOwner != last.name {
+			if !renamed[repository.NameWithOwner] {
+				renamed[repository.NameWithOwner] = true
+				fmt.Fprintf(progress, "%s was renamed or transferred to %s, its PRs are merged\n", repository.NameWithOwner, last.name)
+			}
+			repository.NameWithOwner = last.name
+		}
+	}
+}
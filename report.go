@@ -0,0 +1,251 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+type percent float64
+
+func (p percent) String() string {
+	return formatDecimal("%."+strconv.Itoa(numberFormats.defaults.precision)+"f", float64(p)) + "%"
+}
+
+type average float64
+
+func (a average) String() string {
+	return formatDecimal("%.1f", float64(a))
+}
+
+type duration time.Duration
+
+func (d duration) String() string {
+	if time.Duration(d) < time.Hour {
+		return fmt.Sprintf("%dm", int(time.Duration(d).Round(time.Minute).Minutes()))
+	}
+
+	hours := int(time.Duration(d).Round(time.Hour).Hours())
+	if hours < 24 {
+		return fmt.Sprintf("%dh", hours)
+	}
+
+	return fmt.Sprintf("%dd %dh", hours/24, hours%24)
+}
+
+type severity int
+
+const (
+	warning severity = iota + 1
+	critical
+)
+
+// A cell value that crossed one of the configured thresholds.
+type flagged struct {
+	value interface{}
+	level severity
+}
+
+func (f flagged) String() string {
+	return fmt.Sprint(f.value)
+}
+
+type cellStyle int
+
+const (
+	plainCells cellStyle = iota
+	emojiCells
+	colorCells
+)
+
+func decorateCell(value interface{}, style cellStyle) interface{} {
+	f, ok := value.(flagged)
+	if !ok || style == plainCells {
+		return value
+	}
+
+	emoji, colors := "🟡", text.Colors{text.FgYellow}
+	if f.level == critical {
+		emoji, colors = "🔴", text.Colors{text.FgRed, text.Bold}
+	}
+
+	decorated := fmt.Sprintf("%s %v", emoji, f.value)
+	if style == colorCells {
+		return colors.Sprint(decorated)
+	}
+
+	return decorated
+}
+
+func decorateRow(row table.Row, style cellStyle) table.Row {
+	decorated := make(table.Row, len(row))
+	for i, value := range row {
+		decorated[i] = decorateCell(value, style)
+	}
+
+	return decorated
+}
+
+type reportSection struct {
+	Name     string
+	Title    string
+	Summary  string
+	Header   table.Row
+	Rows     []table.Row
+	Footer   table.Row
+	Centered []int
+	Outputs  map[string]string
+	Charts   []barChart `json:"-"`
+	// The section this one splits, like Jira for the sections per project, so
+	// a template naming it takes them too.
+	Parent string `json:"-"`
+}
+
+const noActivity = "No activity in this period"
+
+type report struct {
+	InitialDate time.Time
+	EndDate     time.Time
+	Sections    []*reportSection
+	// The change requests, reviews, work items, people and teams behind the
+	// sections, for the JSON report.
+	Entities []event
+
+	signatures map[[sha256.Size]byte]reportIntegrity
+}
+
+func (s *reportSection) table(style cellStyle) table.Writer {
+	t := table.NewWriter()
+	t.AppendHeader(localizedHeader(s.Header))
+
+	for _, row := range s.Rows {
+		t.AppendRow(decorateRow(s.formatRow(row), style))
+		t.AppendSeparator()
+	}
+
+	if s.Footer != nil {
+		t.AppendFooter(localizedFooter(s.formatRow(s.Footer)))
+	}
+
+	var configs []table.ColumnConfig
+	for _, number := range s.Centered {
+		configs = append(configs, table.ColumnConfig{Number: number, Align: text.AlignCenter, AlignFooter: text.AlignCenter})
+	}
+	t.SetColumnConfigs(configs)
+
+	return t
+}
+
+type terminalOptions struct {
+	colors bool
+	layout string
+	width  int
+	charts bool
+}
+
+func (r *report) print(w io.Writer, options terminalOptions) {
+	style := emojiCells
+	if options.colors {
+		style = colorCells
+	}
+
+	for i, s := range r.Sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if s.Title != "" {
+			fmt.Fprintln(w, tr(s.Title))
+		}
+
+		if s.Summary != "" {
+			fmt.Fprintln(w, s.Summary)
+		}
+
+		if len(s.Rows) == 0 {
+			fmt.Fprintln(w, tr(noActivity))
+			continue
+		}
+
+		fmt.Fprintln(w, s.renderForTerminal(style, options))
+
+		if options.charts {
+			for _, chart := range s.Charts {
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, chart.render(options.width))
+			}
+		}
+	}
+}
+
+func (r *report) markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s %s - %s\n", tr("Pull metrics"), formatDate(r.InitialDate), formatDate(r.EndDate))
+
+	for _, s := range r.Sections {
+		fmt.Fprintln(&b)
+
+		if s.Title != "" {
+			fmt.Fprintf(&b, "## %s\n\n", tr(s.Title))
+		}
+
+		if s.Summary != "" {
+			fmt.Fprintf(&b, "%s\n\n", s.Summary)
+		}
+
+		if len(s.Rows) == 0 {
+			fmt.Fprintf(&b, "_%s_\n", tr(noActivity))
+			continue
+		}
+
+		fmt.Fprintln(&b, s.table(emojiCells).RenderMarkdown())
+
+		if definitions := definitionsFor(s); len(definitions) > 0 {
+			fmt.Fprintln(&b)
+			for _, metric := range definitions {
+				fmt.Fprintf(&b, "- **%s**: %s\n", tr(metric.column), metric.definition)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func (r *report) renderHTML() string {
+	var b strings.Builder
+
+	for _, s := range r.Sections {
+		if s.Title != "" {
+			fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(tr(s.Title)))
+		}
+
+		if s.Summary != "" {
+			fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(s.Summary))
+		}
+
+		if len(s.Rows) == 0 {
+			fmt.Fprintf(&b, "<p><em>%s</em></p>\n", html.EscapeString(tr(noActivity)))
+			continue
+		}
+
+		fmt.Fprintln(&b, s.table(emojiCells).RenderHTML())
+
+		if definitions := definitionsFor(s); len(definitions) > 0 {
+			fmt.Fprintln(&b, "<ul>")
+			for _, metric := range definitions {
+				fmt.Fprintf(&b, "<li><small><strong>%s</strong>: %s</small></li>\n", html.EscapeString(tr(metric.column)), html.EscapeString(metric.definition))
+			}
+			fmt.Fprintln(&b, "</ul>")
+		}
+	}
+
+	return b.String()
+}
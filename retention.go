@@ -0,0 +1,426 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+func retentionCutoff() (time.Time, bool) {
+	months := envInt("STORE_RETENTION_MONTHS", 0)
+	if months == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Now().AddDate(0, -months, 0), true
+}
+
+// Removes the snapshots older than STORE_RETENTION_MONTHS, and the fetched PRs
+// and issues created before then.
+func (s *store) purge() error {
+	cutoff, ok := retentionCutoff()
+	if !ok {
+		return nil
+	}
+
+	if err := s.updateFetched(func(fetched *fetchedStore) bool { return fetched.expire(cutoff) }); err != nil {
+		return err
+	}
+
+	snaps, err := s.snapshots()
+	if err != nil {
+		return err
+	}
+
+	for _, snap := range snaps {
+		if snap.CreatedAt.Before(cutoff) {
+			if err := os.Remove(s.snapshotPath(snap.Id)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Forgotten people are kept as hashes, so the list itself does not keep
+// their identity around.
+func identityHash(identity string) string {
+	return sha256Hex([]byte(strings.ToLower(strings.TrimSpace(identity))))
+}
+
+func (s *store) forgottenPath() string {
+	return filepath.Join(s.dir, "forgotten.json")
+}
+
+func (s *store) forgotten() (map[string]bool, error) {
+	hashes := make(map[string]bool)
+
+	content, err := os.ReadFile(s.forgottenPath())
+	if os.IsNotExist(err) {
+		return hashes, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var list []string
+	if err := json.Unmarshal(content, &list); err != nil {
+		return nil, err
+	}
+
+	for _, hash := range list {
+		hashes[hash] = true
+	}
+
+	return hashes, nil
+}
+
+func (s *store) forget(identities []string) (int, error) {
+	hashes, err := s.forgotten()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, identity := range identities {
+		hashes[identityHash(identity)] = true
+	}
+
+	var list []string
+	for hash := range hashes {
+		list = append(list, hash)
+	}
+	sort.Strings(list)
+
+	content, _ := json.MarshalIndent(list, "", "  ")
+	if err := os.WriteFile(s.forgottenPath(), content, 0o644); err != nil {
+		return 0, err
+	}
+
+	if err := s.updateFetched(func(fetched *fetchedStore) bool { return fetched.forget(hashes) }); err != nil {
+		return 0, err
+	}
+	forgetCachedNames(hashes)
+
+	snaps, err := s.snapshots()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, snap := range snaps {
+		if !snap.forget(hashes) {
+			continue
+		}
+
+		content, err := json.MarshalIndent(snap, "", "  ")
+		if err != nil {
+			return removed, err
+		}
+
+		if err := os.WriteFile(s.snapshotPath(snap.Id), content, 0o644); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Drops the rows and the columns of forgotten people, matching the leading
+// text columns and the header cells that identify the person, like the
+// reviewer by author tables, and the summaries and outputs naming them.
+// Returns whether the snapshot changed.
+func (snap *snapshot) forget(hashes map[string]bool) bool {
+	changed := false
+
+	if snap.User != "" && hashes[identityHash(snap.User)] {
+		snap.User = ""
+		changed = true
+	}
+
+	for i := range snap.Sections {
+		if snap.Sections[i].forget(hashes) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func (section *snapshotSection) forget(hashes map[string]bool) bool {
+	changed := false
+
+	var rows [][]string
+	for _, row := range section.Rows {
+		if rowIdentifies(row, hashes) {
+			changed = true
+			continue
+		}
+		rows = append(rows, row)
+	}
+	section.Rows = rows
+
+	// The first column labels the rows, so only the others can be people.
+	for column := len(section.Header) - 1; column > 0; column-- {
+		if !identifies(section.Header[column], hashes) {
+			continue
+		}
+
+		section.Header = withoutCell(section.Header, column)
+		for i, row := range section.Rows {
+			section.Rows[i] = withoutCell(row, column)
+		}
+		section.Footer = withoutCell(section.Footer, column)
+		changed = true
+	}
+
+	if textIdentifies(section.Summary, hashes) {
+		section.Summary = ""
+		changed = true
+	}
+
+	for key, value := range section.Outputs {
+		if textIdentifies(value, hashes) {
+			delete(section.Outputs, key)
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func identifies(cell string, hashes map[string]bool) bool {
+	return cell != "" && hashes[identityHash(cell)]
+}
+
+func rowIdentifies(row []string, hashes map[string]bool) bool {
+	for i, cell := range row {
+		if i > 1 {
+			break
+		}
+
+		if identifies(cell, hashes) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The longest name, in words, looked for in the free text.
+const maxNameWords = 4
+
+// Whether any run of words of the text, without the punctuation around it,
+// is a forgotten login or name.
+func textIdentifies(text string, hashes map[string]bool) bool {
+	words := strings.Fields(text)
+	for i := range words {
+		for j := i + 1; j <= len(words) && j <= i+maxNameWords; j++ {
+			candidate := strings.TrimFunc(strings.Join(words[i:j], " "), func(r rune) bool {
+				return unicode.IsPunct(r) && r != '-' && r != '_'
+			})
+			if identifies(candidate, hashes) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func withoutCell(row []string, column int) []string {
+	if column >= len(row) {
+		return row
+	}
+
+	return append(row[:column:column], row[column+1:]...)
+}
+
+// Removes everything the forgotten people did from the stored data: their PRs,
+// reviews, comments, review requests, issue assignments and Jira transitions,
+// and their names. Returns whether it changed.
+func (fetched *fetchedStore) forget(hashes map[string]bool) bool {
+	if len(hashes) == 0 {
+		return false
+	}
+
+	isForgotten := forgottenBy(hashes, fetched.Names)
+
+	var changed bool
+	fetched.PRs, changed = forgetInPRs(fetched.PRs, isForgotten)
+	if forgetInIssues(fetched.Issues, isForgotten) {
+		changed = true
+	}
+
+	for login, name := range fetched.Names {
+		if hashes[identityHash(login)] || (name != "" && hashes[identityHash(name)]) {
+			delete(fetched.Names, login)
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// Whether a GitHub login, or the name it goes by, or a Jira display name is
+// one of the forgotten people.
+func forgottenBy(hashes map[string]bool, names map[string]string) func(string) bool {
+	return func(identity string) bool {
+		for _, id := range []string{identity, names[identity]} {
+			if id != "" && hashes[identityHash(id)] {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Drops the PRs of the forgotten people, and their reviews, comments and
+// review requests on the others. Returns whether it changed.
+func forgetInPRs(prs []pullRequest, isForgotten func(string) bool) ([]pullRequest, bool) {
+	changed := false
+
+	var kept []pullRequest
+	for _, pr := range prs {
+		if isForgotten(pr.Author.Login) {
+			changed = true
+			continue
+		}
+
+		var reviews []pullRequestReview
+		for _, review := range pr.Reviews.Nodes {
+			if !isForgotten(review.Author.Login) {
+				reviews = append(reviews, review)
+			}
+		}
+
+		var comments []pullRequestComment
+		for _, comment := range pr.Comments.Nodes {
+			if !isForgotten(comment.Author.Login) {
+				comments = append(comments, comment)
+			}
+		}
+
+		var items []timelineItem
+		for _, item := range pr.TimelineItems.Nodes {
+			if item.Typename != "ReviewRequestedEvent" || !isForgotten(item.ReviewRequestedEvent.RequestedReviewer.User.Login) {
+				items = append(items, item)
+			}
+		}
+
+		if len(reviews) != len(pr.Reviews.Nodes) || len(comments) != len(pr.Comments.Nodes) || len(items) != len(pr.TimelineItems.Nodes) {
+			changed = true
+		}
+		pr.Reviews.Nodes, pr.Comments.Nodes, pr.TimelineItems.Nodes = reviews, comments, items
+		kept = append(kept, pr)
+	}
+
+	return kept, changed
+}
+
+// Unassigns the forgotten people from the issues and drops their transitions.
+// Returns whether it changed.
+func forgetInIssues(issues []jiraIssue, isForgotten func(string) bool) bool {
+	changed := false
+
+	for i := range issues {
+		issue := &issues[i]
+		if isForgotten(issue.Fields.Assignee.DisplayName) {
+			issue.Fields.Assignee.DisplayName = ""
+			changed = true
+		}
+		if isForgotten(issue.ParentAssignee) {
+			issue.ParentAssignee = ""
+			changed = true
+		}
+
+		histories := issue.Changelog.Histories
+		kept := histories[:0]
+		for _, history := range histories {
+			if !isForgotten(history.Author.DisplayName) {
+				kept = append(kept, history)
+			}
+		}
+		if len(kept) != len(histories) {
+			changed = true
+		}
+		issue.Changelog.Histories = kept
+	}
+
+	return changed
+}
+
+// Drops the PRs and issues created before the cutoff. Returns whether it
+// changed.
+func (fetched *fetchedStore) expire(cutoff time.Time) bool {
+	changed := false
+
+	var prs []pullRequest
+	for _, pr := range fetched.PRs {
+		if pr.CreatedAt.Before(cutoff) {
+			changed = true
+			continue
+		}
+		prs = append(prs, pr)
+	}
+	fetched.PRs = prs
+
+	var issues []jiraIssue
+	for _, issue := range fetched.Issues {
+		if created, err := time.Parse(jiraTimeLayout, issue.Fields.Created); err == nil && created.Before(cutoff) {
+			changed = true
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	fetched.Issues = issues
+
+	if fetched.InitialDate.Before(cutoff) {
+		fetched.InitialDate = cutoff
+		changed = true
+	}
+
+	return changed
+}
+
+// Leaves the forgotten people out of the fetched data, so they are not part of
+// any table nor total.
+func (data *fetchedData) forget(hashes map[string]bool) {
+	if len(hashes) == 0 {
+		return
+	}
+
+	isForgotten := forgottenBy(hashes, names)
+	data.prs, _ = forgetInPRs(data.prs, isForgotten)
+	forgetInIssues(data.issues, isForgotten)
+}
+
+func forgetCommand(s *store, args []string) {
+	var users []string
+
+	flags := flag.NewFlagSet("forget", flag.ExitOnError)
+	flags.Func("user", "GitHub login or Jira display name to forget. Can be repeated", func(value string) error {
+		users = append(users, value)
+		return nil
+	})
+	flags.Parse(args)
+
+	if len(users) == 0 {
+		fatalf(exitConfig, "pull-metrics forget --user <login> [--user <name>]")
+	}
+
+	removed, err := requireStore(s).forget(users)
+	if err != nil {
+		log.Fatalf("Error forgetting %s: %v", strings.Join(users, ", "), err)
+	}
+
+	fmt.Printf("Removed from %d snapshots. Future reports will leave them out.\n", removed)
+}
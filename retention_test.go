@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func assignedIssue(key, assignee string) jiraIssue {
+	var issue jiraIssue
+	issue.Key = key
+	issue.Fields.Project.Key = "APP"
+	issue.Fields.Assignee.DisplayName = assignee
+	issue.Fields.IssueType.Name = "Story"
+	issue.Fields.Status.Name = "In Progress"
+	issue.Fields.Created = testStart.AddDate(0, 0, 2).Format(jiraTimeLayout)
+
+	// The assignee moved it to In Progress, as the tickets of TICKETS_URL do.
+	changelog := fmt.Sprintf(`{"Histories": [{"Author": {"DisplayName": %q}, "Created": %q, "Items": [{"Field": "status", "FromString": "To Do", "ToString": "In Progress"}]}]}`,
+		assignee, testStart.AddDate(0, 0, 3).Format(jiraTimeLayout))
+	if err := json.Unmarshal([]byte(changelog), &issue.Changelog); err != nil {
+		panic(err)
+	}
+
+	return issue
+}
+
+func TestForgetLeavesNoSectionNamingThePerson(t *testing.T) {
+	t.Setenv("JIRA_SUBTASKS", "rollup")
+	created := testStart.AddDate(0, 0, 2)
+
+	// Counted for the assignee of its parent.
+	subtask := assignedIssue("APP-3", "Eve Smith")
+	subtask.ParentAssignee = "Dana Jones"
+	if err := json.Unmarshal([]byte(`{"Key": "APP-4", "Fields": {"IssueType": {"Name": "Story"}, "Status": {"Name": "In Progress"}}}`), &subtask.Fields.Parent); err != nil {
+		t.Fatal(err)
+	}
+
+	commented := reviewedPR(testPR("ana", created), "APPROVED", "bob")
+	var comment pullRequestComment
+	comment.Author.Login = "bob"
+	comment.CreatedAt = created.Add(1)
+	commented.Comments.Nodes = append(commented.Comments.Nodes, comment)
+
+	data := &fetchedData{
+		prs: []pullRequest{
+			commented,
+			requestedPR(testPR("cy", created), "bob"),
+			testPR("bob", created),
+		},
+		issues: []jiraIssue{
+			assignedIssue("APP-1", "Dana Jones"),
+			assignedIssue("APP-2", "Eve Smith"),
+			subtask,
+		},
+		githubOk: true,
+		jiraOk:   true,
+	}
+
+	data.forget(map[string]bool{identityHash("bob"): true, identityHash("Dana Jones"): true})
+
+	for _, section := range data.allSections(testStart, testEnd) {
+		cells := []string{section.Name, section.Summary, fmt.Sprint(section.Header), fmt.Sprint(section.Footer)}
+		for _, row := range section.Rows {
+			cells = append(cells, fmt.Sprint(row))
+		}
+		for _, output := range section.Outputs {
+			cells = append(cells, output)
+		}
+
+		for _, cell := range cells {
+			for _, forgotten := range []string{"bob", "Dana"} {
+				if strings.Contains(cell, forgotten) {
+					t.Errorf("%s names %s: %s", section.Name, forgotten, cell)
+				}
+			}
+		}
+	}
+}
+
+func TestSnapshotForget(t *testing.T) {
+	snap := snapshot{
+		User: "bob",
+		Sections: []snapshotSection{{
+			Name:    "Review pairs",
+			Summary: "Most reviews by @bob.",
+			Header:  []string{"Author", "ana", "bob", "cy"},
+			Rows:    [][]string{{"ana", "0", "2", "1"}, {"bob", "3", "0", "1"}, {"cy", "1", "4", "0"}},
+			Footer:  []string{"Total", "4", "6", "2"},
+			Outputs: map[string]string{"top_reviewer": "bob", "pairs": "3"},
+		}},
+	}
+
+	if !snap.forget(map[string]bool{identityHash("bob"): true}) {
+		t.Fatal("forget did not change the snapshot")
+	}
+
+	section := snap.Sections[0]
+	if got := fmt.Sprint(section.Header, section.Rows, section.Footer); got != "[Author ana cy] [[ana 0 1] [cy 1 0]] [Total 4 2]" {
+		t.Errorf("got %s", got)
+	}
+	if snap.User != "" || section.Summary != "" {
+		t.Errorf("user %q, summary %q, want none", snap.User, section.Summary)
+	}
+	if _, ok := section.Outputs["top_reviewer"]; ok || section.Outputs["pairs"] != "3" {
+		t.Errorf("outputs %v", section.Outputs)
+	}
+}
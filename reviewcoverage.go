@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// A review of someone other than the author, submitted by the merge.
+func (pr pullRequest) reviewedBeforeMerge() bool {
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login != "" && review.Author.Login != pr.Author.Login && review.State != "PENDING" && !review.SubmittedAt.After(pr.MergedAt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The merged PRs that got at least one review from someone else before the
+// merge, per repository and, with the roster teams, per author team.
+func reviewCoverageSections(prs []pullRequest, endDate time.Time, r *roster) []*reportSection {
+	type coverage struct {
+		merged   int
+		reviewed int
+	}
+
+	byRepo := make(map[string]*coverage)
+	byTeam := make(map[string]*coverage)
+	total := &coverage{}
+	for _, pr := range prs {
+		if pr.stateAt(endDate) != prMerged {
+			continue
+		}
+
+		reviewed := pr.reviewedBeforeMerge()
+		count := func(stats map[string]*coverage, key string) {
+			if stats[key] == nil {
+				stats[key] = &coverage{}
+			}
+			stats[key].merged++
+			if reviewed {
+				stats[key].reviewed++
+			}
+		}
+
+		count(byRepo, pr.Repository.NameWithOwner)
+		if r != nil && r.hasTeams() {
+			count(byTeam, r.team(pr.Author.Login, names[pr.Author.Login]))
+		}
+
+		total.merged++
+		if reviewed {
+			total.reviewed++
+		}
+	}
+
+	coverageSection := func(name, column string, stats map[string]*coverage) *reportSection {
+		section := &reportSection{
+			Name:     name,
+			Title:    name,
+			Header:   table.Row{column, "Merged PRs", "Reviewed", "Unreviewed", "Review coverage (%)"},
+			Centered: []int{2, 3, 4, 5},
+		}
+
+		for _, key := range sortedKeys(stats) {
+			s := stats[key]
+			unreviewed := interface{}(s.merged - s.reviewed)
+			if s.merged > s.reviewed {
+				unreviewed = flagged{s.merged - s.reviewed, warning}
+			}
+			section.Rows = append(section.Rows, table.Row{key, s.merged, s.reviewed, unreviewed, percentCell(s.reviewed, s.merged)})
+		}
+
+		return section
+	}
+
+	repos := coverageSection("Review coverage", "Repository", byRepo)
+	repos.Summary = fmt.Sprintf("%d of %d merged PRs were reviewed by someone other than their author before the merge", total.reviewed, total.merged)
+	if total.merged > 0 {
+		repos.Footer = table.Row{"Total", total.merged, total.reviewed, total.merged - total.reviewed, percentCell(total.reviewed, total.merged)}
+		repos.Outputs = map[string]string{
+			"github_review_coverage": formatOutput(float64(total.reviewed*100) / float64(total.merged)),
+		}
+	}
+
+	sections := []*reportSection{repos}
+	if len(byTeam) > 0 {
+		teams := coverageSection("Review coverage by team", "Author team", byTeam)
+		teams.Outputs = make(map[string]string)
+		for team, s := range byTeam {
+			output := "github_review_coverage_" + strings.ToLower(strings.ReplaceAll(team, " ", "_"))
+			teams.Outputs[output] = formatOutput(float64(s.reviewed*100) / float64(s.merged))
+		}
+		sections = append(sections, teams)
+	}
+
+	return sections
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The people who reviewed the PR by the end date, its author left out.
+func (pr pullRequest) reviewerCount(endDate time.Time) int {
+	reviewers := make(map[string]bool)
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login != "" && review.Author.Login != pr.Author.Login && review.State != "PENDING" && !review.SubmittedAt.After(endDate) {
+			reviewers[review.Author.Login] = true
+		}
+	}
+
+	return len(reviewers)
+}
+
+// The average requested reviewers and reviewers of the PRs. Fewer than one
+// reviewer per PR is flagged.
+func reviewerCells(prs []pullRequest, endDate time.Time) (interface{}, interface{}) {
+	requested, reviewers := 0, 0
+	for _, pr := range prs {
+		requested += len(pr.reviewRequests())
+		reviewers += pr.reviewerCount(endDate)
+	}
+
+	var reviewing interface{} = averageOf(reviewers, len(prs))
+	if len(prs) > 0 && reviewers < len(prs) {
+		reviewing = flagged{reviewing, warning}
+	}
+
+	return averageOf(requested, len(prs)), reviewing
+}
+
+// The same averages per repository.
+func reviewerCountSection(prs []pullRequest, endDate time.Time) *reportSection {
+	byRepo := make(map[string][]pullRequest)
+	for _, pr := range prs {
+		byRepo[pr.Repository.NameWithOwner] = append(byRepo[pr.Repository.NameWithOwner], pr)
+	}
+
+	section := &reportSection{
+		Name:     "Reviewers per PR",
+		Title:    "Reviewers per PR",
+		Summary:  "Distinct reviewers requested on the PRs and distinct people who reviewed them, their authors left out",
+		Header:   table.Row{"Repository", "Total PRs", "Requested reviewers / PR", "Reviewers / PR"},
+		Centered: []int{2, 3, 4},
+	}
+
+	for _, repo := range sortedKeys(byRepo) {
+		requested, reviewing := reviewerCells(byRepo[repo], endDate)
+		section.Rows = append(section.Rows, table.Row{repo, len(byRepo[repo]), requested, reviewing})
+	}
+
+	if len(prs) > 0 {
+		requested, reviewing := reviewerCells(prs, endDate)
+		section.Footer = table.Row{"All", len(prs), requested, reviewing}
+	}
+
+	return section
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The PR waits for a reviewer from its creation or, when it was opened as a
+// draft, from the first time it was marked ready for review.
+func (pr pullRequest) readyForReviewAt() time.Time {
+	for _, item := range pr.TimelineItems.Nodes {
+		if item.Typename == "ReadyForReviewEvent" {
+			return item.ReadyForReviewEvent.CreatedAt
+		}
+	}
+
+	return pr.CreatedAt
+}
+
+// Returns the first time a reviewer was requested or somebody was assigned,
+// up to the end of the report.
+func (pr pullRequest) firstReviewerAt(endDate time.Time) (time.Time, bool) {
+	for _, item := range pr.TimelineItems.Nodes {
+		var at time.Time
+		switch item.Typename {
+		case "ReviewRequestedEvent":
+			at = item.ReviewRequestedEvent.CreatedAt
+		case "AssignedEvent":
+			at = item.AssignedEvent.CreatedAt
+		default:
+			continue
+		}
+
+		if !at.After(endDate) {
+			return at, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Drafts are not waiting for a reviewer yet.
+func (pr pullRequest) isDraftAt(endDate time.Time) bool {
+	return pr.IsDraft || pr.readyForReviewAt().After(endDate)
+}
+
+func durationCell(values []float64, p float64) interface{} {
+	value := percentile(values, p)
+	if math.IsNaN(value) {
+		return notApplicable
+	}
+
+	return duration(time.Duration(value))
+}
+
+// Separates the PRs nobody was asked to review from the ones whose reviewers
+// were slow, per repository.
+func reviewAssignmentSection(prs []pullRequest, endDate time.Time) *reportSection {
+	type repoStats struct {
+		prs          int
+		notRequested int
+		latencies    []float64
+	}
+
+	byRepo := make(map[string]*repoStats)
+	total := &repoStats{}
+
+	for _, pr := range prs {
+		if pr.isDraftAt(endDate) {
+			continue
+		}
+
+		repo := pr.Repository.NameWithOwner
+		if byRepo[repo] == nil {
+			byRepo[repo] = &repoStats{}
+		}
+
+		for _, stats := range []*repoStats{byRepo[repo], total} {
+			stats.prs++
+
+			requestedAt, ok := pr.firstReviewerAt(endDate)
+			if !ok {
+				stats.notRequested++
+				continue
+			}
+
+			latency := requestedAt.Sub(pr.readyForReviewAt())
+			if latency < 0 {
+				latency = 0
+			}
+			stats.latencies = append(stats.latencies, float64(latency))
+		}
+	}
+
+	var repos []string
+	for repo := range byRepo {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	section := &reportSection{
+		Name:     "Review assignment",
+		Title:    "Review assignment",
+		Summary:  fmt.Sprintf("Time from a PR being ready for review to the first reviewer being requested or assigned. %d of %d PRs had nobody asked", total.notRequested, total.prs),
+		Header:   table.Row{"Repository", "PRs", "Reviewer requested", "Nobody asked", "Median latency", "90th percentile latency"},
+		Centered: []int{2, 3, 4, 5, 6},
+	}
+
+	row := func(name string, stats *repoStats) table.Row {
+		return table.Row{
+			name,
+			stats.prs,
+			len(stats.latencies),
+			stats.notRequested,
+			durationCell(stats.latencies, 50),
+			durationCell(stats.latencies, 90),
+		}
+	}
+
+	for _, repo := range repos {
+		section.Rows = append(section.Rows, row(repo, byRepo[repo]))
+	}
+	section.Footer = row("Total", total)
+
+	return section
+}
+
+type reviewRequest struct {
+	reviewer    string
+	requestedAt time.Time
+}
+
+// The first request of each user. Team requests have no login and are left
+// out.
+func (pr pullRequest) reviewRequests() []reviewRequest {
+	var requests []reviewRequest
+	seen := make(map[string]bool)
+
+	for _, item := range pr.TimelineItems.Nodes {
+		reviewer := item.ReviewRequestedEvent.RequestedReviewer.User.Login
+		if item.Typename != "ReviewRequestedEvent" || reviewer == "" || seen[reviewer] {
+			continue
+		}
+
+		seen[reviewer] = true
+		requests = append(requests, reviewRequest{reviewer: reviewer, requestedAt: item.ReviewRequestedEvent.CreatedAt})
+	}
+
+	return requests
+}
+
+func (pr pullRequest) firstReviewBy(login string, after time.Time) (time.Time, bool) {
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login == login && review.State != "PENDING" && !review.SubmittedAt.Before(after) {
+			return review.SubmittedAt, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func reviewSLA() time.Duration {
+	return envDuration("REVIEW_SLA", 24*time.Hour)
+}
+
+// Requests still inside the SLA at the end of the report are pending, and do
+// not count against the reviewer.
+func reviewResponseSection(prs []pullRequest, endDate time.Time) *reportSection {
+	sla := reviewSLA()
+
+	type reviewerStats struct {
+		requests  int
+		answered  int
+		withinSLA int
+		pending   int
+		responses []float64
+	}
+
+	byReviewer := make(map[string]*reviewerStats)
+	total := &reviewerStats{}
+
+	for _, pr := range prs {
+		for _, request := range pr.reviewRequests() {
+			if request.requestedAt.After(endDate) {
+				continue
+			}
+
+			if byReviewer[request.reviewer] == nil {
+				byReviewer[request.reviewer] = &reviewerStats{}
+			}
+
+			reviewedAt, ok := pr.firstReviewBy(request.reviewer, request.requestedAt)
+			if ok && reviewedAt.After(endDate) {
+				ok = false
+			}
+
+			for _, stats := range []*reviewerStats{byReviewer[request.reviewer], total} {
+				stats.requests++
+
+				switch {
+				case ok:
+					stats.answered++
+					stats.responses = append(stats.responses, float64(reviewedAt.Sub(request.requestedAt)))
+					if reviewedAt.Sub(request.requestedAt) <= sla {
+						stats.withinSLA++
+					}
+				case endDate.Sub(request.requestedAt) < sla:
+					stats.pending++
+				}
+			}
+		}
+	}
+
+	var reviewers []string
+	for reviewer := range byReviewer {
+		reviewers = append(reviewers, reviewer)
+	}
+	sort.Strings(reviewers)
+
+	section := &reportSection{
+		Name:     "Review response",
+		Title:    "Review response",
+		Summary:  fmt.Sprintf("Time from a reviewer being requested to their first review, with a %v SLA", duration(sla)),
+		Header:   table.Row{"ID", "Name", "Requests", "Reviewed", "Pending", "Median response", "Within SLA (%)"},
+		Centered: []int{3, 4, 5, 6, 7},
+	}
+
+	row := func(login, name string, stats *reviewerStats) table.Row {
+		return table.Row{
+			login,
+			name,
+			stats.requests,
+			stats.answered,
+			stats.pending,
+			durationCell(stats.responses, 50),
+			percentCell(stats.withinSLA, stats.requests-stats.pending),
+		}
+	}
+
+	for _, reviewer := range reviewers {
+		section.Rows = append(section.Rows, row(reviewer, names[reviewer], byReviewer[reviewer]))
+	}
+	section.Footer = row("Total", "", total)
+
+	return section
+}
+
+// Counts the reviews submitted by each reviewer team on the PRs of each author
+// team. Several reviews of the same person on a PR count once.
+func reviewMatrixSection(prs []pullRequest, endDate time.Time, r *roster) *reportSection {
+	counts := make(map[string]map[string]int)
+	teamSet := make(map[string]bool)
+	total, crossTeam := 0, 0
+
+	for _, pr := range prs {
+		authorTeam := r.team(pr.Author.Login, names[pr.Author.Login])
+		teamSet[authorTeam] = true
+
+		seen := make(map[string]bool)
+		for _, review := range pr.Reviews.Nodes {
+			reviewer := review.Author.Login
+			if reviewer == "" || reviewer == pr.Author.Login || review.State == "PENDING" || review.SubmittedAt.After(endDate) || seen[reviewer] {
+				continue
+			}
+			seen[reviewer] = true
+
+			reviewerTeam := r.team(reviewer, names[reviewer])
+			teamSet[reviewerTeam] = true
+
+			if counts[authorTeam] == nil {
+				counts[authorTeam] = make(map[string]int)
+			}
+			counts[authorTeam][reviewerTeam]++
+
+			total++
+			if authorTeam != reviewerTeam {
+				crossTeam++
+			}
+		}
+	}
+
+	var teams []string
+	for team := range teamSet {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	summary := "No reviews were submitted"
+	if total > 0 {
+		summary = fmt.Sprintf("%d of %d reviews (%v) crossed teams. Rows are the author teams, columns the reviewer teams", crossTeam, total, percent(float64(crossTeam*100)/float64(total)))
+	}
+
+	section := &reportSection{
+		Name:    "Review matrix",
+		Title:   "Cross-team reviews",
+		Summary: summary,
+		Header:  table.Row{"Author team"},
+	}
+
+	for i, team := range teams {
+		section.Header = append(section.Header, team)
+		section.Centered = append(section.Centered, i+2)
+	}
+	section.Header = append(section.Header, "Total")
+	section.Centered = append(section.Centered, len(teams)+2)
+
+	columnTotals := make([]int, len(teams))
+	for _, authorTeam := range teams {
+		row := table.Row{authorTeam}
+		rowTotal := 0
+		for i, reviewerTeam := range teams {
+			count := counts[authorTeam][reviewerTeam]
+			row = append(row, count)
+			rowTotal += count
+			columnTotals[i] += count
+		}
+		section.Rows = append(section.Rows, append(row, rowTotal))
+	}
+
+	section.Footer = table.Row{"Total"}
+	for _, count := range columnTotals {
+		section.Footer = append(section.Footer, count)
+	}
+	section.Footer = append(section.Footer, total)
+
+	return section
+}
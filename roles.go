@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The repository roles, from the most to the least permissions.
+var repoRoles = []string{"admin", "maintain", "write", "triage", "read", "none"}
+
+type githubPermission struct {
+	Permission string
+	RoleName   string `json:"role_name"`
+}
+
+// With AUTHOR_ROLES, reads the role of every author in the repository, which
+// takes a request per author and a token allowed to list the collaborators.
+func fetchAuthorRoles(owner, repo string, prs []pullRequest) {
+	if getenv("AUTHOR_ROLES") != "true" || len(prs) == 0 {
+		return
+	}
+
+	roles := make(map[string]string)
+	for i, pr := range prs {
+		login := pr.Author.Login
+		if _, ok := roles[login]; !ok && login != "" {
+			var permission githubPermission
+			url := fmt.Sprintf("%s/repos/%s/%s/collaborators/%s/permission", githubApiUrl(), owner, repo, login)
+			if err := githubGetJSON(url, &permission); err != nil {
+				warnPartial("Error reading the role of %s in %s: %v\n", login, repo, err)
+			}
+
+			roles[login] = permission.RoleName
+			if roles[login] == "" {
+				roles[login] = permission.Permission
+			}
+		}
+
+		prs[i].AuthorRole = roles[login]
+	}
+}
+
+// The highest role of the author in the repositories of the PRs, noting the
+// outside collaborators, who have it without being in the organization.
+func roleCell(prs []pullRequest) string {
+	role, outside := "", false
+	for _, pr := range prs {
+		if pr.AuthorRole == "" {
+			continue
+		}
+		if pr.AuthorAssociation == "COLLABORATOR" {
+			outside = true
+		}
+
+		if role == "" || roleRank(pr.AuthorRole) < roleRank(role) {
+			role = pr.AuthorRole
+		}
+	}
+
+	switch {
+	case role == "":
+		return notApplicable
+	case outside:
+		return "outside collaborator (" + role + ")"
+	}
+
+	return role
+}
+
+// Custom roles rank below the built-in ones.
+func roleRank(role string) int {
+	for i, known := range repoRoles {
+		if strings.EqualFold(role, known) {
+			return i
+		}
+	}
+
+	return len(repoRoles)
+}
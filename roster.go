@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"time"
+)
+
+type rosterEntry struct {
+	startDate time.Time
+	seniority string
+	team      string
+	country   string
+}
+
+type roster struct {
+	entries map[string]rosterEntry
+	rampUp  time.Duration
+}
+
+// The file has one line per person: person,start date,seniority,team,country.
+// The person is matched against GitHub logins and Jira display names, and the
+// country picks their HOLIDAYS.
+func loadRoster(rampUpDays int) *roster {
+	path := getenv("ROSTER_FILE")
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fatalf(exitConfig, "Error opening ROSTER_FILE: %v", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		fatalf(exitConfig, "Error reading ROSTER_FILE: %v", err)
+	}
+
+	r := &roster{
+		entries: make(map[string]rosterEntry),
+		rampUp:  time.Duration(rampUpDays) * time.Hour * 24,
+	}
+
+	for i, record := range records {
+		if len(record) < 2 {
+			fatalf(exitConfig, "ROSTER_FILE line %d: expected person,start date[,seniority[,team[,country]]]", i+1)
+		}
+
+		startDate, err := parseDateOrTime(strings.TrimSpace(record[1]), false)
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			fatalf(exitConfig, "ROSTER_FILE line %d: %v", i+1, err)
+		}
+
+		entry := rosterEntry{startDate: startDate}
+		if len(record) > 2 {
+			entry.seniority = strings.TrimSpace(record[2])
+		}
+		if len(record) > 3 {
+			entry.team = strings.TrimSpace(record[3])
+		}
+		if len(record) > 4 {
+			entry.country = strings.TrimSpace(record[4])
+		}
+
+		r.entries[strings.ToLower(strings.TrimSpace(record[0]))] = entry
+	}
+
+	return r
+}
+
+func (r *roster) lookup(identities ...string) (rosterEntry, bool) {
+	for _, identity := range identities {
+		if entry, ok := r.entries[strings.ToLower(identity)]; ok && identity != "" {
+			return entry, true
+		}
+	}
+
+	return rosterEntry{}, false
+}
+
+func (r *roster) inRampUp(at time.Time, identities ...string) bool {
+	entry, ok := r.lookup(identities...)
+	return ok && at.Before(entry.startDate.Add(r.rampUp))
+}
+
+// People who started less than a ramp-up period before the end of the report
+// are new joiners, everybody else on the roster is tenured.
+func (r *roster) cohort(endDate time.Time, identities ...string) string {
+	entry, ok := r.lookup(identities...)
+	if !ok {
+		return "Not on roster"
+	}
+
+	if endDate.Before(entry.startDate.Add(r.rampUp)) {
+		return "New joiners"
+	}
+
+	return "Tenured"
+}
+
+func (r *roster) hasTeams() bool {
+	for _, entry := range r.entries {
+		if entry.team != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *roster) team(identities ...string) string {
+	if entry, ok := r.lookup(identities...); ok && entry.team != "" {
+		return entry.team
+	}
+
+	return "No team"
+}
@@ -0,0 +1,59 @@
+package main
+
+// The version of the JSON report, its entities, the --events lines and the
+// queued events. Fields are only added within a version; renaming or removing
+// one, or changing what it means, bumps it.
+const schemaVersion = 1
+
+// The entities of the events and of the JSON report, whatever the provider.
+const (
+	entityChangeRequest = "change_request"
+	entityReview        = "review"
+	entityWorkItem      = "work_item"
+	entityPerson        = "person"
+	entityTeam          = "team"
+)
+
+// A person for every author, reviewer and assignee left after the exclusions
+// and the opt-outs, with their roster team, then a team per roster team with
+// its members among them.
+func (data *fetchedData) peopleEvents() []event {
+	people := make(map[string]bool)
+	for _, pr := range data.prs {
+		people[pr.Author.Login] = true
+		for _, review := range pr.Reviews.Nodes {
+			people[review.Author.Login] = true
+		}
+	}
+	for _, issue := range data.issues {
+		people[issue.Fields.Assignee.DisplayName] = true
+	}
+	delete(people, "")
+	for person := range people {
+		if isOptedOut(person) || isOptedOut(names[person]) {
+			delete(people, person)
+		}
+	}
+
+	var batch []event
+	members := make(map[string][]string)
+	for _, person := range sortedKeys(people) {
+		e := event{Entity: entityPerson, Type: "person", Key: person}
+		if name, ok := knownName(person); ok && name != person {
+			e.Name = name
+		}
+		if data.people.roster != nil {
+			if entry, ok := data.people.roster.lookup(person); ok && entry.team != "" {
+				e.Team = entry.team
+				members[entry.team] = append(members[entry.team], person)
+			}
+		}
+		batch = append(batch, e)
+	}
+
+	for _, team := range sortedKeys(members) {
+		batch = append(batch, event{Entity: entityTeam, Type: "team", Key: team, Members: members[team]})
+	}
+
+	return batch
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+var resolvedSecrets = make(map[string]string)
+
+// The providers read their settings concurrently.
+var secretsMutex sync.Mutex
+
+// Same as os.Getenv, but values can also reference a secret in an external
+// secret manager:
+//
+//	vault://secret/data/pull-metrics#github_token
+//	aws-sm://pull-metrics/tokens#github_token
+//	gcp-sm://projects/my-project/secrets/github-token
+//
+// The part after # picks a key when the secret is a JSON object. Variables
+// missing from the environment are read from the ones saved by
+// pull-metrics login.
+func getenv(name string) string {
+	value := os.Getenv(name)
+
+	if value == "" && isStored(name) {
+		if secret, ok := resolvedSecret("stored:" + name); ok {
+			return secret
+		}
+
+		secret, err := readStoredCredential(name)
+		if err != nil {
+			fatalf(exitConfig, "Error reading %s: %v", name, err)
+		}
+
+		rememberSecret("stored:"+name, secret)
+		return secret
+	}
+
+	scheme, reference, found := strings.Cut(value, "://")
+	if !found {
+		return value
+	}
+
+	switch scheme {
+	case "vault", "aws-sm", "gcp-sm":
+	default:
+		return value
+	}
+
+	if secret, ok := resolvedSecret(value); ok {
+		return secret
+	}
+
+	// The secret managers are read without the lock: their requests go
+	// through the --debug-http redaction, which reads the resolved secrets.
+	path, key, _ := strings.Cut(reference, "#")
+
+	var secret string
+	var err error
+	switch scheme {
+	case "vault":
+		secret, err = readVaultSecret(path, key)
+	case "aws-sm":
+		secret, err = readAwsSecret(path, key)
+	case "gcp-sm":
+		secret, err = readGcpSecret(path, key)
+	}
+
+	if err != nil {
+		fatalf(exitConfig, "Error resolving %s: %v", name, err)
+	}
+
+	rememberSecret(value, secret)
+	return secret
+}
+
+func resolvedSecret(reference string) (string, bool) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	secret, ok := resolvedSecrets[reference]
+	return secret, ok
+}
+
+func rememberSecret(reference, secret string) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	resolvedSecrets[reference] = secret
+}
+
+func secretKey(content []byte, key string) (string, error) {
+	if key == "" {
+		return string(content), nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return "", fmt.Errorf("the secret is not a JSON object: %v", err)
+	}
+
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in the secret", key)
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+func readVaultSecret(path, key string) (string, error) {
+	address := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if address == "" {
+		return "", fmt.Errorf("VAULT_ADDR not provided")
+	}
+
+	authorize := func(req *http.Request) {
+		req.Header.Add("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+		if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+			req.Header.Add("X-Vault-Namespace", namespace)
+		}
+	}
+
+	var response struct {
+		Data map[string]json.RawMessage
+	}
+
+	if _, err := sendJSON("GET", address+"/v1/"+strings.TrimPrefix(path, "/"), authorize, nil, &response); err != nil {
+		return "", err
+	}
+
+	// KV version 2 nests the secret in another data object.
+	data, _ := json.Marshal(response.Data)
+	if nested, ok := response.Data["data"]; ok {
+		data = nested
+	}
+
+	if key == "" {
+		return "", fmt.Errorf("Vault references need a #key")
+	}
+
+	return secretKey(data, key)
+}
+
+func readAwsSecret(id, key string) (string, error) {
+	credentials, err := awsCredentialsFromEnv()
+	if err != nil {
+		return "", err
+	}
+
+	payload, _ := json.Marshal(map[string]string{"SecretId": id})
+
+	req, err := http.NewRequest("POST", "https://secretsmanager."+credentials.region+".amazonaws.com/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	credentials.sign(req, "secretsmanager", payload)
+
+	res, err := newHTTPClient("").Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AWS Secrets Manager returned %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		SecretString string
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", err
+	}
+
+	return secretKey([]byte(secret.SecretString), key)
+}
+
+func gcpAccessToken() (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	// On GCE, GKE and Cloud Run the metadata server hands out tokens for the
+	// attached service account.
+	// Off Google Cloud it does not answer, so it gets a short timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	req.Header.Add("Metadata-Flavor", "Google")
+	if res, err := newHTTPClient("").Do(req); err == nil {
+		defer res.Body.Close()
+
+		var token struct {
+			AccessToken string `json:"access_token"`
+		}
+		if res.StatusCode == http.StatusOK && json.NewDecoder(res.Body).Decode(&token) == nil {
+			return token.AccessToken, nil
+		}
+	}
+
+	output, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+	if err != nil {
+		return "", fmt.Errorf("no Google credentials found (GOOGLE_OAUTH_ACCESS_TOKEN, metadata server or gcloud): %v", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+func readGcpSecret(name, key string) (string, error) {
+	token, err := gcpAccessToken()
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	authorize := func(req *http.Request) {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+
+	var response struct {
+		Payload struct {
+			Data string
+		}
+	}
+
+	if _, err := sendJSON("GET", "https://secretmanager.googleapis.com/v1/"+name+":access", authorize, nil, &response); err != nil {
+		return "", err
+	}
+
+	content, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+
+	return secretKey(content, key)
+}
@@ -0,0 +1,97 @@
+package main
+
+import "time"
+
+const (
+	selectCreated = "created"
+	selectMerged  = "merged"
+	selectClosed  = "closed"
+	selectUpdated = "updated"
+)
+
+// Set by --select: the event of a PR that has to happen in the window for the
+// PR to be in the report.
+var prSelection = selectCreated
+
+func validSelection(selection string) bool {
+	switch selection {
+	case selectCreated, selectMerged, selectClosed, selectUpdated:
+		return true
+	}
+
+	return false
+}
+
+// When the event of --select happened, or zero if it did not.
+func (pr pullRequest) selectedAt() time.Time {
+	return pr.selectedBy(prSelection)
+}
+
+// When the selected event happened, or zero if it did not. Closed PRs include
+// the merged ones, as in GitHub.
+func (pr pullRequest) selectedBy(selection string) time.Time {
+	switch selection {
+	case selectMerged:
+		if pr.Merged {
+			return pr.MergedAt
+		}
+		return time.Time{}
+	case selectClosed:
+		if pr.Closed || pr.Merged {
+			return pr.ClosedAt
+		}
+		return time.Time{}
+	case selectUpdated:
+		return pr.UpdatedAt
+	}
+
+	return pr.CreatedAt
+}
+
+func (pr pullRequest) selectedIn(initialDate, endDate time.Time) bool {
+	at := pr.selectedAt()
+	return at.After(initialDate) && !at.After(endDate)
+}
+
+// Whether a provider must keep a PR it fetched: any selected event in the
+// window also updated the PR, so the PRs updated after the start are kept
+// for everything but created.
+func fetchedIn(created, updated, initialDate, endDate time.Time) bool {
+	if created.After(endDate) {
+		return false
+	}
+
+	if prSelection == selectCreated {
+		return created.After(initialDate)
+	}
+
+	return updated.After(initialDate)
+}
+
+// The order of the GitHub pages, so the scan can stop at the start of the
+// window.
+type issueOrder struct {
+	Field     string `json:"field"`
+	Direction string `json:"direction"`
+}
+
+func (issueOrder) GetGraphQLType() string {
+	return "IssueOrder"
+}
+
+func githubOrder() issueOrder {
+	if prSelection == selectCreated {
+		return issueOrder{"CREATED_AT", "DESC"}
+	}
+
+	return issueOrder{"UPDATED_AT", "DESC"}
+}
+
+// The date the GitHub pages are ordered by.
+func (pr pullRequest) scanDate() time.Time {
+	if prSelection == selectCreated {
+		return pr.CreatedAt
+	}
+
+	return pr.UpdatedAt
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type server struct {
+	options reportOptions
+	store   *store
+	window  int
+	publish bool
+
+	mutex        sync.RWMutex
+	report       *report
+	html         string
+	markdown     string
+	badges       map[string]badge
+	generatedAt  time.Time
+	shuttingDown bool
+}
+
+func serveAddr() string {
+	if addr := getenv("SERVE_ADDR"); addr != "" {
+		return addr
+	}
+
+	return ":8080"
+}
+
+// Runs the report every SERVE_INTERVAL over the last SERVE_WINDOW_DAYS and
+// serves the latest one, until SIGINT or SIGTERM.
+func serve(options reportOptions, snapshots *store) {
+	thresholds = loadThresholds()
+	derivedMetrics = loadDerivedMetrics()
+
+	s := &server{
+		options: options,
+		store:   snapshots,
+		window:  envInt("SERVE_WINDOW_DAYS", 14),
+		publish: getenv("SERVE_PUBLISH") == "true",
+	}
+	interval := envDuration("SERVE_INTERVAL", time.Hour)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.HandleFunc("/report.md", s.serveMarkdown)
+	mux.HandleFunc("/report.json", s.serveJSON)
+	mux.HandleFunc("/badges/", s.serveBadge)
+	mux.HandleFunc("/", s.serveHTML)
+
+	httpServer := &http.Server{Addr: serveAddr(), Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Printf("Listening on %s", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error starting the server: %v", err)
+		}
+	}()
+
+	serving = true
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.refresh()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	<-ctx.Done()
+
+	log.Print("Shutting down")
+
+	s.mutex.Lock()
+	s.shuttingDown = true
+	s.mutex.Unlock()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down the server: %v", err)
+	}
+}
+
+// A failure is logged instead of exiting, and the last report generated is
+// served until the next refresh.
+func (s *server) refresh() {
+	defer func() {
+		if r := recover(); r != nil {
+			failure, ok := r.(refreshFailure)
+			if !ok {
+				panic(r)
+			}
+			log.Printf("Error refreshing the report: %s", failure)
+		}
+	}()
+
+	endDate := time.Now()
+	initialDate := endDate.AddDate(0, 0, -s.window)
+
+	data := fetchData(initialDate, endDate, s.options)
+	publishEvents()
+	rep := &report{InitialDate: initialDate, EndDate: endDate, Sections: data.sections(initialDate, endDate), Entities: data.reportEntities(initialDate, endDate)}
+
+	html := rep.renderHTML()
+	markdown := rep.markdown()
+	badges := reportBadges(windowPRs(data.prs, initialDate, endDate, data.people), endDate)
+
+	s.mutex.Lock()
+	s.report = rep
+	s.html = html
+	s.markdown = markdown
+	s.badges = badges
+	s.generatedAt = endDate
+	s.mutex.Unlock()
+
+	log.Printf("Report for %s - %s generated", initialDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	if s.publish {
+		publishReport(rep)
+		uploadReport(rep)
+		runHooks(rep)
+	}
+
+	if s.store != nil {
+		if _, err := s.store.save(rep); err != nil {
+			log.Printf("Error saving the snapshot: %v", err)
+		}
+	}
+}
+
+func (s *server) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// Not ready until the first report is generated, nor once shutting down.
+func (s *server) readyz(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.report == nil || s.shuttingDown {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *server) serveHTML(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.report == nil {
+		http.Error(w, "The first report is still being generated", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	title := html.EscapeString(tr("Pull metrics"))
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title></head><body>\n<h1>%s %s - %s</h1>\n<p>Generated at %s</p>\n%s</body></html>\n",
+		title, title, formatDate(s.report.InitialDate), formatDate(s.report.EndDate), s.generatedAt.Format(time.RFC3339), s.html)
+}
+
+func (s *server) serveMarkdown(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.report == nil {
+		http.Error(w, "The first report is still being generated", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	fmt.Fprint(w, s.markdown)
+}
+
+func (s *server) serveJSON(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.report == nil {
+		http.Error(w, "The first report is still being generated", http.StatusServiceUnavailable)
+		return
+	}
+
+	content, err := s.report.json()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(content)
+}
+
+// Serves /badges/repo/<owner>/<repo>/<metric>.json and
+// /badges/author/<login>/<metric>.json for shields.io endpoint badges.
+func (s *server) serveBadge(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.report == nil {
+		http.Error(w, "The first report is still being generated", http.StatusServiceUnavailable)
+		return
+	}
+
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/badges/"), ".json")
+	b, ok := s.badges[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=300")
+	json.NewEncoder(w).Encode(b)
+}
+
+// Probes the /healthz endpoint of a running server, for container
+// healthchecks in images without curl or wget.
+func healthcheck() {
+	_, port, err := net.SplitHostPort(serveAddr())
+	if err != nil {
+		fatalf(exitConfig, "Invalid SERVE_ADDR: %v", err)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Get("http://127.0.0.1:" + port + "/healthz")
+	if err != nil {
+		log.Fatalf("Healthcheck failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Fatalf("Healthcheck failed: %s", res.Status)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+type servicePrefix struct {
+	repo    string
+	prefix  string
+	service string
+}
+
+type serviceMap struct {
+	prefixes []servicePrefix
+	teams    map[string]string
+}
+
+// SERVICES_FILE splits monorepos into services, with one line per path prefix:
+// prefix,service[,team]. The prefix applies to every repository, or to one
+// with repo:prefix, by name or name with owner. A file belongs to the service
+// of its longest prefix.
+func loadServices() *serviceMap {
+	path := getenv("SERVICES_FILE")
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fatalf(exitConfig, "Error opening SERVICES_FILE: %v", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		fatalf(exitConfig, "Error reading SERVICES_FILE: %v", err)
+	}
+
+	services := &serviceMap{teams: make(map[string]string)}
+	for i, record := range records {
+		if len(record) < 2 {
+			fatalf(exitConfig, "SERVICES_FILE line %d: expected prefix,service[,team]", i+1)
+		}
+
+		p := servicePrefix{
+			prefix:  strings.TrimPrefix(strings.TrimSpace(record[0]), "/"),
+			service: strings.TrimSpace(record[1]),
+		}
+		if repo, prefix, ok := strings.Cut(p.prefix, ":"); ok {
+			p.repo, p.prefix = strings.ToLower(repo), strings.TrimPrefix(prefix, "/")
+		}
+		services.prefixes = append(services.prefixes, p)
+
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			services.teams[p.service] = strings.TrimSpace(record[2])
+		}
+	}
+
+	return services
+}
+
+func (services *serviceMap) service(nameWithOwner, file string) (string, bool) {
+	_, name, _ := strings.Cut(nameWithOwner, "/")
+
+	var best *servicePrefix
+	for i, p := range services.prefixes {
+		if p.repo != "" && p.repo != strings.ToLower(nameWithOwner) && p.repo != strings.ToLower(name) {
+			continue
+		}
+		if strings.HasPrefix(file, p.prefix) && (best == nil || len(p.prefix) > len(best.prefix)) {
+			best = &services.prefixes[i]
+		}
+	}
+	if best == nil {
+		return "", false
+	}
+
+	return best.service, true
+}
+
+// The services whose files the PR changed, each once.
+func (services *serviceMap) of(pr pullRequest) map[string]int {
+	lines := make(map[string]int)
+	for _, file := range pr.Files.Nodes {
+		if service, ok := services.service(pr.Repository.NameWithOwner, file.Path); ok {
+			lines[service] += file.Additions + file.Deletions
+		}
+	}
+
+	return lines
+}
+
+// Throughput and cycle time per service. A PR counts for every service it
+// changed, so the rows do not add up to the PRs of the repositories.
+func servicesSection(prs []pullRequest, endDate time.Time, services *serviceMap) *reportSection {
+	type serviceStats struct {
+		prs        int
+		merged     int
+		cycleTimes []float64
+		authors    map[string]bool
+		lines      int
+	}
+
+	byService := make(map[string]*serviceStats)
+	unmapped, shared := 0, 0
+	for _, pr := range prs {
+		touched := services.of(pr)
+		switch {
+		case len(touched) == 0:
+			unmapped++
+		case len(touched) > 1:
+			shared++
+		}
+
+		for service, lines := range touched {
+			if byService[service] == nil {
+				byService[service] = &serviceStats{authors: make(map[string]bool)}
+			}
+
+			stats := byService[service]
+			stats.prs++
+			stats.lines += lines
+			stats.authors[pr.Author.Login] = true
+			if pr.stateAt(endDate) == prMerged {
+				stats.merged++
+				stats.cycleTimes = append(stats.cycleTimes, float64(pr.MergedAt.Sub(pr.CreatedAt)))
+			}
+		}
+	}
+
+	section := &reportSection{
+		Name:     "Services",
+		Title:    "Services",
+		Summary:  fmt.Sprintf("%d PRs changed several services and count for each, %d changed none of SERVICES_FILE", shared, unmapped),
+		Header:   table.Row{"Service", "Team", "Total PRs", "Merged PRs", "Merged PRs (%)", "Median cycle time", "Authors", "Changed lines"},
+		Centered: []int{3, 4, 5, 6, 7, 8},
+		Outputs:  make(map[string]string),
+	}
+
+	for _, service := range sortedKeys(byService) {
+		stats := byService[service]
+		section.Rows = append(section.Rows, table.Row{
+			service,
+			services.teams[service],
+			stats.prs,
+			stats.merged,
+			prCounts{prs: stats.prs, merged: stats.merged}.mergeRateCell(),
+			durationCell(stats.cycleTimes, 50),
+			len(stats.authors),
+			stats.lines,
+		})
+
+		output := "github_service_merged_prs_" + strings.ToLower(strings.ReplaceAll(service, " ", "_"))
+		section.Outputs[output] = fmt.Sprint(stats.merged)
+	}
+
+	return section
+}
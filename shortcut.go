@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type shortcutStory struct {
+	Id              int
+	Name            string
+	StoryType       string     `json:"story_type"`
+	OwnerIds        []string   `json:"owner_ids"`
+	WorkflowStateId int        `json:"workflow_state_id"`
+	StartedAt       *time.Time `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+}
+
+type shortcutClient struct {
+	baseUrl string
+	token   string
+	client  *http.Client
+}
+
+func (c *shortcutClient) get(path string, out interface{}) {
+	req, err := http.NewRequest("GET", c.baseUrl+path, nil)
+	if err != nil {
+		fatalf(exitConfig, "Error parsing SHORTCUT_URL: %v", err)
+	}
+
+	req.Header.Add("Shortcut-Token", c.token)
+	req.Header.Add("Accept", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		fatalf(exitError, "Error requesting %s to Shortcut: %v", path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		fatalf(exitAuth, "Shortcut rejected the credentials: %s", res.Status)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		fatalf(exitError, "Error requesting %s to Shortcut: %s", path, res.Status)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		fatalf(exitError, "Error decoding %s from Shortcut: %v", path, err)
+	}
+}
+
+// The names of the members and of the workflow states, by id.
+func (c *shortcutClient) names() (map[string]string, map[int]string) {
+	var members []struct {
+		Id      string
+		Profile struct {
+			Name        string
+			MentionName string `json:"mention_name"`
+		}
+	}
+	c.get("/api/v3/members", &members)
+
+	people := make(map[string]string)
+	for _, member := range members {
+		people[member.Id] = member.Profile.Name
+		if people[member.Id] == "" {
+			people[member.Id] = member.Profile.MentionName
+		}
+	}
+
+	var workflows []struct {
+		States []struct {
+			Id   int
+			Name string
+		}
+	}
+	c.get("/api/v3/workflows", &workflows)
+
+	states := make(map[int]string)
+	for _, workflow := range workflows {
+		for _, state := range workflow.States {
+			states[state.Id] = state.Name
+		}
+	}
+
+	return people, states
+}
+
+// The stories updated in the window, with SHORTCUT_QUERY narrowing the search,
+// e.g. "team:mobile". A story counts for its first owner, like a Jira issue
+// for its assignee, started and completed when Shortcut says so.
+func fetchShortcutStories(initialDate, endDate time.Time) ([]jiraIssue, bool) {
+	token := getenv("SHORTCUT_TOKEN")
+	if token == "" {
+		return nil, false
+	}
+
+	baseUrl := strings.TrimSuffix(getenv("SHORTCUT_URL"), "/")
+	if baseUrl == "" {
+		baseUrl = "https://api.app.shortcut.com"
+	}
+
+	c := &shortcutClient{baseUrl: baseUrl, token: token, client: newHTTPClient("SHORTCUT")}
+	people, states := c.names()
+
+	query := strings.TrimSpace(fmt.Sprintf("updated:%s..%s %s",
+		initialDate.Format("2006-01-02"), endDate.Format("2006-01-02"), getenv("SHORTCUT_QUERY")))
+
+	var issues []jiraIssue
+	next := "/api/v3/search/stories?" + url.Values{"query": {query}, "page_size": {"25"}}.Encode()
+	for page := 1; next != ""; page++ {
+		fmt.Fprintf(progress, "Requesting page %d of the stories to Shortcut\n", page)
+
+		var result struct {
+			Data []shortcutStory
+			Next string
+		}
+		c.get(next, &result)
+
+		for _, story := range result.Data {
+			var started, completed time.Time
+			if story.StartedAt != nil {
+				started = *story.StartedAt
+			}
+			if story.CompletedAt != nil {
+				completed = *story.CompletedAt
+			}
+			if len(story.OwnerIds) == 0 || started.IsZero() && completed.IsZero() {
+				continue
+			}
+
+			var issue jiraIssue
+			issue.Key = fmt.Sprintf("sc-%d", story.Id)
+			issue.Fields.Summary = story.Name
+			issue.Fields.Project.Key = "Shortcut"
+			issue.Fields.Assignee.DisplayName = people[story.OwnerIds[0]]
+			issue.Fields.Status.Name = states[story.WorkflowStateId]
+			if story.StoryType != "" {
+				issue.Fields.IssueType.Name = strings.ToUpper(story.StoryType[:1]) + story.StoryType[1:]
+			}
+			issue.setWorked(started, completed)
+
+			issue.Source = "shortcut"
+			issues = append(issues, issue)
+		}
+
+		next = result.Next
+	}
+
+	return issues, true
+}
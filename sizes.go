@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// The file list is cut at the first 100 files, or left out when GitHub timed
+// out, so the changes per file of these PRs are incomplete.
+func (pr pullRequest) sizeApproximate() bool {
+	return len(pr.Files.Nodes) < pr.ChangedFiles
+}
+
+// GraphQL can cap the counts of the PRs with huge diffs, which the REST API
+// reports in full.
+func githubRestSize(pr *pullRequest) {
+	var rest struct {
+		Additions    int
+		Deletions    int
+		ChangedFiles int `json:"changed_files"`
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d", githubApiUrl(), pr.Repository.NameWithOwner, pr.Number)
+	if err := githubGetJSON(url, &rest); err != nil {
+		log.Printf("Could not read the size of %s: %v", pr.Url, err)
+		return
+	}
+
+	pr.Additions = max(pr.Additions, rest.Additions)
+	pr.Deletions = max(pr.Deletions, rest.Deletions)
+	pr.ChangedFiles = max(pr.ChangedFiles, rest.ChangedFiles)
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+type snapshotSection struct {
+	Name    string
+	Title   string
+	Summary string
+	Header  []string
+	Rows    [][]string
+	Footer  []string
+	Outputs map[string]string
+}
+
+// A generated report as it was printed, plus who generated it and how, so the
+// numbers can be explained when they are questioned later.
+type snapshot struct {
+	Id          string
+	CreatedAt   time.Time
+	User        string
+	Host        string
+	Args        []string
+	Profile     string
+	InitialDate time.Time
+	EndDate     time.Time
+	Sections    []snapshotSection
+}
+
+type store struct {
+	dir     string
+	profile string
+}
+
+func openStore(profile string) *store {
+	dir := getenv("STORE_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "snapshots"), 0o755); err != nil {
+		fatalf(exitConfig, "Error creating STORE_DIR: %v", err)
+	}
+
+	return &store{dir: dir, profile: profile}
+}
+
+func cellStrings(row table.Row) []string {
+	var cells []string
+	for _, cell := range decorateRow(row, emojiCells) {
+		cells = append(cells, fmt.Sprint(cell))
+	}
+
+	return cells
+}
+
+func (s *store) save(rep *report) (*snapshot, error) {
+	return s.saveAt(rep, time.Now().UTC(), os.Args[1:])
+}
+
+// Imported reports are saved as created at the end of their window, so they
+// sort with the ones generated at the time.
+func (s *store) saveAt(rep *report, now time.Time, args []string) (*snapshot, error) {
+	snap := &snapshot{
+		Id:          now.Format("20060102-150405"),
+		CreatedAt:   now,
+		Args:        args,
+		Profile:     s.profile,
+		InitialDate: rep.InitialDate,
+		EndDate:     rep.EndDate,
+	}
+
+	if current, err := user.Current(); err == nil {
+		snap.User = current.Username
+	}
+	snap.Host, _ = os.Hostname()
+
+	for _, section := range rep.Sections {
+		saved := snapshotSection{
+			Name:    section.Name,
+			Title:   section.Title,
+			Summary: section.Summary,
+			Header:  cellStrings(section.Header),
+			Outputs: section.Outputs,
+		}
+
+		for _, row := range section.Rows {
+			saved.Rows = append(saved.Rows, cellStrings(row))
+		}
+
+		if section.Footer != nil {
+			saved.Footer = cellStrings(section.Footer)
+		}
+
+		snap.Sections = append(snap.Sections, saved)
+	}
+
+	content, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.snapshotPath(snap.Id)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		snap.Id = fmt.Sprintf("%s-%d", now.Format("20060102-150405"), i)
+		path = s.snapshotPath(snap.Id)
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return nil, err
+	}
+
+	return snap, s.purge()
+}
+
+func (s *store) snapshotPath(id string) string {
+	return filepath.Join(s.dir, "snapshots", id+".json")
+}
+
+func (s *store) load(id string) (*snapshot, error) {
+	content, err := os.ReadFile(s.snapshotPath(id))
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{}
+	return snap, json.Unmarshal(content, snap)
+}
+
+func (s *store) snapshots() ([]*snapshot, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "snapshots", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []*snapshot
+	for _, file := range files {
+		snap, err := s.load(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		snaps = append(snaps, snap)
+	}
+
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].CreatedAt.Before(snaps[j].CreatedAt)
+	})
+
+	return snaps, nil
+}
+
+func requireStore(s *store) *store {
+	if s == nil {
+		fatalf(exitConfig, "STORE_DIR not provided")
+	}
+
+	return s
+}
+
+func printHistory(s *store) {
+	snaps, err := requireStore(s).snapshots()
+	if err != nil {
+		log.Fatalf("Error reading the snapshots: %v", err)
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Id", "Created", "Window", "Profile", "User", "Host", "Sections", "Arguments"})
+
+	for _, snap := range snaps {
+		var sections []string
+		for _, section := range snap.Sections {
+			sections = append(sections, section.Name)
+		}
+
+		t.AppendRow(table.Row{
+			snap.Id,
+			snap.CreatedAt.Local().Format("2006-01-02 15:04"),
+			snap.InitialDate.Format("2006-01-02") + " - " + snap.EndDate.Format("2006-01-02"),
+			snap.Profile,
+			snap.User,
+			snap.Host,
+			strings.Join(sections, ", "),
+			strings.Join(snap.Args, " "),
+		})
+	}
+
+	fmt.Println(t.Render())
+}
+
+func rowKey(row []string) string {
+	if len(row) == 0 {
+		return ""
+	}
+
+	return row[0]
+}
+
+// Prints every cell that changed between two snapshots. Rows are matched by
+// their first column, which identifies the person or group.
+func printDiff(s *store, fromId, toId string) {
+	from, err := requireStore(s).load(fromId)
+	if err != nil {
+		fatalf(exitConfig, "Error loading snapshot %s: %v", fromId, err)
+	}
+
+	to, err := s.load(toId)
+	if err != nil {
+		fatalf(exitConfig, "Error loading snapshot %s: %v", toId, err)
+	}
+
+	fmt.Printf("%s (%s - %s) → %s (%s - %s)\n",
+		from.Id, from.InitialDate.Format("2006-01-02"), from.EndDate.Format("2006-01-02"),
+		to.Id, to.InitialDate.Format("2006-01-02"), to.EndDate.Format("2006-01-02"))
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Section", "Row", "Column", "Before", "After"})
+
+	sectionNames := make(map[string]bool)
+	var order []string
+	for _, snap := range []*snapshot{from, to} {
+		for _, section := range snap.Sections {
+			if !sectionNames[section.Name] {
+				sectionNames[section.Name] = true
+				order = append(order, section.Name)
+			}
+		}
+	}
+
+	changes := 0
+	for _, name := range order {
+		before, after := from.section(name), to.section(name)
+
+		if before == nil || after == nil {
+			state := "Only in " + to.Id
+			if after == nil {
+				state = "Only in " + from.Id
+			}
+			t.AppendRow(table.Row{name, "", "", state, ""})
+			changes++
+			continue
+		}
+
+		if before.Summary != after.Summary {
+			t.AppendRow(table.Row{name, "", "Summary", before.Summary, after.Summary})
+			changes++
+		}
+
+		beforeRows := make(map[string][]string)
+		for _, row := range before.Rows {
+			beforeRows[rowKey(row)] = row
+		}
+		afterRows := make(map[string][]string)
+		for _, row := range after.Rows {
+			afterRows[rowKey(row)] = row
+		}
+
+		for _, row := range before.Rows {
+			if _, ok := afterRows[rowKey(row)]; !ok {
+				t.AppendRow(table.Row{name, rowKey(row), "", "present", "missing"})
+				changes++
+			}
+		}
+
+		rows := append([][]string{}, after.Rows...)
+		if after.Footer != nil {
+			rows = append(rows, after.Footer)
+			beforeRows["Footer"] = before.Footer
+		}
+
+		for i, row := range rows {
+			key := rowKey(row)
+			if after.Footer != nil && i == len(rows)-1 {
+				key = "Footer"
+			}
+
+			previous, ok := beforeRows[key]
+			if !ok {
+				t.AppendRow(table.Row{name, key, "", "missing", "present"})
+				changes++
+				continue
+			}
+
+			for column := 1; column < len(row); column++ {
+				old := ""
+				if column < len(previous) {
+					old = previous[column]
+				}
+
+				if old != row[column] {
+					header := ""
+					if column < len(after.Header) {
+						header = after.Header[column]
+					}
+					t.AppendRow(table.Row{name, rowKey(row), header, old, row[column]})
+					changes++
+				}
+			}
+		}
+	}
+
+	if changes == 0 {
+		fmt.Println("No differences")
+		return
+	}
+
+	fmt.Println(t.Render())
+}
+
+func (snap *snapshot) section(name string) *snapshotSection {
+	for i := range snap.Sections {
+		if snap.Sections[i].Name == name {
+			return &snap.Sections[i]
+		}
+	}
+
+	return nil
+}
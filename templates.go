@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Set by --report-template or REPORT_TEMPLATE: the sections of the report, in
+// order. Empty shows every section.
+var reportTemplate []string
+
+// The report templates file maps each template to its sections, by name. Jira
+// also takes the sections per project, and Abandoned PRs and Churn their
+// sections by author and by repository, e.g.
+//
+//	{
+//	  "leadership": ["GitHub", "Trend", "PR aging"],
+//	  "team": ["Review response", "Review pairs", "Jira", "Jira WIP"]
+//	}
+func loadReportTemplate(name string) []string {
+	if name == "" {
+		name = getenv("REPORT_TEMPLATE")
+	}
+	if name == "" {
+		return nil
+	}
+
+	path := getenv("REPORT_TEMPLATES_FILE")
+	if path == "" {
+		for _, file := range configFiles("report-templates.json") {
+			if _, err := os.Stat(file); err == nil {
+				path = file
+				break
+			}
+		}
+	}
+
+	if path == "" {
+		fatalf(exitConfig, "No report templates file found. Set REPORT_TEMPLATES_FILE or create report-templates.json")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf(exitConfig, "Error opening REPORT_TEMPLATES_FILE: %v", err)
+	}
+
+	var templates map[string][]string
+	if err := json.Unmarshal(content, &templates); err != nil {
+		fatalf(exitConfig, "Error reading %s: %v", path, err)
+	}
+
+	sections, ok := templates[name]
+	if !ok {
+		var known []string
+		for templateName := range templates {
+			known = append(known, templateName)
+		}
+		sort.Strings(known)
+
+		fatalf(exitConfig, "Unknown report template %q. Templates in %s: %s", name, path, strings.Join(known, ", "))
+	}
+
+	known := knownSections()
+	for _, section := range sections {
+		if !known[section] && !strings.HasPrefix(section, "Jira ") {
+			fatalf(exitConfig, "Unknown section %q in report template %q. Sections: %s", section, name, strings.Join(sortedKeys(known), ", "))
+		}
+	}
+
+	return sections
+}
+
+// The sections -explain does not describe.
+var undescribedSections = []string{
+	"Abandoned PRs by author", "Abandoned PRs by repository", "Churn by author", "Churn by repository",
+	"Done without a merged PR", "Merged PRs without an issue", "New contributors", "Reviewers per PR",
+	"Skipped statuses by project", "Trend",
+}
+
+// The names a template can use: the sections of -explain and the others. The
+// sections per Jira project are named Jira <project>.
+func knownSections() map[string]bool {
+	known := make(map[string]bool)
+	for _, metric := range metricDefinitions {
+		if metric.section != "" {
+			known[metric.section] = true
+		}
+	}
+	for _, section := range undescribedSections {
+		known[section] = true
+	}
+
+	return known
+}
+
+// The sections of the template, in its order. The ones the data did not
+// produce, like Jira without JIRA_BASE_URL, are left out.
+func composeSections(sections []*reportSection, template []string) []*reportSection {
+	if len(template) == 0 {
+		return sections
+	}
+
+	var composed []*reportSection
+	used := make(map[*reportSection]bool)
+	for _, name := range template {
+		for _, s := range sections {
+			if !used[s] && (s.Name == name || s.Parent == name) {
+				composed = append(composed, s)
+				used[s] = true
+			}
+		}
+	}
+
+	return composed
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The data --template renders, e.g. for a wiki page:
+//
+//	h1. Pull metrics {{date .Start}} - {{date .End}}
+//	{{range .Sections}}{{if .Rows}}
+//	h2. {{.Title}}
+//	||{{join .Columns "||"}}||
+//	{{range .Rows}}|{{range .}}{{.Text}}|{{end}}
+//	{{end}}{{end}}{{end}}
+//
+// Start and End are the window. Activity is false when no section has rows.
+// Every section has its Name, as in the JSON output, a localized Title (its
+// Name when it has none), the Summary, the localized Columns, the Rows and the
+// Footer, whose cells have the Text of the tables, the Value of the JSON output
+// and a Flag of "warning" or "critical" when a threshold was crossed. Outputs
+// are the GitHub Actions outputs, and Definitions the -explain text of the
+// columns. The functions are join, upper, lower, replace, tr, date and
+// section, which finds a section by name.
+type templateReport struct {
+	Start    time.Time
+	End      time.Time
+	Activity bool
+	Sections []templateSection
+}
+
+type templateSection struct {
+	Name        string
+	Title       string
+	Summary     string
+	Columns     []string
+	Rows        [][]templateCell
+	Footer      []templateCell
+	Outputs     map[string]string
+	Definitions map[string]string
+}
+
+type templateCell struct {
+	Text  string
+	Value interface{}
+	Flag  string
+}
+
+func newTemplateCell(s *reportSection, column int, cell interface{}) templateCell {
+	c := templateCell{Text: fmt.Sprint(s.formatCell(column, cell)), Value: jsonCell(cell)}
+	if f, ok := cell.(flagged); ok {
+		c.Flag = "warning"
+		if f.level == critical {
+			c.Flag = "critical"
+		}
+	}
+
+	return c
+}
+
+func (r *report) templateData() templateReport {
+	data := templateReport{Start: r.InitialDate, End: r.EndDate, Activity: r.hasActivity()}
+
+	for _, s := range r.Sections {
+		section := templateSection{
+			Name:        s.Name,
+			Title:       tr(s.Title),
+			Summary:     s.Summary,
+			Outputs:     s.Outputs,
+			Definitions: make(map[string]string),
+		}
+		if section.Title == "" {
+			section.Title = tr(s.Name)
+		}
+
+		for _, column := range s.columns() {
+			section.Columns = append(section.Columns, tr(column))
+		}
+
+		for _, row := range s.Rows {
+			var cells []templateCell
+			for i, cell := range row {
+				cells = append(cells, newTemplateCell(s, i, cell))
+			}
+			section.Rows = append(section.Rows, cells)
+		}
+
+		for i, cell := range s.Footer {
+			section.Footer = append(section.Footer, newTemplateCell(s, i, cell))
+		}
+
+		for _, metric := range definitionsFor(s) {
+			section.Definitions[metric.column] = metric.definition
+		}
+
+		data.Sections = append(data.Sections, section)
+	}
+
+	return data
+}
+
+// Renders the report through the Go text/template in path, instead of one of
+// the formats.
+func (r *report) writeTemplate(w io.Writer, path string) error {
+	data := r.templateData()
+
+	functions := template.FuncMap{
+		"join":    strings.Join,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"replace": strings.ReplaceAll,
+		"tr":      tr,
+		"date":    formatDate,
+		"section": func(name string) *templateSection {
+			for i := range data.Sections {
+				if data.Sections[i].Name == name {
+					return &data.Sections[i]
+				}
+			}
+			return nil
+		},
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf(exitConfig, "Error reading the template: %v", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(functions).Parse(string(content))
+	if err != nil {
+		fatalf(exitConfig, "Error parsing the template: %v", err)
+	}
+
+	return tmpl.Execute(w, data)
+}
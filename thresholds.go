@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strconv"
+)
+
+type thresholdConfig struct {
+	minMergeRate float64
+	maxPRSize    float64
+	maxOwnership float64
+}
+
+// Off until MERGE_RATE_THRESHOLD, PR_SIZE_THRESHOLD or OWNERSHIP_THRESHOLD
+// sets them, so nothing is flagged, nor fails --fail-on thresholds, unasked.
+var thresholds thresholdConfig
+
+func loadThresholds() thresholdConfig {
+	config := thresholds
+
+	if value := getenv("MERGE_RATE_THRESHOLD"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fatalf(exitConfig, "Error parsing MERGE_RATE_THRESHOLD: %v", err)
+		}
+		config.minMergeRate = rate
+	}
+
+	if value := getenv("PR_SIZE_THRESHOLD"); value != "" {
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fatalf(exitConfig, "Error parsing PR_SIZE_THRESHOLD: %v", err)
+		}
+		config.maxPRSize = size
+	}
+
+	if value := getenv("OWNERSHIP_THRESHOLD"); value != "" {
+		share, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fatalf(exitConfig, "Error parsing OWNERSHIP_THRESHOLD: %v", err)
+		}
+		config.maxOwnership = share
+	}
+
+	return config
+}
+
+func (config thresholdConfig) mergeRate(rate percent) interface{} {
+	if config.minMergeRate > 0 && float64(rate) < config.minMergeRate {
+		return flagged{value: rate, level: critical}
+	}
+
+	return rate
+}
+
+// Flags the value when the average size (added plus removed lines) of the PRs
+// is over the threshold.
+func (config thresholdConfig) prSize(value, changedLines, prs int) interface{} {
+	if config.maxPRSize > 0 && value > 0 && prs > 0 && float64(changedLines)/float64(prs) > config.maxPRSize {
+		return flagged{value: value, level: warning}
+	}
+
+	return value
+}
+
+// Flags the areas where a single person authored most of the changes.
+func (config thresholdConfig) ownership(share percent) interface{} {
+	if config.maxOwnership > 0 && float64(share) > config.maxOwnership {
+		return flagged{value: share, level: warning}
+	}
+
+	return share
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The fields of a ticket, mapped from TICKETS_FIELDS.
+var ticketFields = []string{"key", "project", "assignee", "type", "status", "title", "started", "completed"}
+
+// TICKETS_FIELDS is a comma-separated list of field=path, e.g.
+// "key=$.number,assignee=$.assigned_to.display_value,completed=$.closed_at".
+// key, assignee and started or completed are required.
+func loadTicketFields() map[string]string {
+	fields := make(map[string]string)
+	for _, entry := range strings.Split(getenv("TICKETS_FIELDS"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		field, path, ok := strings.Cut(entry, "=")
+		field = strings.TrimSpace(field)
+		if !ok || !containsString(ticketFields, field) {
+			fatalf(exitConfig, "Invalid TICKETS_FIELDS entry %q: expected one of %s=<path>", entry, strings.Join(ticketFields, ", "))
+		}
+		fields[field] = strings.TrimSpace(path)
+	}
+
+	if fields["key"] == "" || fields["assignee"] == "" || fields["started"] == "" && fields["completed"] == "" {
+		fatalf(exitConfig, "TICKETS_FIELDS needs key, assignee and started or completed")
+	}
+
+	return fields
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The value at a JSONPath of dotted names and indexes, like
+// $.result[0].assigned_to.display_value.
+func jsonPath(value interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(path, "[", ".[")
+
+	for _, step := range strings.Split(path, ".") {
+		if step == "" {
+			continue
+		}
+
+		if strings.HasPrefix(step, "[") && strings.HasSuffix(step, "]") {
+			index, err := strconv.Atoi(step[1 : len(step)-1])
+			list, ok := value.([]interface{})
+			if err != nil || !ok || index < 0 || index >= len(list) {
+				return nil, false
+			}
+			value = list[index]
+			continue
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[step]; !ok {
+			return nil, false
+		}
+	}
+
+	return value, value != nil
+}
+
+// A string or a number at the path, or "".
+func jsonPathString(value interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	switch v, _ := jsonPath(value, path); v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return ""
+}
+
+// The date layouts of the usual ticket systems: ISO 8601, Jira's, and
+// ServiceNow's, in UTC.
+var ticketTimeLayouts = []string{time.RFC3339, jiraTimeLayout, "2006-01-02 15:04:05", "2006-01-02"}
+
+func parseTicketTime(value string) (time.Time, bool) {
+	for _, layout := range ticketTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Gives the issue of another tracker a changelog that moves it into the first
+// active status when it was started and to Done when it was completed, so it
+// counts in the Jira report. The zero times are skipped.
+func (issue *jiraIssue) setWorked(started, completed time.Time) {
+	active := jiraActiveStatuses()[0]
+	if issue.Fields.Status.Name == "" {
+		issue.Fields.Status.Name = active
+	}
+
+	type item = struct {
+		Field      string
+		FromString string
+		ToString   string
+	}
+
+	move := func(at time.Time, from, to string) {
+		history := struct {
+			Author struct {
+				DisplayName string
+			}
+			Created string
+			Items   []item
+		}{Created: at.Format(jiraTimeLayout), Items: []item{{"status", from, to}}}
+		history.Author.DisplayName = issue.Fields.Assignee.DisplayName
+		issue.Changelog.Histories = append(issue.Changelog.Histories, history)
+	}
+
+	from := ""
+	if !started.IsZero() {
+		move(started, "", active)
+		issue.Fields.Created = started.Format(jiraTimeLayout)
+		from = active
+	}
+
+	if !completed.IsZero() {
+		move(completed, from, "Done")
+		issue.Fields.Status.Name = "Done"
+		issue.Fields.ResolutionDate = completed.Format(jiraTimeLayout)
+	}
+}
+
+// Maps a ticket to a Jira issue.
+func ticketIssue(ticket interface{}, fields map[string]string) (jiraIssue, bool) {
+	var issue jiraIssue
+
+	issue.Key = jsonPathString(ticket, fields["key"])
+	if issue.Key == "" {
+		return issue, false
+	}
+
+	issue.Fields.Summary = jsonPathString(ticket, fields["title"])
+	issue.Fields.Project.Key = jsonPathString(ticket, fields["project"])
+	if issue.Fields.Project.Key == "" {
+		issue.Fields.Project.Key = "Tickets"
+	}
+	issue.Fields.Assignee.DisplayName = jsonPathString(ticket, fields["assignee"])
+	issue.Fields.IssueType.Name = jsonPathString(ticket, fields["type"])
+	if issue.Fields.IssueType.Name == "" {
+		issue.Fields.IssueType.Name = "Ticket"
+	}
+
+	issue.Fields.Status.Name = jsonPathString(ticket, fields["status"])
+
+	started, isStarted := parseTicketTime(jsonPathString(ticket, fields["started"]))
+	completed, isCompleted := parseTicketTime(jsonPathString(ticket, fields["completed"]))
+	issue.setWorked(started, completed)
+
+	return issue, isStarted || isCompleted
+}
+
+// TICKETS_URL is a REST endpoint answering the tickets worked in the window,
+// like a ServiceNow table or a Redmine issues search. {from} and {to} are
+// replaced by the dates, and {offset}, {page} and {limit} page through it.
+func fetchTickets(initialDate, endDate time.Time) ([]jiraIssue, bool) {
+	endpoint := getenv("TICKETS_URL")
+	if endpoint == "" {
+		return nil, false
+	}
+
+	fields := loadTicketFields()
+	itemsPath := getenv("TICKETS_ITEMS")
+	pageSize := envInt("TICKETS_PAGE_SIZE", 100)
+
+	auth := getenv("TICKETS_AUTH")
+	if user := getenv("TICKETS_USER"); auth == "" && user != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+getenv("TICKETS_TOKEN")))
+	}
+
+	client := newHTTPClient("TICKETS")
+	paged := strings.Contains(endpoint, "{offset}") || strings.Contains(endpoint, "{page}")
+
+	var issues []jiraIssue
+	for page := 1; ; page++ {
+		pageUrl := strings.NewReplacer(
+			"{from}", initialDate.Format("2006-01-02"),
+			"{to}", endDate.Format("2006-01-02"),
+			"{offset}", fmt.Sprint((page-1)*pageSize),
+			"{page}", fmt.Sprint(page),
+			"{limit}", fmt.Sprint(pageSize),
+		).Replace(endpoint)
+
+		fmt.Fprintf(progress, "Requesting page %d of the tickets\n", page)
+		body := requestTickets(client, pageUrl, auth)
+
+		items, ok := body, true
+		if itemsPath != "" {
+			items, ok = jsonPath(body, itemsPath)
+		}
+		tickets, isList := items.([]interface{})
+		if itemsPath == "" && !isList {
+			fatalf(exitConfig, "The top level of the TICKETS_URL response is not a list, set TICKETS_ITEMS to the path of the tickets in it")
+		}
+		if !ok || !isList {
+			fatalf(exitConfig, "TICKETS_ITEMS %q is not a list in the answer of TICKETS_URL", itemsPath)
+		}
+
+		for _, ticket := range tickets {
+			if issue, ok := ticketIssue(ticket, fields); ok {
+				issue.Source = "tickets"
+				issues = append(issues, issue)
+			}
+		}
+
+		if !paged || len(tickets) < pageSize {
+			break
+		}
+	}
+
+	return issues, true
+}
+
+// One page of TICKETS_URL, decoded.
+func requestTickets(client *http.Client, pageUrl, auth string) interface{} {
+	req, err := http.NewRequest("GET", pageUrl, nil)
+	if err != nil {
+		fatalf(exitConfig, "Error parsing TICKETS_URL: %v", err)
+	}
+
+	if auth != "" {
+		req.Header.Add("Authorization", auth)
+	}
+	req.Header.Add("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		fatalf(exitError, "Error requesting the tickets: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		fatalf(exitAuth, "The ticket system rejected the credentials: %s", res.Status)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		fatalf(exitError, "Error requesting the tickets: %s", res.Status)
+	}
+
+	var body interface{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		fatalf(exitError, "Error decoding the tickets: %v", err)
+	}
+
+	return body
+}
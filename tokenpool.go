@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GITHUB_TOKEN and the comma-separated GITHUB_TOKENS, for the scans that need
+// more than the hourly budget of a single token.
+func githubTokens() []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, token := range append([]string{getenv("GITHUB_TOKEN")}, strings.Split(getenv("GITHUB_TOKENS"), ",")...) {
+		if token = strings.TrimSpace(token); token != "" && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+type pooledToken struct {
+	token     string
+	remaining int
+	resetAt   time.Time
+}
+
+// Sends every request with the token with the most rate limit points left,
+// as the responses of GitHub tell, waiting for the first reset when all of
+// them are down to GITHUB_RATE_LIMIT_RESERVE.
+type tokenPoolTransport struct {
+	mutex  sync.Mutex
+	tokens []*pooledToken
+	next   http.RoundTripper
+}
+
+var (
+	tokenPool     *tokenPoolTransport
+	tokenPoolOnce sync.Once
+)
+
+// The client of the reads of the report, which takes its token from the pool.
+// Publishing keeps the identity of its own token.
+func githubPoolClient() *http.Client {
+	base := newHTTPClient("GITHUB")
+
+	tokenPoolOnce.Do(func() {
+		tokenPool = &tokenPoolTransport{next: base.Transport}
+		for _, token := range githubTokens() {
+			tokenPool.tokens = append(tokenPool.tokens, &pooledToken{token: token, remaining: -1})
+		}
+		if len(tokenPool.tokens) > 1 {
+			fmt.Fprintf(progress, "Rotating across %d GitHub tokens\n", len(tokenPool.tokens))
+		}
+	})
+
+	return &http.Client{Transport: tokenPool, Timeout: base.Timeout}
+}
+
+// The points left are unknown before the first response and after the reset.
+func (t *tokenPoolTransport) pick() *pooledToken {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for {
+		var best, first *pooledToken
+		for _, token := range t.tokens {
+			if !token.resetAt.IsZero() && time.Now().After(token.resetAt) {
+				token.remaining, token.resetAt = -1, time.Time{}
+			}
+
+			if token.remaining < 0 || token.remaining > githubRateLimitReserve {
+				if best == nil || (best.remaining >= 0 && (token.remaining < 0 || token.remaining > best.remaining)) {
+					best = token
+				}
+			} else if first == nil || token.resetAt.Before(first.resetAt) {
+				first = token
+			}
+		}
+
+		if best != nil || first == nil || first.resetAt.IsZero() {
+			if best == nil {
+				best = first
+			}
+			return best
+		}
+
+		wait := time.Until(first.resetAt) + time.Minute
+		fmt.Fprintf(progress, "Every GitHub token is out of rate limit points, waiting %v for the reset\n", wait.Round(time.Second))
+		t.mutex.Unlock()
+		time.Sleep(wait)
+		t.mutex.Lock()
+	}
+}
+
+func (t *tokenPoolTransport) update(token *pooledToken, res *http.Response) {
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	token.remaining = remaining
+	if reset > 0 {
+		token.resetAt = time.Unix(reset, 0)
+	}
+}
+
+func (t *tokenPoolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.tokens) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		token := t.pick()
+
+		attemptReq := req.Clone(req.Context())
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+		attemptReq.Header.Set("Authorization", "Bearer "+token.token)
+
+		res, err := t.next.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		t.update(token, res)
+
+		// A token out of points is answered 403 or 429, which the next one
+		// may not be.
+		exhausted := (res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests) && res.Header.Get("X-RateLimit-Remaining") == "0"
+		if !exhausted || attempt+1 >= len(t.tokens) || (req.Body != nil && req.GetBody == nil) {
+			return res, nil
+		}
+		res.Body.Close()
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// A review or comment sent to the scorer, and the score it answers with,
+// from -1 (negative) to 1 (positive).
+type toneText struct {
+	Id   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type toneScore struct {
+	Id    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// The scores of the reviews and comments, by toneKey. Empty unless
+// SENTIMENT_COMMAND or SENTIMENT_URL is set. Replaced as a whole on every
+// fetch, as serve refreshes while reports are built.
+var (
+	commentScores = map[string]float64{}
+	scoresMutex   sync.Mutex
+)
+
+func toneKey(pr pullRequest, kind string, i int) string {
+	return fmt.Sprintf("%s#%s-%d", pr.Url, kind, i)
+}
+
+// The texts of the reviews and comments of the PRs, with their dates.
+func toneTexts(prs []pullRequest, visit func(pr pullRequest, key string, at time.Time, text string)) {
+	for _, pr := range prs {
+		for i, review := range pr.Reviews.Nodes {
+			if review.Body != "" {
+				visit(pr, toneKey(pr, "review", i), review.SubmittedAt, review.Body)
+			}
+		}
+		for i, comment := range pr.Comments.Nodes {
+			if comment.Body != "" {
+				visit(pr, toneKey(pr, "comment", i), comment.CreatedAt, comment.Body)
+			}
+		}
+	}
+}
+
+// Scores the tone of the reviews and comments with SENTIMENT_COMMAND, which
+// runs through the shell with a JSON array of {id, text} on stdin and prints
+// an array of {id, score}, or with SENTIMENT_URL, which receives the same
+// array in a POST and answers with the scores.
+func scoreComments(prs []pullRequest) {
+	command := getenv("SENTIMENT_COMMAND")
+	url := getenv("SENTIMENT_URL")
+	if command == "" && url == "" {
+		return
+	}
+
+	var texts []toneText
+	toneTexts(prs, func(_ pullRequest, key string, _ time.Time, text string) {
+		texts = append(texts, toneText{key, text})
+	})
+	if len(texts) == 0 {
+		return
+	}
+
+	scores, err := sentimentScores(command, url, texts)
+	if err != nil {
+		scoresMutex.Lock()
+		commentScores = map[string]float64{}
+		scoresMutex.Unlock()
+
+		warnPartial("Error scoring the tone of the reviews and comments, skipping the tone sections: %v\n", err)
+		return
+	}
+
+	byKey := make(map[string]float64, len(scores))
+	for _, score := range scores {
+		byKey[score.Id] = score.Score
+	}
+
+	scoresMutex.Lock()
+	commentScores = byKey
+	scoresMutex.Unlock()
+
+	fmt.Fprintf(progress, "Tone of %d reviews and comments scored\n", len(scores))
+}
+
+func sentimentScores(command, url string, texts []toneText) ([]toneScore, error) {
+	content, err := json.Marshal(texts)
+	if err != nil {
+		return nil, fmt.Errorf("encoding the comments: %v", err)
+	}
+
+	var output []byte
+	if command != "" {
+		output, err = runSentimentCommand(command, content)
+	} else {
+		output, err = postSentiment(url, content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []toneScore
+	if err := json.Unmarshal(output, &scores); err != nil {
+		return nil, fmt.Errorf("parsing the scores: %v", err)
+	}
+
+	return scores, nil
+}
+
+func runSentimentCommand(command string, content []byte) ([]byte, error) {
+	shell, option := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, option = "cmd", "/C"
+	}
+
+	cmd := exec.Command(shell, option, command)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running SENTIMENT_COMMAND: %v", err)
+	}
+
+	return output, nil
+}
+
+func postSentiment(url string, content []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing SENTIMENT_URL: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token := getenv("SENTIMENT_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := newHTTPClient("SENTIMENT").Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("posting the comments to SENTIMENT_URL: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("posting the comments to SENTIMENT_URL: %s", res.Status)
+	}
+
+	var output bytes.Buffer
+	if _, err := output.ReadFrom(res.Body); err != nil {
+		return nil, fmt.Errorf("reading the scores: %v", err)
+	}
+
+	return output.Bytes(), nil
+}
+
+// The score under which a review or comment is negative.
+func negativeTone() float64 {
+	value := getenv("SENTIMENT_NEGATIVE")
+	if value == "" {
+		return -0.5
+	}
+
+	score, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		fatalf(exitConfig, "Error parsing SENTIMENT_NEGATIVE: %v", err)
+	}
+
+	return score
+}
+
+// The tone of the scored reviews and comments written by the end date, per
+// repository and thread. Repositories are flagged when their share of
+// negative ones is twice the overall share. Nil when nothing was scored.
+func toneSections(prs []pullRequest, endDate time.Time) []*reportSection {
+	scoresMutex.Lock()
+	scores := commentScores
+	scoresMutex.Unlock()
+
+	if len(scores) == 0 {
+		return nil
+	}
+
+	type tone struct {
+		scores   []float64
+		negative int
+		lowest   float64
+	}
+
+	negativeBelow := negativeTone()
+	byRepo := make(map[string]*tone)
+	byThread := make(map[string]*tone)
+	threadRepo := make(map[string]string)
+	var overall tone
+
+	toneTexts(prs, func(pr pullRequest, key string, at time.Time, _ string) {
+		score, ok := scores[key]
+		if !ok || at.After(endDate) {
+			return
+		}
+
+		repo := pr.Repository.NameWithOwner
+		if byRepo[repo] == nil {
+			byRepo[repo] = &tone{lowest: score}
+		}
+		if byThread[pr.Url] == nil {
+			byThread[pr.Url] = &tone{lowest: score}
+			threadRepo[pr.Url] = repo
+		}
+
+		for _, t := range []*tone{byRepo[repo], byThread[pr.Url], &overall} {
+			t.scores = append(t.scores, score)
+			if score < negativeBelow {
+				t.negative++
+			}
+			if score < t.lowest {
+				t.lowest = score
+			}
+		}
+	})
+
+	if len(overall.scores) == 0 {
+		return nil
+	}
+
+	repos := &reportSection{
+		Name:     "Review tone",
+		Title:    "Review tone",
+		Header:   table.Row{"Repository", "Scored", "Negative", "Negative (%)", "Median score"},
+		Centered: []int{2, 3, 4, 5},
+	}
+
+	var names []string
+	for repo := range byRepo {
+		names = append(names, repo)
+	}
+	sort.Strings(names)
+
+	overallShare := float64(overall.negative) / float64(len(overall.scores))
+	for _, repo := range names {
+		t := byRepo[repo]
+
+		var share interface{} = percentCell(t.negative, len(t.scores))
+		if t.negative > 0 && float64(t.negative)/float64(len(t.scores)) >= 2*overallShare {
+			share = flagged{share, warning}
+		}
+
+		repos.Rows = append(repos.Rows, table.Row{repo, len(t.scores), t.negative, share, average(median(t.scores))})
+	}
+
+	var threads []string
+	for url, t := range byThread {
+		if t.negative > 0 {
+			threads = append(threads, url)
+		}
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		a, b := byThread[threads[i]], byThread[threads[j]]
+		if a.negative != b.negative {
+			return a.negative > b.negative
+		}
+		return threads[i] < threads[j]
+	})
+	if len(threads) > 10 {
+		threads = threads[:10]
+	}
+
+	negative := &reportSection{
+		Name:     "Negative threads",
+		Title:    "Most negative threads",
+		Header:   table.Row{"URL", "Repository", "Negative", "Lowest score"},
+		Centered: []int{3, 4},
+	}
+	for _, url := range threads {
+		t := byThread[url]
+		negative.Rows = append(negative.Rows, table.Row{url, threadRepo[url], t.negative, average(t.lowest)})
+	}
+
+	return []*reportSection{repos, negative}
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+const (
+	tuiBrowsing = iota
+	tuiFiltering
+	tuiEditingRange
+	tuiDrilling
+)
+
+type tuiModel struct {
+	minDate     time.Time
+	maxDate     time.Time
+	initialDate time.Time
+	endDate     time.Time
+
+	build    func(initialDate, endDate time.Time) []*reportSection
+	prs      []pullRequest
+	sections []*reportSection
+
+	current    int
+	sortColumn int
+	sortDesc   bool
+	filter     string
+	selected   int
+	offset     int
+
+	mode    int
+	input   string
+	drill   string
+	message string
+
+	height int
+}
+
+func runTUI(initialDate, endDate time.Time, prs []pullRequest, build func(initialDate, endDate time.Time) []*reportSection) {
+	m := &tuiModel{
+		minDate:     initialDate,
+		maxDate:     endDate,
+		initialDate: initialDate,
+		endDate:     endDate,
+		build:       build,
+		prs:         prs,
+		sections:    build(initialDate, endDate),
+		sortColumn:  -1,
+		height:      24,
+	}
+
+	if len(m.sections) == 0 {
+		log.Fatal("Nothing to browse: both reports were skipped")
+	}
+
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		log.Fatalf("Error running the interactive mode: %v", err)
+	}
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		switch m.mode {
+		case tuiFiltering, tuiEditingRange:
+			return m, m.updateInput(msg)
+		case tuiDrilling:
+			return m, m.updateDrill(msg)
+		default:
+			return m, m.updateBrowsing(msg)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) updateBrowsing(msg tea.KeyMsg) tea.Cmd {
+	rows := m.visibleRows()
+
+	switch msg.String() {
+	case "q", "esc":
+		return tea.Quit
+	case "tab", "right", "l":
+		m.switchSection(1)
+	case "shift+tab", "left", "h":
+		m.switchSection(-1)
+	case "down", "j":
+		if m.selected < len(rows)-1 {
+			m.selected++
+		}
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "s":
+		m.sortColumn++
+		if m.sortColumn >= len(m.sections[m.current].Header) {
+			m.sortColumn = -1
+		}
+	case "r":
+		m.sortDesc = !m.sortDesc
+	case "/":
+		m.mode = tuiFiltering
+		m.input = m.filter
+	case "d":
+		m.mode = tuiEditingRange
+		m.input = m.initialDate.Format("2006-01-02") + " " + m.endDate.Format("2006-01-02")
+	case "enter":
+		if m.selected < len(rows) {
+			login := fmt.Sprint(rows[m.selected][0])
+			if len(m.personPRs(login)) > 0 {
+				m.mode = tuiDrilling
+				m.drill = login
+				m.offset = 0
+			} else {
+				m.message = "No pull requests for " + login
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *tuiModel) updateInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = tuiBrowsing
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	case tea.KeyEnter:
+		if m.mode == tuiFiltering {
+			m.filter = strings.TrimSpace(m.input)
+			m.selected = 0
+		} else {
+			m.applyRange(m.input)
+		}
+		m.mode = tuiBrowsing
+	}
+
+	return nil
+}
+
+func (m *tuiModel) updateDrill(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "esc", "backspace", "enter":
+		m.mode = tuiBrowsing
+	case "down", "j":
+		if m.offset < len(m.personPRs(m.drill))-1 {
+			m.offset++
+		}
+	case "up", "k":
+		if m.offset > 0 {
+			m.offset--
+		}
+	}
+
+	return nil
+}
+
+func (m *tuiModel) switchSection(delta int) {
+	m.current = (m.current + delta + len(m.sections)) % len(m.sections)
+	m.sortColumn = -1
+	m.selected = 0
+}
+
+// The data is only fetched once, so the range can only shrink inside the dates
+// given in the command line.
+func (m *tuiModel) applyRange(input string) {
+	fields := strings.Fields(input)
+	if len(fields) != 2 {
+		m.message = "Expected <start date> <end date>"
+		return
+	}
+
+	initialDate, err := time.Parse("2006-1-2", fields[0])
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+
+	endDate, err := time.Parse("2006-1-2", fields[1])
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	endDate = endDate.Add(time.Hour*24 - time.Second)
+
+	if initialDate.Before(m.minDate) {
+		initialDate = m.minDate
+	}
+	if endDate.After(m.maxDate) {
+		endDate = m.maxDate
+	}
+
+	if !endDate.After(initialDate) {
+		m.message = "The range is empty"
+		return
+	}
+
+	m.initialDate, m.endDate = initialDate, endDate
+	m.sections = m.build(initialDate, endDate)
+	m.message = ""
+	m.selected = 0
+
+	if m.current >= len(m.sections) {
+		m.current = 0
+	}
+}
+
+func (m *tuiModel) visibleRows() []table.Row {
+	section := m.sections[m.current]
+
+	var rows []table.Row
+	for _, row := range section.Rows {
+		if m.filter == "" || m.matchesFilter(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	if m.sortColumn >= 0 {
+		sort.SliceStable(rows, func(i, j int) bool {
+			if m.sortDesc {
+				return compareCells(rows[j][m.sortColumn], rows[i][m.sortColumn]) < 0
+			}
+			return compareCells(rows[i][m.sortColumn], rows[j][m.sortColumn]) < 0
+		})
+	}
+
+	return rows
+}
+
+func (m *tuiModel) matchesFilter(row table.Row) bool {
+	filter := strings.ToLower(m.filter)
+
+	// Only the leading text columns identify the person.
+	for _, cell := range row {
+		value, ok := cell.(string)
+		if !ok {
+			break
+		}
+
+		if strings.Contains(strings.ToLower(value), filter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cellNumber(cell interface{}) (float64, bool) {
+	switch v := cell.(type) {
+	case flagged:
+		return cellNumber(v.value)
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case percent:
+		return float64(v), true
+	case average:
+		return float64(v), true
+	case duration:
+		return float64(v), true
+	case time.Duration:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func compareCells(a, b interface{}) int {
+	x, okX := cellNumber(a)
+	y, okY := cellNumber(b)
+
+	switch {
+	case okX && okY:
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case okX:
+		return 1
+	case okY:
+		return -1
+	}
+
+	return strings.Compare(strings.ToLower(fmt.Sprint(a)), strings.ToLower(fmt.Sprint(b)))
+}
+
+func (m *tuiModel) personPRs(login string) []pullRequest {
+	var prs []pullRequest
+	for _, pr := range m.prs {
+		if pr.Author.Login == login && pr.CreatedAt.After(m.initialDate) && !pr.CreatedAt.After(m.endDate) {
+			prs = append(prs, pr)
+		}
+	}
+
+	return prs
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	for i, section := range m.sections {
+		name := section.Name
+		if name == "" {
+			name = fmt.Sprintf("Section %d", i+1)
+		}
+
+		if i == m.current {
+			b.WriteString(text.Colors{text.Bold, text.ReverseVideo}.Sprint(" " + name + " "))
+		} else {
+			b.WriteString(" " + name + " ")
+		}
+		b.WriteString(" ")
+	}
+
+	fmt.Fprintf(&b, "\n%s - %s", m.initialDate.Format("2006-01-02"), m.endDate.Format("2006-01-02"))
+	if m.filter != "" {
+		fmt.Fprintf(&b, "  filter: %s", m.filter)
+	}
+	b.WriteString("\n\n")
+
+	if m.mode == tuiDrilling {
+		b.WriteString(m.drillView())
+	} else {
+		b.WriteString(m.tableView())
+	}
+
+	b.WriteString("\n")
+
+	switch m.mode {
+	case tuiFiltering:
+		fmt.Fprintf(&b, "Filter: %s█", m.input)
+	case tuiEditingRange:
+		fmt.Fprintf(&b, "Range (start end): %s█", m.input)
+	case tuiDrilling:
+		b.WriteString("↑/↓ scroll • esc back • q quit")
+	default:
+		if m.message != "" {
+			b.WriteString(m.message + "\n")
+		}
+		b.WriteString("tab/←/→ switch table • ↑/↓ select • enter PRs • s sort • r reverse • / filter • d date range • q quit")
+	}
+
+	return b.String()
+}
+
+func (m *tuiModel) tableView() string {
+	section := m.sections[m.current]
+	rows := m.visibleRows()
+
+	if m.selected >= len(rows) {
+		m.selected = len(rows) - 1
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+
+	// Leave room for the tabs, the borders and the help line.
+	capacity := m.height - 12
+	if capacity < 3 {
+		capacity = 3
+	}
+
+	first := 0
+	if m.selected >= capacity {
+		first = m.selected - capacity + 1
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	header := localizedHeader(section.Header)
+	if m.sortColumn >= 0 {
+		arrow := "▲"
+		if m.sortDesc {
+			arrow = "▼"
+		}
+		header[m.sortColumn] = fmt.Sprintf("%v %s", header[m.sortColumn], arrow)
+	}
+	t.AppendHeader(header)
+
+	for i := first; i < len(rows) && i < first+capacity; i++ {
+		row := decorateRow(rows[i], colorCells)
+
+		if i == m.selected {
+			for j := range row {
+				row[j] = text.Colors{text.ReverseVideo}.Sprint(fmt.Sprint(row[j]))
+			}
+		}
+
+		t.AppendRow(row)
+	}
+
+	if section.Footer != nil {
+		t.AppendFooter(localizedFooter(section.Footer))
+	}
+
+	var configs []table.ColumnConfig
+	for _, number := range section.Centered {
+		configs = append(configs, table.ColumnConfig{Number: number, Align: text.AlignCenter, AlignFooter: text.AlignCenter})
+	}
+	t.SetColumnConfigs(configs)
+
+	var b strings.Builder
+	if section.Title != "" {
+		b.WriteString(tr(section.Title) + "\n")
+	}
+	if section.Summary != "" {
+		b.WriteString(section.Summary + "\n")
+	}
+	b.WriteString(t.Render())
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func (m *tuiModel) drillView() string {
+	prs := m.personPRs(m.drill)
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.SetTitle("%s %s", m.drill, names[m.drill])
+	t.AppendHeader(table.Row{"Created", "State", "Added", "Removed", "Title", "URL"})
+
+	capacity := m.height - 12
+	if capacity < 3 {
+		capacity = 3
+	}
+
+	for i := m.offset; i < len(prs) && i < m.offset+capacity; i++ {
+		pr := prs[i]
+
+		t.AppendRow(table.Row{
+			pr.CreatedAt.Format("2006-01-02"),
+			pr.stateAt(m.endDate),
+			pr.Additions,
+			pr.Deletions,
+			text.Trim(pr.Title, 60),
+			pr.Url,
+		})
+	}
+
+	return t.Render() + "\n"
+}
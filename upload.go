@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var uploadContentTypes = map[string]string{
+	formatHTML:     "text/html; charset=utf-8",
+	formatCSV:      "text/csv; charset=utf-8",
+	formatJSON:     "application/json",
+	formatMarkdown: "text/markdown; charset=utf-8",
+}
+
+var uploadExtensions = map[string]string{
+	formatHTML:     "html",
+	formatCSV:      "csv",
+	formatJSON:     "json",
+	formatMarkdown: "md",
+}
+
+// Uploads the report to REPORT_BUCKET, s3://bucket/prefix or gs://bucket/prefix,
+// as <prefix>/<yyyy>/<mm>/<dd>/pull-metrics-<start>-<end>.<ext> in each of
+// REPORT_BUCKET_FORMATS.
+func uploadReport(rep *report) {
+	bucketUrl := getenv("REPORT_BUCKET")
+	if bucketUrl == "" {
+		return
+	}
+
+	bucket, err := url.Parse(bucketUrl)
+	if err != nil || bucket.Host == "" || (bucket.Scheme != "s3" && bucket.Scheme != "gs") {
+		fatalf(exitConfig, "Invalid REPORT_BUCKET %q: expected s3://bucket/prefix or gs://bucket/prefix", bucketUrl)
+	}
+
+	formats := getenv("REPORT_BUCKET_FORMATS")
+	if formats == "" {
+		formats = "html,csv,json"
+	}
+
+	prefix := strings.Trim(bucket.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	prefix += time.Now().UTC().Format("2006/01/02") + "/"
+	name := fmt.Sprintf("pull-metrics-%s-%s", rep.InitialDate.Format("2006-01-02"), rep.EndDate.Format("2006-01-02"))
+
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.TrimSpace(format)
+		extension, ok := uploadExtensions[format]
+		if !ok {
+			fatalf(exitConfig, "Unknown format %q in REPORT_BUCKET_FORMATS", format)
+		}
+
+		var content bytes.Buffer
+		if err := rep.write(&content, format, terminalOptions{}); err != nil {
+			fatalf(exitError, "Error writing the report: %v", err)
+		}
+
+		key := prefix + name + "." + extension
+		if bucket.Scheme == "s3" {
+			err = uploadToS3(bucket.Host, key, uploadContentTypes[format], content.Bytes())
+		} else {
+			err = uploadToGCS(bucket.Host, key, uploadContentTypes[format], content.Bytes())
+		}
+		if err != nil {
+			fatalf(exitError, "Error uploading the report: %v", err)
+		}
+
+		fmt.Fprintf(progress, "Report uploaded to %s://%s/%s\n", bucket.Scheme, bucket.Host, key)
+	}
+}
+
+// S3_ENDPOINT points to S3 compatible storage, like MinIO, with path-style
+// URLs.
+func uploadToS3(bucket, key, contentType string, content []byte) error {
+	credentials, err := awsCredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	target := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, credentials.region, key)
+	if endpoint := getenv("S3_ENDPOINT"); endpoint != "" {
+		target = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + key
+	}
+
+	req, err := http.NewRequest("PUT", target, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	credentials.sign(req, "s3", content)
+
+	return sendUpload(req, "S3")
+}
+
+func uploadToGCS(bucket, key, contentType string, content []byte) error {
+	token, err := gcpAccessToken()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"uploadType": {"media"}, "name": {key}}
+	req, err := http.NewRequest("POST", "https://storage.googleapis.com/upload/storage/v1/b/"+url.PathEscape(bucket)+"/o?"+query.Encode(), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return sendUpload(req, "Google Cloud Storage")
+}
+
+func sendUpload(req *http.Request, service string) error {
+	res, err := newHTTPClient("UPLOAD").Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		message, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("%s returned %s: %s", service, res.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
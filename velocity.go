@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// The merged PRs of every roster team per period of REPORTING_CALENDAR, next to
+// the days its people were around, so a team down to half its capacity is not
+// read as half as fast.
+func velocitySection(prs []pullRequest, initialDate, endDate time.Time, people overlays) *reportSection {
+	section := &reportSection{
+		Name:     "Velocity",
+		Title:    "Velocity",
+		Summary:  "Merged PRs per team and period over the working days of its people, without the holidays and absences",
+		Header:   table.Row{"Team", "Period", "People", "Working days", "Available days", "Capacity (%)", "Merged PRs", "Merged PRs / person-week", "Merged PRs at full capacity"},
+		Centered: []int{3, 4, 5, 6, 7, 8, 9},
+	}
+
+	r := people.roster
+	if r == nil || !r.hasTeams() {
+		return section
+	}
+
+	// The working days leave out the holidays only, the available days the
+	// absences too.
+	available := people.availability
+	if available == nil {
+		available = &availabilityCalendar{}
+	}
+	working := &availabilityCalendar{holidays: available.holidays}
+
+	type teamPeriod struct {
+		people  int
+		working float64
+		days    float64
+		merged  int
+	}
+
+	calendar := loadCalendar()
+	type period struct{ start, end time.Time }
+	var periods []period
+	for start := initialDate; !start.After(endDate); {
+		_, next := calendar.period(start)
+		end := next.Add(-time.Second)
+		if end.After(endDate) {
+			end = endDate
+		}
+		periods = append(periods, period{start, end})
+		start = next
+	}
+
+	stats := make(map[string][]teamPeriod)
+	totals := make(map[string]*teamPeriod)
+	for identity, entry := range r.entries {
+		if entry.team == "" {
+			continue
+		}
+		if stats[entry.team] == nil {
+			stats[entry.team] = make([]teamPeriod, len(periods))
+			totals[entry.team] = &teamPeriod{}
+		}
+
+		identities := []string{identity}
+		if name, ok := knownName(identity); ok {
+			identities = append(identities, name)
+		}
+
+		for i, p := range periods {
+			if entry.startDate.After(p.end) {
+				continue
+			}
+			start := p.start
+			if entry.startDate.After(start) {
+				start = entry.startDate
+			}
+
+			s := &stats[entry.team][i]
+			s.people++
+			s.working += working.workingDays(start, p.end, identities...)
+			s.days += available.workingDays(start, p.end, identities...)
+		}
+	}
+
+	for _, pr := range prs {
+		if pr.stateAt(endDate) != prMerged {
+			continue
+		}
+
+		team := r.team(pr.Author.Login, names[pr.Author.Login])
+		for i, p := range periods {
+			if stats[team] != nil && !pr.MergedAt.Before(p.start) && !pr.MergedAt.After(p.end) {
+				stats[team][i].merged++
+			}
+		}
+	}
+
+	row := func(team, label string, s teamPeriod) table.Row {
+		perWeek, atFull := interface{}(notApplicable), interface{}(notApplicable)
+		if s.days > 0 {
+			perWeek = average(float64(s.merged) * 5 / s.days)
+			atFull = average(float64(s.merged) * s.working / s.days)
+		}
+		capacity := interface{}(notApplicable)
+		if s.working > 0 {
+			capacity = percent(s.days * 100 / s.working)
+		}
+
+		return table.Row{team, label, s.people, average(s.working), average(s.days), capacity, s.merged, perWeek, atFull}
+	}
+
+	section.Outputs = make(map[string]string)
+	for _, team := range sortedKeys(stats) {
+		total := totals[team]
+		for i, p := range periods {
+			s := stats[team][i]
+			section.Rows = append(section.Rows, row(team, formatDate(p.start)+" - "+formatDate(p.end), s))
+
+			total.people = max(total.people, s.people)
+			total.working += s.working
+			total.days += s.days
+			total.merged += s.merged
+		}
+
+		if total.days > 0 {
+			output := "github_velocity_" + strings.ToLower(strings.ReplaceAll(team, " ", "_"))
+			section.Outputs[output] = formatOutput(float64(total.merged) * 5 / total.days)
+		}
+	}
+
+	if len(totals) > 0 {
+		var all teamPeriod
+		for _, total := range totals {
+			all.people += total.people
+			all.working += total.working
+			all.days += total.days
+			all.merged += total.merged
+		}
+		section.Footer = row("All", formatDate(initialDate)+" - "+formatDate(endDate), all)
+	}
+
+	return section
+}
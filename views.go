@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	viewManager = "manager"
+	viewIC      = "ic"
+)
+
+// Set by --view or VIEW: manager is the comparative report, ic shows a single
+// person, VIEW_PERSON, against the median of the team, without naming anybody
+// else.
+var reportView = viewManager
+
+func loadView(name string) string {
+	if name == "" {
+		name = getenv("VIEW")
+	}
+	if name == "" {
+		return viewManager
+	}
+
+	if name != viewManager && name != viewIC {
+		fatalf(exitConfig, "Unknown view %q, expected manager or ic", name)
+	}
+
+	if name == viewIC && getenv("VIEW_PERSON") == "" {
+		fatalf(exitConfig, "The ic view needs VIEW_PERSON, the GitHub login or Jira name of the person it is for")
+	}
+
+	return name
+}
+
+// VIEW_PERSON is a comma-separated list of the GitHub login and Jira name of
+// the person of the ic view, the name known for the login included.
+func viewPeople() map[string]bool {
+	people := make(map[string]bool)
+	for _, person := range statusList(getenv("VIEW_PERSON")) {
+		people[strings.ToLower(person)] = true
+		if name, ok := knownName(person); ok && name != "" {
+			people[strings.ToLower(name)] = true
+		}
+	}
+
+	return people
+}
+
+// The sections that only compare people with each other.
+var crossPersonSections = map[string]bool{
+	"Review pairs":  true,
+	"Review matrix": true,
+	"Leaderboards":  true,
+}
+
+// The columns naming a person in the lists of PRs and issues.
+var personColumns = map[string]bool{
+	"Author":   true,
+	"Assignee": true,
+	"Reviewer": true,
+}
+
+const teamMedianLabel = "Team median"
+
+// Turns the sections into the ic view: the tables with a row per person keep
+// the one of VIEW_PERSON and a team median row, the lists keep its rows, the
+// charts per person and the comparisons between people are left out.
+func icView(sections []*reportSection) []*reportSection {
+	if reportView != viewIC {
+		return sections
+	}
+
+	people := viewPeople()
+	named := func(cell interface{}) bool {
+		s, ok := cell.(string)
+		return ok && people[strings.ToLower(s)]
+	}
+
+	var kept []*reportSection
+	for _, s := range sections {
+		if crossPersonSections[s.Name] {
+			continue
+		}
+		if len(s.Header) == 0 {
+			kept = append(kept, s)
+			continue
+		}
+
+		switch fmt.Sprint(s.Header[0]) {
+		case "ID", "Name", "Person":
+			// The GitHub tables have the login, then the name.
+			labels := 1
+			if fmt.Sprint(s.Header[0]) == "ID" && len(s.Header) > 1 && fmt.Sprint(s.Header[1]) == "Name" {
+				labels = 2
+			}
+
+			var rows []table.Row
+			everyone := make(map[string]bool)
+			for _, row := range s.Rows {
+				mine := false
+				for i := 0; i < labels && i < len(row); i++ {
+					everyone[strings.ToLower(fmt.Sprint(row[i]))] = true
+					mine = mine || named(row[i])
+				}
+				if mine {
+					rows = append(rows, row)
+				}
+			}
+
+			if len(s.Rows) > 0 {
+				rows = append(rows, teamMedianRow(s.Rows, labels))
+			}
+			s.Rows = rows
+
+			var charts []barChart
+			for _, chart := range s.Charts {
+				perPerson := false
+				for _, label := range chart.Labels {
+					perPerson = perPerson || everyone[strings.ToLower(label)]
+				}
+				if !perPerson {
+					charts = append(charts, chart)
+				}
+			}
+			s.Charts = charts
+
+		default:
+			column := -1
+			for i, header := range s.Header {
+				if i > 0 && personColumns[fmt.Sprint(header)] {
+					column = i
+				}
+			}
+			if column < 0 {
+				break
+			}
+
+			var rows []table.Row
+			for _, row := range s.Rows {
+				if column < len(row) && named(row[column]) {
+					rows = append(rows, row)
+				}
+			}
+			s.Rows = rows
+		}
+
+		kept = append(kept, s)
+	}
+
+	return kept
+}
+
+// The median of every numeric column of the rows, in the type of its cells so
+// it reads like them.
+func teamMedianRow(rows []table.Row, labels int) table.Row {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	median := make(table.Row, width)
+	median[0] = teamMedianLabel
+	for i := 1; i < labels && i < width; i++ {
+		median[i] = ""
+	}
+
+	for column := labels; column < width; column++ {
+		var values []float64
+		var sample interface{}
+		for _, row := range rows {
+			if column >= len(row) {
+				continue
+			}
+			cell := row[column]
+			if f, ok := cell.(flagged); ok {
+				cell = f.value
+			}
+			if value, ok := cellNumber(cell); ok {
+				if _, isString := cell.(string); !isString {
+					values = append(values, value)
+					sample = cell
+				}
+			}
+		}
+
+		value := percentile(values, 50)
+		if len(values) == 0 || math.IsNaN(value) {
+			median[column] = notApplicable
+			continue
+		}
+
+		switch sample.(type) {
+		case percent:
+			median[column] = percent(value)
+		case duration, time.Duration:
+			median[column] = duration(value)
+		default:
+			median[column] = average(value)
+		}
+	}
+
+	return median
+}
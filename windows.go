@@ -0,0 +1,145 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Set by --windows: the comma-separated windows reported from a single fetch,
+// as months like 2024-01 or ranges like 2024-01-01..2024-01-15.
+var reportWindows string
+
+type labeledWindow struct {
+	label string
+	window
+}
+
+func parseWindows(list string) []labeledWindow {
+	var windows []labeledWindow
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if start, end, ok := strings.Cut(item, ".."); ok {
+			initialDate, err := time.Parse("2006-1-2", start)
+			if err != nil {
+				fatalf(exitConfig, "Invalid window %q in --windows: %v", item, err)
+			}
+			endDate, err := time.Parse("2006-1-2", end)
+			if err != nil {
+				fatalf(exitConfig, "Invalid window %q in --windows: %v", item, err)
+			}
+			windows = append(windows, labeledWindow{item, window{initialDate, endDate.Add(time.Hour*24 - time.Second)}})
+			continue
+		}
+
+		month, err := time.Parse("2006-1", item)
+		if err != nil {
+			fatalf(exitConfig, "Invalid window %q in --windows: expected a month like 2024-01 or a range like 2024-01-01..2024-01-15", item)
+		}
+		windows = append(windows, labeledWindow{item, window{month, month.AddDate(0, 1, 0).Add(-time.Second)}})
+	}
+
+	if len(windows) == 0 {
+		fatalf(exitConfig, "--windows has no windows")
+	}
+
+	return windows
+}
+
+// The window covering all of them, which is fetched once.
+func windowsSpan(windows []labeledWindow) (time.Time, time.Time) {
+	initialDate, endDate := windows[0].start, windows[0].end
+	for _, w := range windows[1:] {
+		if w.start.Before(initialDate) {
+			initialDate = w.start
+		}
+		if w.end.After(endDate) {
+			endDate = w.end
+		}
+	}
+
+	return initialDate, endDate
+}
+
+// The sections of every window, titled with its label, followed by a trend
+// table of their outputs side by side.
+func (data *fetchedData) windowsReport(windows []labeledWindow) *report {
+	initialDate, endDate := windowsSpan(windows)
+	rep := &report{InitialDate: initialDate, EndDate: endDate}
+
+	trend := &reportSection{
+		Name:   "Trend",
+		Title:  "Trend",
+		Header: table.Row{"Metric"},
+	}
+
+	values := make(map[string]table.Row)
+	for i, w := range windows {
+		trend.Header = append(trend.Header, w.label)
+		trend.Centered = append(trend.Centered, i+2)
+
+		for _, s := range data.sections(w.start, w.end) {
+			title := s.Title
+			if title == "" {
+				title = s.Name
+			}
+
+			for name, value := range s.Outputs {
+				if values[name] == nil {
+					values[name] = make(table.Row, len(windows))
+					for j := range values[name] {
+						values[name][j] = notApplicable
+					}
+				}
+				values[name][i] = value
+			}
+
+			s.Name = w.label + " " + s.Name
+			s.Title = w.label + ": " + tr(title)
+			s.Outputs = nil
+			rep.Sections = append(rep.Sections, s)
+		}
+	}
+
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		trend.Rows = append(trend.Rows, append(table.Row{name}, values[name]...))
+	}
+
+	// The annotations of each window, under its column.
+	notes := table.Row{"Annotations"}
+	annotated := false
+	for _, w := range windows {
+		var texts []string
+		for _, a := range annotationsIn(w.start, w.end) {
+			texts = append(texts, a.label())
+		}
+		if len(texts) == 0 {
+			notes = append(notes, "")
+			continue
+		}
+		notes = append(notes, strings.Join(texts, "; "))
+		annotated = true
+	}
+	if annotated {
+		trend.Footer = notes
+	}
+
+	if len(composeSections([]*reportSection{trend}, reportTemplate)) > 0 {
+		rep.Sections = append(rep.Sections, trend)
+	}
+
+	return rep
+}
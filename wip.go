@@ -0,0 +1,153 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// The time-weighted average and the peak of the intervals open at the same
+// time inside the window. An interval ending when another starts does not
+// overlap it.
+func concurrency(intervals []interval, initialDate, endDate time.Time) (float64, int) {
+	type change struct {
+		at    time.Time
+		delta int
+	}
+
+	var changes []change
+	var open time.Duration
+	for _, i := range intervals {
+		start, end := i.start, i.end
+		if start.Before(initialDate) {
+			start = initialDate
+		}
+		if end.After(endDate) {
+			end = endDate
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		changes = append(changes, change{start, 1}, change{end, -1})
+		open += end.Sub(start)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].at.Equal(changes[j].at) {
+			return changes[i].at.Before(changes[j].at)
+		}
+		return changes[i].delta < changes[j].delta
+	})
+
+	current, peak := 0, 0
+	for _, c := range changes {
+		current += c.delta
+		if current > peak {
+			peak = current
+		}
+	}
+
+	return float64(open) / float64(endDate.Sub(initialDate)), peak
+}
+
+// WIP_LIMIT flags the people whose peak goes over it.
+func wipCell(peak int) interface{} {
+	if limit := envInt("WIP_LIMIT", 0); limit > 0 && peak > limit {
+		return flagged{peak, warning}
+	}
+
+	return peak
+}
+
+func wipSection(name, title, key string, byPerson map[string][]interval, initialDate, endDate time.Time) *reportSection {
+	section := &reportSection{
+		Name:     name,
+		Title:    title,
+		Header:   table.Row{key, "Average WIP", "Peak WIP"},
+		Centered: []int{2, 3},
+	}
+
+	var people []string
+	for person := range byPerson {
+		people = append(people, person)
+	}
+	sort.Strings(people)
+
+	for _, person := range people {
+		mean, peak := concurrency(byPerson[person], initialDate, endDate)
+		if peak == 0 {
+			continue
+		}
+		section.Rows = append(section.Rows, table.Row{person, average(mean), wipCell(peak)})
+	}
+
+	return section
+}
+
+// The PRs each author had open at the same time during the window.
+func prWipSection(prs []pullRequest, initialDate, endDate time.Time) *reportSection {
+	byAuthor := make(map[string][]interval)
+	for _, pr := range prs {
+		if pr.CreatedAt.After(endDate) {
+			continue
+		}
+
+		end := endDate
+		if at, ok := pr.decidedAt(endDate); ok {
+			end = at
+		}
+		byAuthor[pr.Author.Login] = append(byAuthor[pr.Author.Login], interval{pr.CreatedAt, end})
+	}
+
+	return wipSection("PR WIP", "Open PRs at the same time", "ID", byAuthor, initialDate, endDate)
+}
+
+// The issues each person had in an active status at the same time during the
+// window, from the move into it by the person to the next status change.
+func jiraWipSection(issues []jiraIssue, initialDate, endDate time.Time) *reportSection {
+	byPerson := make(map[string][]interval)
+	for _, issue := range issues {
+		type move struct {
+			at     time.Time
+			by     string
+			status string
+		}
+
+		var moves []move
+		for _, history := range issue.Changelog.Histories {
+			created, err := time.Parse(jiraTimeLayout, history.Created)
+			if err != nil {
+				continue
+			}
+			for _, item := range history.Items {
+				if item.Field == "status" {
+					moves = append(moves, move{created, history.Author.DisplayName, item.ToString})
+				}
+			}
+		}
+		sort.SliceStable(moves, func(i, j int) bool {
+			return moves[i].at.Before(moves[j].at)
+		})
+
+		for i, m := range moves {
+			if !jiraActive(m.status) {
+				continue
+			}
+
+			end := endDate
+			if i+1 < len(moves) {
+				end = moves[i+1].at
+			}
+			byPerson[m.by] = append(byPerson[m.by], interval{m.at, end})
+		}
+	}
+
+	return wipSection("Jira WIP", "Issues In Progress at the same time", "Person", byPerson, initialDate, endDate)
+}